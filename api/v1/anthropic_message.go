@@ -1 +1,205 @@
 package v1
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// https://docs.anthropic.com/en/api/messages
+
+type (
+	AnthropicMessageRequest struct {
+		Model         string               `json:"model" binding:"required"`
+		Messages      []AnthropicMessage   `json:"messages" binding:"required"`
+		MaxTokens     int                  `json:"max_tokens" binding:"required"`
+		System        json.RawMessage      `json:"system,omitempty"` // string or array(AnthropicContent)
+		Metadata      *AnthropicMetadata   `json:"metadata,omitempty"`
+		StopSequences []string             `json:"stop_sequences,omitempty"`
+		Stream        bool                 `json:"stream,omitempty"`
+		Temperature   *float64             `json:"temperature,omitempty"`
+		TopP          *float64             `json:"top_p,omitempty"`
+		TopK          int                  `json:"top_k,omitempty"`
+		Tools         []AnthropicTool      `json:"tools,omitempty"`
+		ToolChoice    *AnthropicToolChoice `json:"tool_choice,omitempty"`
+		Thinking      *AnthropicThinking   `json:"thinking,omitempty"`
+	}
+	AnthropicMessage struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"` // string or array(AnthropicContent)
+	}
+	AnthropicContent struct {
+		Type string `json:"type"`
+		// text
+		Text string `json:"text,omitempty"`
+		// image / document
+		Source *AnthropicSource `json:"source,omitempty"`
+		// tool_use
+		ID    string          `json:"id,omitempty"`
+		Name  string          `json:"name,omitempty"`
+		Input json.RawMessage `json:"input,omitempty"`
+		// tool_result
+		ToolUseID string          `json:"tool_use_id,omitempty"`
+		Content   json.RawMessage `json:"content,omitempty"` // string or array(AnthropicContent)
+		IsError   bool            `json:"is_error,omitempty"`
+		// thinking
+		Thinking  string `json:"thinking,omitempty"`
+		Signature string `json:"signature,omitempty"`
+		// redacted_thinking
+		Data         string `json:"data,omitempty"`
+		CacheControl any    `json:"cache_control,omitempty"`
+	}
+	AnthropicSource struct {
+		Type      string `json:"type"` // base64, url, text
+		MediaType string `json:"media_type,omitempty"`
+		Data      string `json:"data,omitempty"`
+		URL       string `json:"url,omitempty"`
+	}
+	AnthropicMetadata struct {
+		UserID string `json:"user_id,omitempty"`
+	}
+	AnthropicTool struct {
+		Type         string          `json:"type,omitempty"`
+		Name         string          `json:"name" binding:"required"`
+		Description  string          `json:"description,omitempty"`
+		InputSchema  json.RawMessage `json:"input_schema,omitempty"`
+		CacheControl any             `json:"cache_control,omitempty"`
+	}
+	AnthropicToolChoice struct {
+		Type                   string `json:"type"` // auto, any, tool, none
+		Name                   string `json:"name,omitempty"`
+		DisableParallelToolUse bool   `json:"disable_parallel_tool_use,omitempty"`
+	}
+	AnthropicThinking struct {
+		Type         string `json:"type"` // enabled, disabled
+		BudgetTokens int    `json:"budget_tokens,omitempty"`
+	}
+)
+
+type (
+	AnthropicMessageResponse struct {
+		ID           string             `json:"id"`
+		Type         string             `json:"type"`
+		Role         string             `json:"role"`
+		Model        string             `json:"model"`
+		Content      []AnthropicContent `json:"content"`
+		StopReason   string             `json:"stop_reason,omitempty"` // end_turn, max_tokens, stop_sequence, tool_use
+		StopSequence string             `json:"stop_sequence,omitempty"`
+		Usage        *AnthropicUsage    `json:"usage,omitempty"`
+	}
+	AnthropicUsage struct {
+		InputTokens              int `json:"input_tokens"`
+		OutputTokens             int `json:"output_tokens"`
+		CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+		CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+	}
+	AnthropicError struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	}
+	AnthropicErrorResponse struct {
+		Type  string         `json:"type"` // error
+		Error AnthropicError `json:"error"`
+	}
+)
+
+// streaming
+type (
+	AnthropicStreamEvent struct {
+		Type         string                    `json:"type"`
+		Message      *AnthropicMessageResponse `json:"message,omitempty"`
+		Index        *int                      `json:"index,omitempty"`
+		ContentBlock *AnthropicContent         `json:"content_block,omitempty"`
+		Delta        *AnthropicDelta           `json:"delta,omitempty"`
+		Usage        *AnthropicUsage           `json:"usage,omitempty"`
+		Error        *AnthropicError           `json:"error,omitempty"`
+	}
+	AnthropicDelta struct {
+		Type         string `json:"type,omitempty"` // text_delta, input_json_delta, thinking_delta, signature_delta
+		Text         string `json:"text,omitempty"`
+		PartialJson  string `json:"partial_json,omitempty"`
+		Thinking     string `json:"thinking,omitempty"`
+		Signature    string `json:"signature,omitempty"`
+		StopReason   string `json:"stop_reason,omitempty"`
+		StopSequence string `json:"stop_sequence,omitempty"`
+	}
+)
+
+const (
+	AnthropicContentTypeText             = "text"
+	AnthropicContentTypeImage            = "image"
+	AnthropicContentTypeDocument         = "document"
+	AnthropicContentTypeToolUse          = "tool_use"
+	AnthropicContentTypeToolResult       = "tool_result"
+	AnthropicContentTypeThinking         = "thinking"
+	AnthropicContentTypeRedactedThinking = "redacted_thinking"
+)
+
+const (
+	AnthropicEventMessageStart      = "message_start"
+	AnthropicEventMessageDelta      = "message_delta"
+	AnthropicEventMessageStop       = "message_stop"
+	AnthropicEventContentBlockStart = "content_block_start"
+	AnthropicEventContentBlockDelta = "content_block_delta"
+	AnthropicEventContentBlockStop  = "content_block_stop"
+	AnthropicEventPing              = "ping"
+	AnthropicEventError             = "error"
+)
+
+// MarshalJSON text/thinking 块即使为空也需要输出对应字段, 流式 content_block_start 依赖这一点
+func (c AnthropicContent) MarshalJSON() ([]byte, error) {
+	type alias AnthropicContent
+	switch c.Type {
+	case AnthropicContentTypeText:
+		return json.Marshal(struct {
+			alias
+			Text string `json:"text"`
+		}{alias(c), c.Text})
+	case AnthropicContentTypeThinking:
+		return json.Marshal(struct {
+			alias
+			Thinking string `json:"thinking"`
+		}{alias(c), c.Thinking})
+	default:
+		return json.Marshal(alias(c))
+	}
+}
+
+func (m *AnthropicMessage) IsStringContent() bool {
+	var stringContent string
+	if err := json.Unmarshal(m.Content, &stringContent); err == nil {
+		return true
+	}
+	return false
+}
+
+func (m *AnthropicMessage) ParseContent() ([]AnthropicContent, error) {
+	return parseAnthropicContent(m.Content)
+}
+
+// ParseSystem system 字段可以是字符串或 text 块数组
+func (r *AnthropicMessageRequest) ParseSystem() ([]AnthropicContent, error) {
+	if len(r.System) == 0 || string(r.System) == "null" {
+		return nil, nil
+	}
+	return parseAnthropicContent(r.System)
+}
+
+// ParseContent tool_result 的 content 可以是字符串或内容块数组
+func (c *AnthropicContent) ParseContent() ([]AnthropicContent, error) {
+	if len(c.Content) == 0 || string(c.Content) == "null" {
+		return nil, nil
+	}
+	return parseAnthropicContent(c.Content)
+}
+
+func parseAnthropicContent(raw json.RawMessage) ([]AnthropicContent, error) {
+	var stringContent string
+	if err := json.Unmarshal(raw, &stringContent); err == nil {
+		return []AnthropicContent{{Type: AnthropicContentTypeText, Text: stringContent}}, nil
+	}
+	var contentList []AnthropicContent
+	if err := json.Unmarshal(raw, &contentList); err != nil {
+		return nil, fmt.Errorf("parse content error: %w", err)
+	}
+	return contentList, nil
+}
@@ -41,6 +41,10 @@ type (
 		TopP              float64         `json:"top_p,omitempty"`
 		User              string          `json:"user,omitempty"`
 		WebSearchOptions  any             `json:"web_search_options,omitempty"`
+		// ExtraBody 供应商特有字段(如 vLLM 的 top_k、guided_json), 序列化时合并到请求体顶层
+		ExtraBody map[string]any `json:"-"`
+		// UnknownFields 解析时遇到的其余未声明字段, 不会发往上游, 由需要透传的客户端(如 vLLM)自行合并
+		UnknownFields map[string]any `json:"-"`
 	}
 )
 
@@ -89,6 +93,71 @@ const (
 	ContentTypeFile       = "file"
 )
 
+const (
+	ToolChoiceAuto     = "auto"
+	ToolChoiceNone     = "none"
+	ToolChoiceRequired = "required"
+	// ToolChoiceFunction 指定调用某个函数
+	ToolChoiceFunction = "function"
+)
+
+// MaxOutputTokens 优先使用 max_completion_tokens, 其次是已废弃的 max_tokens
+func (r *ChatCompletionRequest) MaxOutputTokens() int {
+	if r.MaxCompletionTokens > 0 {
+		return r.MaxCompletionTokens
+	}
+	return r.MaxTokens
+}
+
+// StopSequences stop 可以是字符串或字符串数组
+func (r *ChatCompletionRequest) StopSequences() []string {
+	return parseStop(r.Stop)
+}
+
+// ParseToolChoice 返回 tool_choice 的类型(auto/none/required/function)以及指定的函数名
+func (r *ChatCompletionRequest) ParseToolChoice() (choiceType string, name string) {
+	switch choice := r.ToolChoice.(type) {
+	case nil:
+		return "", ""
+	case string:
+		return choice, ""
+	default:
+		data, err := json.Marshal(choice)
+		if err != nil {
+			return "", ""
+		}
+		var toolChoice ToolChoice
+		if err := json.Unmarshal(data, &toolChoice); err != nil || toolChoice.Function == nil {
+			return "", ""
+		}
+		return ToolChoiceFunction, toolChoice.Function.Name
+	}
+}
+
+func parseStop(stop any) []string {
+	switch s := stop.(type) {
+	case nil:
+		return nil
+	case string:
+		if s == "" {
+			return nil
+		}
+		return []string{s}
+	case []string:
+		return s
+	case []any:
+		stops := make([]string, 0, len(s))
+		for _, item := range s {
+			if str, ok := item.(string); ok && str != "" {
+				stops = append(stops, str)
+			}
+		}
+		return stops
+	default:
+		return nil
+	}
+}
+
 func (m *Message) StringContent() string {
 	var stringContent string
 	if err := json.Unmarshal(m.Content, &stringContent); err == nil {
@@ -32,8 +32,8 @@ type (
 		Id       string   `json:"id,omitempty"`
 		Type     string   `json:"type"`
 		Function Function `json:"function"`
-		// streaming
-		Index int `json:"index,omitempty"`
+		// streaming, 使用指针以便输出 index 为 0 的增量
+		Index *int `json:"index,omitempty"`
 	}
 	WebSearchOptions struct {
 		SearchContentSize string        `json:"search_content_size,omitempty" binding:"omitempty,oneof=low medium high,default=medium"`
@@ -82,6 +82,7 @@ type (
 	}
 	Reasoning struct {
 		Effect          string `json:"effect,omitempty"`
+		Effort          string `json:"effort,omitempty"` // low, medium, high
 		GenerateSummary string `json:"generate_summary,omitempty"`
 		Summary         string `json:"summary,omitempty"`
 	}
@@ -123,3 +124,11 @@ type (
 		ReasoningTokens int `json:"reasoning_tokens"`
 	}
 )
+
+// IndexOr 返回流式增量中的 index, 未设置时返回 def
+func (t ToolCall) IndexOr(def int) int {
+	if t.Index == nil {
+		return def
+	}
+	return *t.Index
+}
@@ -28,6 +28,10 @@ type CompletionsRequest struct {
 	Template  string   `json:"template,omitempty"`
 	Raw       bool     `json:"raw,omitempty"`
 	KeepAlive string   `json:"keep_alive,omitempty"`
+	// ExtraBody 供应商特有字段, 序列化时合并到请求体顶层
+	ExtraBody map[string]any `json:"-"`
+	// UnknownFields 解析时遇到的其余未声明字段, 不会发往上游
+	UnknownFields map[string]any `json:"-"`
 }
 
 type (
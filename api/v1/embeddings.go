@@ -1,5 +1,7 @@
 package v1
 
+import "fmt"
+
 type (
 	EmbeddingsRequest struct {
 		Input          any    `json:"input"`
@@ -7,6 +9,8 @@ type (
 		Dimensions     int    `json:"dimensions,omitempty"`
 		EncodingFormat string `json:"encoding_format,omitempty" binding:"omitempty,oneof=base64 float,default=float"`
 		User           string `json:"user,omitempty"`
+		// InputType 部分服务商(如 Cohere)需要区分文档与查询, 如 search_document、search_query
+		InputType string `json:"input_type,omitempty"`
 	}
 )
 
@@ -24,3 +28,25 @@ type (
 		Embedding []any  `json:"embedding,omitempty"`
 	}
 )
+
+// InputStrings input 可以是字符串或字符串数组, token 数组形式的输入不支持转换
+func (r *EmbeddingsRequest) InputStrings() ([]string, error) {
+	switch input := r.Input.(type) {
+	case string:
+		return []string{input}, nil
+	case []string:
+		return input, nil
+	case []any:
+		inputs := make([]string, 0, len(input))
+		for _, item := range input {
+			str, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("unsupported input item type: %T", item)
+			}
+			inputs = append(inputs, str)
+		}
+		return inputs, nil
+	default:
+		return nil, fmt.Errorf("unsupported input type: %T", r.Input)
+	}
+}
@@ -0,0 +1,135 @@
+package v1
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ExtraBodyKey 显式携带供应商特有字段, 与 OpenAI SDK 的 extra_body 对应
+const ExtraBodyKey = "extra_body"
+
+var jsonFieldCache sync.Map // reflect.Type -> map[string]struct{}
+
+// jsonFieldNames 返回结构体已声明的 json 字段名
+func jsonFieldNames(t reflect.Type) map[string]struct{} {
+	if names, ok := jsonFieldCache.Load(t); ok {
+		return names.(map[string]struct{})
+	}
+	names := make(map[string]struct{}, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = struct{}{}
+	}
+	jsonFieldCache.Store(t, names)
+	return names
+}
+
+// splitExtraBody 分别收集 extra_body 中的字段与请求体中其余未声明的顶层字段
+// 字段值保留为 json.RawMessage, 避免大整数等精度丢失
+func splitExtraBody(data []byte, t reflect.Type) (extra map[string]any, unknown map[string]any, err error) {
+	var raw map[string]json.RawMessage
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, err
+	}
+	known := jsonFieldNames(t)
+	for key, value := range raw {
+		if _, ok := known[key]; ok {
+			continue
+		}
+		if key == ExtraBodyKey {
+			var body map[string]json.RawMessage
+			if err = json.Unmarshal(value, &body); err != nil {
+				return nil, nil, err
+			}
+			if len(body) > 0 && extra == nil {
+				extra = make(map[string]any, len(body))
+			}
+			for k, v := range body {
+				extra[k] = v
+			}
+			continue
+		}
+		if unknown == nil {
+			unknown = make(map[string]any)
+		}
+		unknown[key] = value
+	}
+	return extra, unknown, nil
+}
+
+// mergeExtraBody 将额外字段合并到序列化结果中, 已声明的字段优先
+func mergeExtraBody(data []byte, extra map[string]any) ([]byte, error) {
+	if len(extra) == 0 {
+		return data, nil
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	for key, value := range extra {
+		if _, ok := raw[key]; ok {
+			continue
+		}
+		valueBytes, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		raw[key] = valueBytes
+	}
+	return json.Marshal(raw)
+}
+
+type chatCompletionRequest ChatCompletionRequest
+
+func (r ChatCompletionRequest) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(chatCompletionRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	return mergeExtraBody(data, r.ExtraBody)
+}
+
+func (r *ChatCompletionRequest) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*chatCompletionRequest)(r)); err != nil {
+		return err
+	}
+	extra, unknown, err := splitExtraBody(data, reflect.TypeOf(chatCompletionRequest{}))
+	if err != nil {
+		return err
+	}
+	r.ExtraBody = extra
+	r.UnknownFields = unknown
+	return nil
+}
+
+type completionsRequest CompletionsRequest
+
+func (r CompletionsRequest) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(completionsRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	return mergeExtraBody(data, r.ExtraBody)
+}
+
+func (r *CompletionsRequest) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*completionsRequest)(r)); err != nil {
+		return err
+	}
+	extra, unknown, err := splitExtraBody(data, reflect.TypeOf(completionsRequest{}))
+	if err != nil {
+		return err
+	}
+	r.ExtraBody = extra
+	r.UnknownFields = unknown
+	return nil
+}
@@ -19,8 +19,9 @@ type (
 		PreviousResponseId string          `json:"previous_response_id,omitempty"`
 		Reasoning          *Reasoning      `json:"reasoning,omitempty"`
 		ServiceTier        string          `json:"service_tier,omitempty"`
-		Store              bool            `json:"store,omitempty"`
-		Steam              bool            `json:"steam,omitempty"`
+		Store              *bool           `json:"store,omitempty"` // 未设置时上游默认为 true
+		Steam              bool            `json:"steam,omitempty"` // 拼写错误, 保留兼容, 使用 Stream
+		Stream             bool            `json:"stream,omitempty"`
 		Temperature        float64         `json:"temperature,omitempty"`
 		Text               *ResponsesText  `json:"text,omitempty"`
 		ToolChoice         json.RawMessage `json:"tool_choice,omitempty"` // string or object(ToolChoice)
@@ -36,6 +37,8 @@ type (
 		Schema      any    `json:"schema,omitempty"`
 		Description string `json:"description,omitempty"`
 		Strict      bool   `json:"strict,omitempty"`
+		// 官方格式为 {"format": {...}}, 兼容旧的扁平格式
+		Format *ResponsesText `json:"format,omitempty"`
 	}
 	ResponsesTool struct {
 		Type string `json:"type"`
@@ -88,6 +91,24 @@ func (r *ResponsesRequest) StringInput() string {
 	return string(r.Input)
 }
 
+func (r *ResponsesRequest) IsStream() bool {
+	return r.Stream || r.Steam
+}
+
+// TextFormat 返回 text.format, 兼容扁平格式
+func (r *ResponsesRequest) TextFormat() *ResponsesText {
+	if r.Text == nil {
+		return nil
+	}
+	if r.Text.Format != nil {
+		return r.Text.Format
+	}
+	if r.Text.Type == "" {
+		return nil
+	}
+	return r.Text
+}
+
 func (r *ResponsesRequest) ParseInput() ([]ResponsesInput, error) {
 	var err error
 	var inputList []ResponsesInput
@@ -95,7 +116,7 @@ func (r *ResponsesRequest) ParseInput() ([]ResponsesInput, error) {
 		inputList = append(inputList, ResponsesInput{
 			ID:      "input",
 			Type:    MessageInputType,
-			Content: json.RawMessage(r.StringInput()),
+			Content: r.Input,
 			Role:    "user",
 		})
 		return inputList, nil
@@ -108,9 +129,9 @@ func (r *ResponsesRequest) ParseInput() ([]ResponsesInput, error) {
 }
 
 type ResponsesInput struct {
-	ID      string          `json:"id"`
+	ID      string          `json:"id,omitempty"`
 	Type    string          `json:"type,omitempty"`
-	Content json.RawMessage `json:"content"` // string or array(InputContent)
+	Content json.RawMessage `json:"content,omitempty"` // string or array(InputContent)
 	Role    string          `json:"role,omitempty"`
 	Status  string          `json:"status,omitempty"`
 	// File Search Tool
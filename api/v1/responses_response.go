@@ -33,11 +33,40 @@ type (
 		Type     string             `json:"type"`
 		Response *ResponsesResponse `json:"response"`
 	}
+	// ResponsesStreamEvent 流式事件, 按 Type 使用不同字段
+	ResponsesStreamEvent struct {
+		Type           string             `json:"type"`
+		SequenceNumber int                `json:"sequence_number"`
+		Response       *ResponsesResponse `json:"response,omitempty"`
+		OutputIndex    *int               `json:"output_index,omitempty"`
+		ContentIndex   *int               `json:"content_index,omitempty"`
+		ItemID         string             `json:"item_id,omitempty"`
+		Item           *ResponsesOutput   `json:"item,omitempty"`
+		Part           *InputContent      `json:"part,omitempty"`
+		Delta          string             `json:"delta,omitempty"`
+		Text           string             `json:"text,omitempty"`
+		Arguments      string             `json:"arguments,omitempty"`
+	}
+)
+
+const (
+	ResponseCreatedEvent                    = "response.created"
+	ResponseInProgressEvent                 = "response.in_progress"
+	ResponseCompletedEvent                  = "response.completed"
+	ResponseIncompleteEvent                 = "response.incomplete"
+	ResponseOutputItemAddedEvent            = "response.output_item.added"
+	ResponseOutputItemDoneEvent             = "response.output_item.done"
+	ResponseContentPartAddedEvent           = "response.content_part.added"
+	ResponseContentPartDoneEvent            = "response.content_part.done"
+	ResponseOutputTextDeltaEvent            = "response.output_text.delta"
+	ResponseOutputTextDoneEvent             = "response.output_text.done"
+	ResponseFunctionCallArgumentsDeltaEvent = "response.function_call_arguments.delta"
+	ResponseFunctionCallArgumentsDoneEvent  = "response.function_call_arguments.done"
 )
 
 type (
 	IncompleteDetails struct {
-		Reasoning string `json:"reasoning"`
+		Reason string `json:"reason"` // max_output_tokens, content_filter
 	}
 	ResponsesOutput struct {
 		ID      string          `json:"id"`
@@ -0,0 +1,196 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/constant"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type Client struct {
+	*base.Client
+	baseUrl string
+	Version string // anthropic-version 请求头
+}
+
+func NewClient(endPoint, apiKey string) *Client {
+	if endPoint == "" {
+		endPoint = constant.AnthropicDefaultURL
+	}
+	endPoint = strings.TrimSpace(endPoint)
+	endPoint = strings.TrimRight(endPoint, "/")
+	return &Client{
+		Client:  base.NewClient(endPoint+"/v1", apiKey),
+		baseUrl: endPoint,
+		Version: constant.AnthropicVersion,
+	}
+}
+
+func (c *Client) generateHeader() http.Header {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("x-api-key", c.APIKey)
+	header.Set("anthropic-version", c.Version)
+	return header
+}
+
+func (c *Client) RelayRequest(ctx context.Context, method, targetPath string, body io.ReadCloser, header http.Header) (io.ReadCloser, http.Header, error) {
+	header.Del("Authorization")
+	header.Set("x-api-key", c.APIKey)
+	if header.Get("anthropic-version") == "" {
+		header.Set("anthropic-version", c.Version)
+	}
+	return base.Relay(ctx, method, c.baseUrl+targetPath, body, header, c.HTTPClient())
+}
+
+// CreateMessages 直接调用 /v1/messages 接口
+func (c *Client) CreateMessages(ctx context.Context, req *v1.AnthropicMessageRequest) (io.ReadCloser, http.Header, error) {
+	reqBytes, err := sonic.Marshal(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	targetUrl := c.EndPoint + "/messages"
+	body := io.NopCloser(bytes.NewReader(reqBytes))
+	return base.RelayWithCheck(ctx, http.MethodPost, targetUrl, body, c.generateHeader(), c.HTTPClient())
+}
+
+func (c *Client) CreateChatCompletions(ctx context.Context, req *v1.ChatCompletionRequest) (io.ReadCloser, http.Header, error) {
+	newReq, err := ConvertChatRequest(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	respBody, _, err := c.CreateMessages(ctx, newReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.Stream {
+		includeUsage := req.StreamOptions != nil && req.StreamOptions.IncludeUsage
+		return ConvertStreamResponse(respBody, includeUsage), base.NewStreamHeader(), nil
+	}
+	return convertNoStreamResponse(respBody)
+}
+
+func convertNoStreamResponse(respBody io.ReadCloser) (io.ReadCloser, http.Header, error) {
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read all error: %w", err)
+	}
+	var resp v1.AnthropicMessageResponse
+	if err = sonic.Unmarshal(respBytes, &resp); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	newResp := ConvertChatResponse(&resp)
+	newRespBytes, err := sonic.Marshal(newResp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(newRespBytes)), base.NewJsonHeader(), nil
+}
+
+type anthropicModel struct {
+	Type        string    `json:"type"`
+	ID          string    `json:"id"`
+	DisplayName string    `json:"display_name"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type anthropicModelList struct {
+	Data    []anthropicModel `json:"data"`
+	HasMore bool             `json:"has_more"`
+	LastID  string           `json:"last_id"`
+}
+
+func (c *Client) Models(ctx context.Context) (*v1.ModelResponse, error) {
+	resp := &v1.ModelResponse{Object: "list", Data: make([]v1.Model, 0)}
+	afterID := ""
+	for {
+		targetUrl := c.EndPoint + "/models?limit=1000"
+		if afterID != "" {
+			targetUrl += "&after_id=" + afterID
+		}
+		body, _, err := base.RelayWithCheck(ctx, http.MethodGet, targetUrl, nil, c.generateHeader(), c.HTTPClient())
+		if err != nil {
+			return nil, err
+		}
+		dataBytes, err := io.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read all error: %w", err)
+		}
+		var list anthropicModelList
+		if err = sonic.Unmarshal(dataBytes, &list); err != nil {
+			return nil, fmt.Errorf("unmarshal error: %w", err)
+		}
+		for _, m := range list.Data {
+			resp.Data = append(resp.Data, v1.Model{
+				ID:      m.ID,
+				Object:  "model",
+				Created: m.CreatedAt.Unix(),
+				OwnedBy: "anthropic",
+			})
+		}
+		if !list.HasMore || list.LastID == "" {
+			break
+		}
+		afterID = list.LastID
+	}
+	return resp, nil
+}
+
+func (c *Client) CreateResponses(ctx context.Context, req *v1.ResponsesRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateCompletions(ctx context.Context, req *v1.CompletionsRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateEmbeddings(ctx context.Context, req *v1.EmbeddingsRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateRerank(ctx context.Context, req *v1.RerankRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImage(ctx context.Context, req *v1.ImageGenerateRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImageEdit(ctx context.Context, req *v1.ImageEditRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImageVariation(ctx context.Context, req *v1.ImageVariationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateSpeech(ctx context.Context, req *v1.AudioSpeechRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateTranslation(ctx context.Context, req *v1.TranslationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateTranscription(ctx context.Context, req *v1.TranscriptionRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateVideoSubmit(ctx context.Context, req *v1.VideoRequest) (*v1.VideoResponse, error) {
+	_, _, err := base.NoImplementMethod(ctx, req)
+	return nil, err
+}
+
+func (c *Client) GetVideoStatus(ctx context.Context, externalID string) (bool, any, error) {
+	_, _, err := base.NoImplementMethod(ctx, externalID)
+	return false, nil, err
+}
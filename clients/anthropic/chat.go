@@ -0,0 +1,462 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/tools"
+	"io"
+	"strings"
+	"time"
+)
+
+// DefaultMaxTokens max_tokens 是 Anthropic 的必填参数, 请求中未设置时使用该值
+var DefaultMaxTokens = 4096
+
+var emptyInputSchema = json.RawMessage(`{"type":"object","properties":{}}`)
+
+// ConvertChatRequest 将 ChatCompletionRequest 转换为 Messages 请求
+func ConvertChatRequest(req *v1.ChatCompletionRequest) (*v1.AnthropicMessageRequest, error) {
+	newReq := &v1.AnthropicMessageRequest{
+		Model:         req.Model,
+		MaxTokens:     req.MaxOutputTokens(),
+		StopSequences: req.StopSequences(),
+		Stream:        req.Stream,
+	}
+	if newReq.MaxTokens <= 0 {
+		newReq.MaxTokens = DefaultMaxTokens
+	}
+	if req.Temperature != 0 {
+		temperature := min(req.Temperature, 1)
+		newReq.Temperature = &temperature
+	}
+	if req.TopP != 0 {
+		topP := req.TopP
+		newReq.TopP = &topP
+	}
+	if req.User != "" {
+		newReq.Metadata = &v1.AnthropicMetadata{UserID: req.User}
+	}
+
+	var system []v1.AnthropicContent
+	messages := make([]v1.AnthropicMessage, 0, len(req.Messages))
+	// 连续相同角色的消息需要合并, tool 结果需放在 user 消息中
+	var curRole string
+	var curContents []v1.AnthropicContent
+	flush := func() error {
+		if len(curContents) == 0 {
+			return nil
+		}
+		content, err := json.Marshal(curContents)
+		if err != nil {
+			return fmt.Errorf("marshal content error: %w", err)
+		}
+		messages = append(messages, v1.AnthropicMessage{Role: curRole, Content: content})
+		curContents = nil
+		return nil
+	}
+	for i := range req.Messages {
+		msg := &req.Messages[i]
+		var role string
+		var contents []v1.AnthropicContent
+		var err error
+		switch msg.Role {
+		case "system", "developer":
+			parts, err := messageToContents(msg)
+			if err != nil {
+				return nil, err
+			}
+			for _, part := range parts {
+				if part.Type == v1.AnthropicContentTypeText && part.Text != "" {
+					system = append(system, part)
+				}
+			}
+			continue
+		case "tool":
+			role = "user"
+			contents = []v1.AnthropicContent{toolResultContent(msg)}
+		case "assistant":
+			role = "assistant"
+			contents, err = assistantToContents(msg)
+		default:
+			role = "user"
+			contents, err = messageToContents(msg)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if role != curRole {
+			if err = flush(); err != nil {
+				return nil, err
+			}
+			curRole = role
+		}
+		curContents = append(curContents, contents...)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	newReq.Messages = messages
+	if len(system) > 0 {
+		systemBytes, err := json.Marshal(system)
+		if err != nil {
+			return nil, fmt.Errorf("marshal system error: %w", err)
+		}
+		newReq.System = systemBytes
+	}
+
+	for _, tool := range req.Tools {
+		if tool.Type != "" && tool.Type != "function" {
+			continue
+		}
+		schema := tool.Function.Parameters
+		if len(schema) == 0 || string(schema) == "null" {
+			schema = emptyInputSchema
+		}
+		newReq.Tools = append(newReq.Tools, v1.AnthropicTool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: schema,
+		})
+	}
+	if len(newReq.Tools) > 0 {
+		switch choiceType, name := req.ParseToolChoice(); choiceType {
+		case v1.ToolChoiceAuto:
+			newReq.ToolChoice = &v1.AnthropicToolChoice{Type: "auto"}
+		case v1.ToolChoiceRequired:
+			newReq.ToolChoice = &v1.AnthropicToolChoice{Type: "any"}
+		case v1.ToolChoiceNone:
+			newReq.ToolChoice = &v1.AnthropicToolChoice{Type: "none"}
+		case v1.ToolChoiceFunction:
+			newReq.ToolChoice = &v1.AnthropicToolChoice{Type: "tool", Name: name}
+		}
+	}
+	return newReq, nil
+}
+
+func messageToContents(msg *v1.Message) ([]v1.AnthropicContent, error) {
+	if len(msg.Content) == 0 || string(msg.Content) == "null" {
+		return nil, nil
+	}
+	mediaContents, err := msg.ParseContent()
+	if err != nil {
+		return nil, err
+	}
+	contents := make([]v1.AnthropicContent, 0, len(mediaContents))
+	for _, mediaContent := range mediaContents {
+		switch mediaContent.Type {
+		case v1.ContentTypeText:
+			if mediaContent.Text == "" {
+				continue
+			}
+			contents = append(contents, v1.AnthropicContent{Type: v1.AnthropicContentTypeText, Text: mediaContent.Text})
+		case v1.ContentTypeImageURL:
+			if mediaContent.ImageUrl == nil || mediaContent.ImageUrl.Url == "" {
+				return nil, errors.New("image_url is empty")
+			}
+			contents = append(contents, v1.AnthropicContent{
+				Type:   v1.AnthropicContentTypeImage,
+				Source: urlToSource(mediaContent.ImageUrl.Url),
+			})
+		case v1.ContentTypeFile:
+			if mediaContent.File == nil {
+				return nil, errors.New("file is empty")
+			}
+			fileData, _ := mediaContent.File.FileData.(string)
+			mimeType, data, ok := tools.ParseDataURL(fileData)
+			if !ok {
+				return nil, errors.New("only base64 file_data is supported")
+			}
+			contents = append(contents, v1.AnthropicContent{
+				Type:   v1.AnthropicContentTypeDocument,
+				Source: &v1.AnthropicSource{Type: "base64", MediaType: mimeType, Data: data},
+			})
+		default:
+			return nil, fmt.Errorf("unsupported content type: %s", mediaContent.Type)
+		}
+	}
+	return contents, nil
+}
+
+func urlToSource(url string) *v1.AnthropicSource {
+	if mimeType, data, ok := tools.ParseDataURL(url); ok {
+		return &v1.AnthropicSource{Type: "base64", MediaType: mimeType, Data: data}
+	}
+	return &v1.AnthropicSource{Type: "url", URL: url}
+}
+
+func assistantToContents(msg *v1.Message) ([]v1.AnthropicContent, error) {
+	contents, err := messageToContents(msg)
+	if err != nil {
+		return nil, err
+	}
+	for _, toolCall := range msg.ToolCalls {
+		input := json.RawMessage(toolCall.Function.Arguments)
+		if len(strings.TrimSpace(toolCall.Function.Arguments)) == 0 || !json.Valid(input) {
+			input = json.RawMessage("{}")
+		}
+		contents = append(contents, v1.AnthropicContent{
+			Type:  v1.AnthropicContentTypeToolUse,
+			ID:    toolCall.Id,
+			Name:  toolCall.Function.Name,
+			Input: input,
+		})
+	}
+	return contents, nil
+}
+
+func toolResultContent(msg *v1.Message) v1.AnthropicContent {
+	content, _ := json.Marshal(msg.StringContent())
+	if !msg.IsStringContent() {
+		// 多模态结果仅保留文本
+		var texts []string
+		if mediaContents, err := msg.ParseContent(); err == nil {
+			for _, mediaContent := range mediaContents {
+				if mediaContent.Type == v1.ContentTypeText {
+					texts = append(texts, mediaContent.Text)
+				}
+			}
+		}
+		content, _ = json.Marshal(strings.Join(texts, "\n"))
+	}
+	return v1.AnthropicContent{
+		Type:      v1.AnthropicContentTypeToolResult,
+		ToolUseID: msg.ToolCallId,
+		Content:   content,
+	}
+}
+
+// ConvertFinishReason Anthropic stop_reason 转 OpenAI finish_reason
+func ConvertFinishReason(stopReason string) string {
+	switch stopReason {
+	case "end_turn", "stop_sequence", "pause_turn":
+		return "stop"
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	case "refusal":
+		return "content_filter"
+	case "":
+		return ""
+	default:
+		return "stop"
+	}
+}
+
+func convertUsage(usage *v1.AnthropicUsage) v1.Usage {
+	if usage == nil {
+		return v1.Usage{}
+	}
+	promptTokens := usage.InputTokens + usage.CacheReadInputTokens + usage.CacheCreationInputTokens
+	newUsage := v1.Usage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: usage.OutputTokens,
+		TotalTokens:      promptTokens + usage.OutputTokens,
+	}
+	if usage.CacheReadInputTokens > 0 {
+		newUsage.PromptTokensDetails = &v1.PromptTokensDetails{CachedTokens: usage.CacheReadInputTokens}
+	}
+	return newUsage
+}
+
+// ConvertChatResponse 将 Messages 响应转换为 ChatCompletionResponse
+func ConvertChatResponse(resp *v1.AnthropicMessageResponse) *v1.ChatCompletionResponse {
+	message := v1.Message{Role: "assistant"}
+	var texts []string
+	for _, content := range resp.Content {
+		switch content.Type {
+		case v1.AnthropicContentTypeText:
+			texts = append(texts, content.Text)
+		case v1.AnthropicContentTypeToolUse:
+			arguments := string(content.Input)
+			if arguments == "" {
+				arguments = "{}"
+			}
+			message.ToolCalls = append(message.ToolCalls, v1.ToolCall{
+				Id:   content.ID,
+				Type: "function",
+				Function: v1.Function{
+					Name:      content.Name,
+					Arguments: arguments,
+				},
+			})
+		}
+	}
+	if len(texts) > 0 || len(message.ToolCalls) == 0 {
+		message.SetStringContent(strings.Join(texts, ""))
+	} else {
+		message.Content = json.RawMessage("null")
+	}
+	return &v1.ChatCompletionResponse{
+		ID:      resp.ID,
+		Model:   resp.Model,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Choices: []v1.Choice{
+			{
+				Index:        0,
+				Message:      message,
+				FinishReason: ConvertFinishReason(resp.StopReason),
+			},
+		},
+		Usage: convertUsage(resp.Usage),
+	}
+}
+
+// streamConverter 维护 Messages 流式事件到 chat.completion.chunk 的转换状态
+type streamConverter struct {
+	id           string
+	model        string
+	created      int64
+	usage        v1.AnthropicUsage
+	toolIndex    map[int]int // content block index -> tool call index
+	includeUsage bool
+}
+
+func (s *streamConverter) chunk(delta v1.Delta, finishReason string) *v1.ChatCompletionStreamResponse {
+	return &v1.ChatCompletionStreamResponse{
+		ID:      s.id,
+		Model:   s.model,
+		Object:  "chat.completion.chunk",
+		Created: s.created,
+		Choices: []v1.ChoiceWithDelta{
+			{
+				Index:        0,
+				Delta:        delta,
+				FinishReason: finishReason,
+			},
+		},
+	}
+}
+
+// convert 返回需要输出的 chunk, done 表示流已结束
+func (s *streamConverter) convert(ev *v1.AnthropicStreamEvent) (chunks []*v1.ChatCompletionStreamResponse, done bool, err error) {
+	switch ev.Type {
+	case v1.AnthropicEventMessageStart:
+		if ev.Message != nil {
+			s.id = ev.Message.ID
+			s.model = ev.Message.Model
+			if ev.Message.Usage != nil {
+				s.usage = *ev.Message.Usage
+			}
+		}
+		chunks = append(chunks, s.chunk(v1.Delta{Role: "assistant"}, ""))
+	case v1.AnthropicEventContentBlockStart:
+		if ev.ContentBlock == nil || ev.Index == nil || ev.ContentBlock.Type != v1.AnthropicContentTypeToolUse {
+			return nil, false, nil
+		}
+		index := len(s.toolIndex)
+		s.toolIndex[*ev.Index] = index
+		chunks = append(chunks, s.chunk(v1.Delta{
+			Role: "assistant",
+			ToolCalls: []v1.ToolCall{
+				{
+					Id:       ev.ContentBlock.ID,
+					Type:     "function",
+					Function: v1.Function{Name: ev.ContentBlock.Name},
+					Index:    &index,
+				},
+			},
+		}, ""))
+	case v1.AnthropicEventContentBlockDelta:
+		if ev.Delta == nil {
+			return nil, false, nil
+		}
+		switch ev.Delta.Type {
+		case "text_delta":
+			chunks = append(chunks, s.chunk(v1.Delta{Role: "assistant", Content: ev.Delta.Text}, ""))
+		case "input_json_delta":
+			if ev.Index == nil || ev.Delta.PartialJson == "" {
+				return nil, false, nil
+			}
+			index, ok := s.toolIndex[*ev.Index]
+			if !ok {
+				return nil, false, nil
+			}
+			chunks = append(chunks, s.chunk(v1.Delta{
+				Role: "assistant",
+				ToolCalls: []v1.ToolCall{
+					{
+						Type:     "function",
+						Function: v1.Function{Arguments: ev.Delta.PartialJson},
+						Index:    &index,
+					},
+				},
+			}, ""))
+		}
+	case v1.AnthropicEventMessageDelta:
+		if ev.Usage != nil {
+			s.usage.OutputTokens = ev.Usage.OutputTokens
+			if ev.Usage.InputTokens > 0 {
+				s.usage.InputTokens = ev.Usage.InputTokens
+			}
+		}
+		if ev.Delta != nil && ev.Delta.StopReason != "" {
+			chunks = append(chunks, s.chunk(v1.Delta{Role: "assistant"}, ConvertFinishReason(ev.Delta.StopReason)))
+		}
+	case v1.AnthropicEventMessageStop:
+		if s.includeUsage {
+			usageChunk := s.chunk(v1.Delta{}, "")
+			usageChunk.Choices = []v1.ChoiceWithDelta{}
+			usageChunk.Usage = convertUsage(&s.usage)
+			chunks = append(chunks, usageChunk)
+		}
+		return chunks, true, nil
+	case v1.AnthropicEventError:
+		if ev.Error != nil {
+			return nil, true, fmt.Errorf("anthropic stream error: %s: %s", ev.Error.Type, ev.Error.Message)
+		}
+		return nil, true, errors.New("anthropic stream error")
+	}
+	return chunks, false, nil
+}
+
+// ConvertStreamResponse 将 Messages SSE 事件流转换为 OpenAI chat.completion.chunk 流
+func ConvertStreamResponse(respBody io.ReadCloser, includeUsage bool) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		defer respBody.Close()
+		converter := &streamConverter{
+			created:      time.Now().Unix(),
+			toolIndex:    make(map[int]int),
+			includeUsage: includeUsage,
+		}
+		err := base.ReadSSE(respBody, func(sse *base.SSEEvent) error {
+			if len(sse.Data) == 0 {
+				return nil
+			}
+			var ev v1.AnthropicStreamEvent
+			if err := sonic.Unmarshal(sse.Data, &ev); err != nil {
+				return fmt.Errorf("unmarshal error: %w", err)
+			}
+			chunks, done, err := converter.convert(&ev)
+			if err != nil {
+				return err
+			}
+			for _, chunk := range chunks {
+				if err = base.WriteSSEData(pw, chunk); err != nil {
+					return err
+				}
+			}
+			if done {
+				return io.EOF
+			}
+			return nil
+		})
+		// 未收到 message_stop 连接就已断开, 不能当作正常结束
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != io.EOF {
+			pw.CloseWithError(err)
+			return
+		}
+		_ = base.WriteSSEDone(pw)
+		pw.Close()
+	}()
+	return pr
+}
@@ -0,0 +1,471 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/pkg/rr"
+	"github.com/jiu-u/oai-adapter/tools"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// 以下为入站方向的转换: 将 Anthropic Messages 协议的请求交给任意 OpenAI 风格的 Adapter 处理
+
+// ConvertMessagesRequest 将 Messages 请求转换为 ChatCompletionRequest
+func ConvertMessagesRequest(req *v1.AnthropicMessageRequest) (*v1.ChatCompletionRequest, error) {
+	newReq := &v1.ChatCompletionRequest{
+		Model:     req.Model,
+		MaxTokens: req.MaxTokens,
+		Stream:    req.Stream,
+	}
+	if len(req.StopSequences) > 0 {
+		newReq.Stop = req.StopSequences
+	}
+	if req.Temperature != nil {
+		newReq.Temperature = *req.Temperature
+	}
+	if req.TopP != nil {
+		newReq.TopP = *req.TopP
+	}
+	if req.Metadata != nil {
+		newReq.User = req.Metadata.UserID
+	}
+	if req.Stream {
+		// message_delta 需要输出用量
+		newReq.StreamOptions = &v1.StreamOptions{IncludeUsage: true}
+	}
+
+	system, err := req.ParseSystem()
+	if err != nil {
+		return nil, err
+	}
+	if len(system) > 0 {
+		texts := make([]string, 0, len(system))
+		for _, content := range system {
+			texts = append(texts, content.Text)
+		}
+		msg := v1.Message{Role: "system"}
+		msg.SetStringContent(strings.Join(texts, "\n"))
+		newReq.Messages = append(newReq.Messages, msg)
+	}
+	for i := range req.Messages {
+		messages, err := convertAnthropicMessage(&req.Messages[i])
+		if err != nil {
+			return nil, err
+		}
+		newReq.Messages = append(newReq.Messages, messages...)
+	}
+
+	for _, tool := range req.Tools {
+		// 服务端工具(web_search 等)无法转换
+		if tool.Type != "" && tool.Type != "custom" {
+			continue
+		}
+		newReq.Tools = append(newReq.Tools, v1.Tool{
+			Type: "function",
+			Function: v1.Function{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.InputSchema,
+			},
+		})
+	}
+	if req.ToolChoice != nil && len(newReq.Tools) > 0 {
+		switch req.ToolChoice.Type {
+		case "auto":
+			newReq.ToolChoice = v1.ToolChoiceAuto
+		case "any":
+			newReq.ToolChoice = v1.ToolChoiceRequired
+		case "none":
+			newReq.ToolChoice = v1.ToolChoiceNone
+		case "tool":
+			newReq.ToolChoice = v1.ToolChoice{
+				Type:     v1.ToolChoiceFunction,
+				Function: &v1.FunctionChoice{Name: req.ToolChoice.Name},
+			}
+		}
+	}
+	return newReq, nil
+}
+
+func convertAnthropicMessage(msg *v1.AnthropicMessage) ([]v1.Message, error) {
+	contents, err := msg.ParseContent()
+	if err != nil {
+		return nil, err
+	}
+	var messages []v1.Message
+	var parts []v1.MediaContent
+	var toolCalls []v1.ToolCall
+	for _, content := range contents {
+		switch content.Type {
+		case v1.AnthropicContentTypeText:
+			parts = append(parts, v1.MediaContent{Type: v1.ContentTypeText, Text: content.Text})
+		case v1.AnthropicContentTypeImage:
+			url, err := sourceToURL(content.Source)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, v1.MediaContent{Type: v1.ContentTypeImageURL, ImageUrl: &v1.ImageUrl{Url: url}})
+		case v1.AnthropicContentTypeDocument:
+			if content.Source != nil && content.Source.Type == "text" {
+				parts = append(parts, v1.MediaContent{Type: v1.ContentTypeText, Text: content.Source.Data})
+				continue
+			}
+			url, err := sourceToURL(content.Source)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, v1.MediaContent{Type: v1.ContentTypeFile, File: &v1.File{FileData: url, Filename: "document.pdf"}})
+		case v1.AnthropicContentTypeToolUse:
+			arguments := string(content.Input)
+			if arguments == "" {
+				arguments = "{}"
+			}
+			toolCalls = append(toolCalls, v1.ToolCall{
+				Id:       content.ID,
+				Type:     "function",
+				Function: v1.Function{Name: content.Name, Arguments: arguments},
+			})
+		case v1.AnthropicContentTypeToolResult:
+			// tool 消息必须紧跟在 assistant 的 tool_calls 之后, 因此先于同一条消息中的其他内容输出
+			toolMsg := v1.Message{Role: "tool", ToolCallId: content.ToolUseID}
+			toolMsg.SetStringContent(toolResultText(&content))
+			messages = append(messages, toolMsg)
+		case v1.AnthropicContentTypeThinking, v1.AnthropicContentTypeRedactedThinking:
+			continue
+		default:
+			return nil, fmt.Errorf("unsupported content type: %s", content.Type)
+		}
+	}
+	if len(parts) == 0 && len(toolCalls) == 0 {
+		return messages, nil
+	}
+	newMsg := v1.Message{Role: msg.Role, ToolCalls: toolCalls}
+	switch {
+	case len(parts) == 0:
+		newMsg.Content = json.RawMessage("null")
+	case msg.Role == "assistant" || onlyText(parts):
+		texts := make([]string, 0, len(parts))
+		for _, part := range parts {
+			texts = append(texts, part.Text)
+		}
+		newMsg.SetStringContent(strings.Join(texts, ""))
+	default:
+		content, err := json.Marshal(parts)
+		if err != nil {
+			return nil, fmt.Errorf("marshal content error: %w", err)
+		}
+		newMsg.Content = content
+	}
+	return append(messages, newMsg), nil
+}
+
+func onlyText(parts []v1.MediaContent) bool {
+	for _, part := range parts {
+		if part.Type != v1.ContentTypeText {
+			return false
+		}
+	}
+	return true
+}
+
+func sourceToURL(source *v1.AnthropicSource) (string, error) {
+	if source == nil {
+		return "", errors.New("source is empty")
+	}
+	switch source.Type {
+	case "base64":
+		return tools.ToDataURL(source.MediaType, source.Data), nil
+	case "url":
+		return source.URL, nil
+	default:
+		return "", fmt.Errorf("unsupported source type: %s", source.Type)
+	}
+}
+
+func toolResultText(content *v1.AnthropicContent) string {
+	results, err := content.ParseContent()
+	if err != nil {
+		return string(content.Content)
+	}
+	texts := make([]string, 0, len(results))
+	for _, result := range results {
+		if result.Type == v1.AnthropicContentTypeText {
+			texts = append(texts, result.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// ConvertStopReason OpenAI finish_reason 转 Anthropic stop_reason
+func ConvertStopReason(finishReason string) string {
+	switch finishReason {
+	case "length":
+		return "max_tokens"
+	case "tool_calls", "function_call":
+		return "tool_use"
+	case "content_filter":
+		return "refusal"
+	default:
+		return "end_turn"
+	}
+}
+
+// ConvertErrorType 上游 HTTP 状态码转 Anthropic 错误类型
+func ConvertErrorType(status int) string {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return "invalid_request_error"
+	case http.StatusUnauthorized:
+		return "authentication_error"
+	case http.StatusForbidden:
+		return "permission_error"
+	case http.StatusNotFound:
+		return "not_found_error"
+	case http.StatusRequestEntityTooLarge:
+		return "request_too_large"
+	case http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case http.StatusServiceUnavailable, 529:
+		return "overloaded_error"
+	default:
+		return "api_error"
+	}
+}
+
+func convertToAnthropicUsage(usage v1.Usage) *v1.AnthropicUsage {
+	newUsage := &v1.AnthropicUsage{
+		InputTokens:  usage.PromptTokens,
+		OutputTokens: usage.CompletionTokens,
+	}
+	if usage.PromptTokensDetails != nil && usage.PromptTokensDetails.CachedTokens > 0 {
+		newUsage.CacheReadInputTokens = usage.PromptTokensDetails.CachedTokens
+		newUsage.InputTokens -= usage.PromptTokensDetails.CachedTokens
+	}
+	return newUsage
+}
+
+// ConvertMessagesResponse 将 ChatCompletionResponse 转换为 Messages 响应
+func ConvertMessagesResponse(resp *v1.ChatCompletionResponse) *v1.AnthropicMessageResponse {
+	newResp := &v1.AnthropicMessageResponse{
+		ID:      "msg_" + strings.TrimPrefix(resp.ID, "chatcmpl-"),
+		Type:    "message",
+		Role:    "assistant",
+		Model:   resp.Model,
+		Content: make([]v1.AnthropicContent, 0),
+		Usage:   convertToAnthropicUsage(resp.Usage),
+	}
+	if resp.ID == "" {
+		newResp.ID = "msg_" + rr.GenString(24)
+	}
+	if len(resp.Choices) == 0 {
+		newResp.StopReason = "end_turn"
+		return newResp
+	}
+	choice := resp.Choices[0]
+	if len(choice.Message.Content) > 0 && string(choice.Message.Content) != "null" {
+		if text := choice.Message.StringContent(); text != "" {
+			newResp.Content = append(newResp.Content, v1.AnthropicContent{Type: v1.AnthropicContentTypeText, Text: text})
+		}
+	}
+	for _, toolCall := range choice.Message.ToolCalls {
+		input := json.RawMessage(toolCall.Function.Arguments)
+		if !json.Valid(input) {
+			input = json.RawMessage("{}")
+		}
+		newResp.Content = append(newResp.Content, v1.AnthropicContent{
+			Type:  v1.AnthropicContentTypeToolUse,
+			ID:    toolCall.Id,
+			Name:  toolCall.Function.Name,
+			Input: input,
+		})
+	}
+	newResp.StopReason = ConvertStopReason(choice.FinishReason)
+	return newResp
+}
+
+// messagesStreamConverter 维护 chat.completion.chunk 到 Messages 流式事件的转换状态
+type messagesStreamConverter struct {
+	w          io.Writer
+	model      string
+	started    bool
+	blockIndex int
+	blockType  string // 当前打开的内容块类型, 为空表示没有打开的块
+	toolBlock  map[int]int
+	stopReason string
+	usage      v1.AnthropicUsage
+}
+
+func (s *messagesStreamConverter) write(event string, v *v1.AnthropicStreamEvent) error {
+	v.Type = event
+	return base.WriteSSEEvent(s.w, event, v)
+}
+
+func (s *messagesStreamConverter) start(chunk *v1.ChatCompletionStreamResponse) error {
+	if s.started {
+		return nil
+	}
+	s.started = true
+	if chunk != nil && chunk.Model != "" {
+		s.model = chunk.Model
+	}
+	id := "msg_" + rr.GenString(24)
+	if chunk != nil && chunk.ID != "" {
+		id = "msg_" + strings.TrimPrefix(chunk.ID, "chatcmpl-")
+	}
+	err := s.write(v1.AnthropicEventMessageStart, &v1.AnthropicStreamEvent{
+		Message: &v1.AnthropicMessageResponse{
+			ID:      id,
+			Type:    "message",
+			Role:    "assistant",
+			Model:   s.model,
+			Content: make([]v1.AnthropicContent, 0),
+			Usage:   &v1.AnthropicUsage{},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return s.write(v1.AnthropicEventPing, &v1.AnthropicStreamEvent{})
+}
+
+func (s *messagesStreamConverter) closeBlock() error {
+	if s.blockType == "" {
+		return nil
+	}
+	index := s.blockIndex
+	s.blockType = ""
+	s.blockIndex++
+	return s.write(v1.AnthropicEventContentBlockStop, &v1.AnthropicStreamEvent{Index: &index})
+}
+
+func (s *messagesStreamConverter) openBlock(block *v1.AnthropicContent) error {
+	if err := s.closeBlock(); err != nil {
+		return err
+	}
+	s.blockType = block.Type
+	index := s.blockIndex
+	return s.write(v1.AnthropicEventContentBlockStart, &v1.AnthropicStreamEvent{Index: &index, ContentBlock: block})
+}
+
+func (s *messagesStreamConverter) delta(delta *v1.AnthropicDelta) error {
+	index := s.blockIndex
+	return s.write(v1.AnthropicEventContentBlockDelta, &v1.AnthropicStreamEvent{Index: &index, Delta: delta})
+}
+
+func (s *messagesStreamConverter) convert(chunk *v1.ChatCompletionStreamResponse) error {
+	if err := s.start(chunk); err != nil {
+		return err
+	}
+	if chunk.Usage.TotalTokens > 0 || chunk.Usage.PromptTokens > 0 {
+		s.usage = *convertToAnthropicUsage(chunk.Usage)
+	}
+	if len(chunk.Choices) == 0 {
+		return nil
+	}
+	choice := chunk.Choices[0]
+	if choice.Delta.Content != "" {
+		if s.blockType != v1.AnthropicContentTypeText {
+			if err := s.openBlock(&v1.AnthropicContent{Type: v1.AnthropicContentTypeText}); err != nil {
+				return err
+			}
+		}
+		if err := s.delta(&v1.AnthropicDelta{Type: "text_delta", Text: choice.Delta.Content}); err != nil {
+			return err
+		}
+	}
+	for i, toolCall := range choice.Delta.ToolCalls {
+		toolIndex := toolCall.IndexOr(i)
+		if _, ok := s.toolBlock[toolIndex]; !ok {
+			err := s.openBlock(&v1.AnthropicContent{
+				Type:  v1.AnthropicContentTypeToolUse,
+				ID:    toolCall.Id,
+				Name:  toolCall.Function.Name,
+				Input: json.RawMessage("{}"),
+			})
+			if err != nil {
+				return err
+			}
+			s.toolBlock[toolIndex] = s.blockIndex
+		}
+		// 不支持交错输出多个工具调用的参数, 仅向当前块追加
+		if toolCall.Function.Arguments != "" && s.toolBlock[toolIndex] == s.blockIndex {
+			if err := s.delta(&v1.AnthropicDelta{Type: "input_json_delta", PartialJson: toolCall.Function.Arguments}); err != nil {
+				return err
+			}
+		}
+	}
+	if choice.FinishReason != "" {
+		s.stopReason = ConvertStopReason(choice.FinishReason)
+	}
+	return nil
+}
+
+func (s *messagesStreamConverter) finish() error {
+	if err := s.start(nil); err != nil {
+		return err
+	}
+	if err := s.closeBlock(); err != nil {
+		return err
+	}
+	if s.stopReason == "" {
+		s.stopReason = "end_turn"
+	}
+	usage := s.usage
+	err := s.write(v1.AnthropicEventMessageDelta, &v1.AnthropicStreamEvent{
+		Delta: &v1.AnthropicDelta{StopReason: s.stopReason},
+		Usage: &usage,
+	})
+	if err != nil {
+		return err
+	}
+	return s.write(v1.AnthropicEventMessageStop, &v1.AnthropicStreamEvent{})
+}
+
+// ConvertMessagesStream 将 OpenAI chat.completion.chunk 流转换为 Messages SSE 事件流
+func ConvertMessagesStream(respBody io.ReadCloser, model string) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		defer respBody.Close()
+		converter := &messagesStreamConverter{
+			w:         pw,
+			model:     model,
+			toolBlock: make(map[int]int),
+		}
+		err := base.ReadSSE(respBody, func(sse *base.SSEEvent) error {
+			data := strings.TrimSpace(string(sse.Data))
+			if data == "" {
+				return nil
+			}
+			if data == "[DONE]" {
+				return io.EOF
+			}
+			chunk, err := base.ParseChatStreamResponse([]byte(data))
+			if err != nil {
+				return err
+			}
+			return converter.convert(chunk)
+		})
+		// 上游以非 SSE 的 JSON 返回错误时读不到任何 chunk, 不能当作空消息正常结束
+		if (err == nil || err == io.EOF) && !converter.started {
+			err = errors.New("upstream returned no stream events")
+		}
+		if err != nil && err != io.EOF {
+			_ = converter.write(v1.AnthropicEventError, &v1.AnthropicStreamEvent{
+				Error: &v1.AnthropicError{Type: "api_error", Message: err.Error()},
+			})
+			pw.CloseWithError(err)
+			return
+		}
+		if err = converter.finish(); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.Close()
+	}()
+	return pr
+}
@@ -0,0 +1,181 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/constant"
+	"github.com/jiu-u/oai-adapter/constant/mode"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TokenProvider 返回 Entra ID (Azure AD) 访问令牌, 令牌的缓存与刷新由调用方负责
+type TokenProvider func(ctx context.Context) (string, error)
+
+type Client struct {
+	*base.Client
+	baseUrl       string
+	APIVersion    string
+	Deployments   map[string]string // 模型名 -> 部署名, 未配置的模型直接使用模型名作为部署名
+	tokenProvider TokenProvider
+}
+
+// NewClient 使用 api-key 请求头鉴权, apiKey 以 "Bearer " 开头时视为 Entra 访问令牌
+func NewClient(endPoint, apiKey, apiVersion string, deployments map[string]string) *Client {
+	if token, ok := strings.CutPrefix(apiKey, "Bearer "); ok {
+		return NewClientWithTokenProvider(endPoint, func(ctx context.Context) (string, error) {
+			return token, nil
+		}, apiVersion, deployments)
+	}
+	return newClient(endPoint, apiKey, apiVersion, deployments, nil)
+}
+
+// NewClientWithTokenProvider 使用 Entra ID 令牌鉴权
+func NewClientWithTokenProvider(endPoint string, provider TokenProvider, apiVersion string, deployments map[string]string) *Client {
+	return newClient(endPoint, "", apiVersion, deployments, provider)
+}
+
+func newClient(endPoint, apiKey, apiVersion string, deployments map[string]string, provider TokenProvider) *Client {
+	endPoint = strings.TrimSpace(endPoint)
+	endPoint = strings.TrimRight(endPoint, "/")
+	if apiVersion == "" {
+		apiVersion = constant.AzureAPIVersion
+	}
+	c := &Client{
+		Client:        base.NewClient(endPoint+"/openai", apiKey),
+		baseUrl:       endPoint,
+		APIVersion:    apiVersion,
+		Deployments:   deployments,
+		tokenProvider: provider,
+	}
+	c.SetURLBuilder(c.buildURL)
+	c.SetAuthFunc(c.auth)
+	return c
+}
+
+// Deployment 返回模型对应的部署名
+func (c *Client) Deployment(model string) string {
+	if deployment, ok := c.Deployments[model]; ok && deployment != "" {
+		return deployment
+	}
+	return model
+}
+
+// buildURL 部署级接口: /openai/deployments/{deployment}/{operation}?api-version=...
+// responses 与 models 为资源级接口, 不在部署路径下
+func (c *Client) buildURL(m mode.Mode, model, path string) string {
+	query := "?api-version=" + url.QueryEscape(c.APIVersion)
+	switch m {
+	case mode.Responses:
+		return c.EndPoint + path + "?api-version=" + url.QueryEscape(c.responsesAPIVersion())
+	case mode.Models:
+		return c.EndPoint + path + query
+	default:
+		return c.EndPoint + "/deployments/" + url.PathEscape(c.Deployment(model)) + path + query
+	}
+}
+
+// responsesAPIVersion 配置的版本不支持 responses 时使用 AzureResponsesAPIVersion
+// preview 版本号形如 2025-03-01-preview, 可直接按字符串比较日期
+func (c *Client) responsesAPIVersion() string {
+	switch {
+	case c.APIVersion == "preview" || c.APIVersion == "latest":
+		return c.APIVersion
+	case strings.HasSuffix(c.APIVersion, "-preview") && c.APIVersion >= constant.AzureResponsesAPIVersion:
+		return c.APIVersion
+	default:
+		return constant.AzureResponsesAPIVersion
+	}
+}
+
+func (c *Client) auth(ctx context.Context, header http.Header) error {
+	header.Del("Authorization")
+	header.Del("api-key")
+	if c.tokenProvider == nil {
+		header.Set("api-key", c.APIKey)
+		return nil
+	}
+	token, err := c.tokenProvider(ctx)
+	if err != nil {
+		return fmt.Errorf("get token error: %w", err)
+	}
+	header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+func (c *Client) RelayRequest(ctx context.Context, method, targetPath string, body io.ReadCloser, header http.Header) (io.ReadCloser, http.Header, error) {
+	if err := c.auth(ctx, header); err != nil {
+		return nil, nil, err
+	}
+	targetUrl := c.baseUrl + targetPath
+	if !strings.Contains(targetPath, "api-version=") {
+		separator := "?"
+		if strings.Contains(targetPath, "?") {
+			separator = "&"
+		}
+		targetUrl += separator + "api-version=" + url.QueryEscape(c.APIVersion)
+	}
+	return base.Relay(ctx, method, targetUrl, body, header, c.HTTPClient())
+}
+
+// CreateResponses responses 接口的 model 字段需要填写部署名
+func (c *Client) CreateResponses(ctx context.Context, req *v1.ResponsesRequest) (io.ReadCloser, http.Header, error) {
+	newReq := *req
+	newReq.Model = c.Deployment(req.Model)
+	return c.Client.CreateResponses(ctx, &newReq)
+}
+
+// Models 配置了部署映射时返回映射中的模型名, 否则查询资源下可用的模型
+func (c *Client) Models(ctx context.Context) (*v1.ModelResponse, error) {
+	resp := &v1.ModelResponse{Object: "list", Data: make([]v1.Model, 0)}
+	if len(c.Deployments) > 0 {
+		now := time.Now().Unix()
+		for model := range c.Deployments {
+			resp.Data = append(resp.Data, v1.Model{ID: model, Object: "model", Created: now, OwnedBy: "azure"})
+		}
+		sort.Slice(resp.Data, func(i, j int) bool { return resp.Data[i].ID < resp.Data[j].ID })
+		return resp, nil
+	}
+	header := http.Header{}
+	if err := c.auth(ctx, header); err != nil {
+		return nil, err
+	}
+	body, _, err := base.RelayWithCheck(ctx, http.MethodGet, c.buildURL(mode.Models, "", "/models"), nil, header, c.HTTPClient())
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	dataBytes, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("read all error: %w", err)
+	}
+	if err = sonic.Unmarshal(dataBytes, resp); err != nil {
+		return nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	return resp, nil
+}
+
+func (c *Client) CreateImageVariation(ctx context.Context, req *v1.ImageVariationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateRerank(ctx context.Context, req *v1.RerankRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateVideoSubmit(ctx context.Context, req *v1.VideoRequest) (*v1.VideoResponse, error) {
+	_, _, err := base.NoImplementMethod(ctx, req)
+	return nil, err
+}
+
+func (c *Client) GetVideoStatus(ctx context.Context, externalID string) (bool, any, error) {
+	_, _, err := base.NoImplementMethod(ctx, externalID)
+	return false, nil, err
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/bytedance/sonic"
 	v1 "github.com/jiu-u/oai-adapter/api/v1"
 	"github.com/jiu-u/oai-adapter/common"
+	"github.com/jiu-u/oai-adapter/constant/mode"
 	"github.com/jiu-u/oai-adapter/pkg/task"
 	"io"
 	"mime/multipart"
@@ -22,6 +23,11 @@ type Client struct {
 	client   *http.Client
 	taskMgr  task.TaskManager
 	baseUrl  string
+	// 可选钩子, 供请求地址或鉴权方式与 OpenAI 不同的服务商使用
+	urlBuilder URLBuilder
+	authFunc   AuthFunc
+	headers    http.Header
+	modes      map[mode.Mode]bool
 }
 
 func NewClient(EndPoint, apiKey string) *Client {
@@ -42,18 +48,36 @@ func (c *Client) SetClient(client *http.Client) {
 	c.client = client
 }
 
+// HTTPClient 返回当前使用的 http.Client, 供自行组装请求的子客户端使用
+func (c *Client) HTTPClient() *http.Client {
+	return c.client
+}
+
+func (c *Client) SetTaskManager(taskMgr task.TaskManager) {
+	c.taskMgr = taskMgr
+}
+
+// TaskManager 返回异步任务管理器, 供自行实现视频等异步接口的子客户端创建轮询任务
+func (c *Client) TaskManager() task.TaskManager {
+	return c.taskMgr
+}
+
 func (c *Client) RelayRequest(ctx context.Context, method, targetPath string, body io.ReadCloser, header http.Header) (io.ReadCloser, http.Header, error) {
 	header.Del("Authorization")
-	header.Set("Authorization", "Bearer "+c.APIKey)
+	if err := c.prepareHeader(ctx, header); err != nil {
+		return nil, nil, err
+	}
 	targetUrl := c.baseUrl + targetPath
 	return Relay(ctx, method, targetUrl, body, header, c.client)
 }
 
-func (c *Client) generateHeaderByContentType(contentType string) http.Header {
+func (c *Client) generateHeaderByContentType(ctx context.Context, contentType string) (http.Header, error) {
 	headers := http.Header{}
 	headers.Set("Content-Type", contentType)
-	headers.Set("Authorization", "Bearer "+c.APIKey)
-	return headers
+	if err := c.prepareHeader(ctx, headers); err != nil {
+		return nil, err
+	}
+	return headers, nil
 }
 
 //func (c *Client) ConvertChatCompletions(req *v1.ChatCompletionRequest)(io.ReadCloser, http.Header, error)
@@ -76,30 +100,58 @@ func (c *Client) SamePostJob(ctx context.Context, targetUrl string, req any, con
 		return nil, nil, fmt.Errorf("marshal error: %w", err)
 	}
 	body := io.NopCloser(bytes.NewBuffer(reqBytes))
-	header := c.generateHeaderByContentType(contentType)
+	header, err := c.generateHeaderByContentType(ctx, contentType)
+	if err != nil {
+		return nil, nil, err
+	}
 	return Relay(ctx, http.MethodPost, targetUrl, body, header, c.client)
 }
 
 func (c *Client) CreateResponses(ctx context.Context, req *v1.ResponsesRequest) (io.ReadCloser, http.Header, error) {
-	targetUrl := c.EndPoint + "/responses"
+	if !c.Supports(mode.Responses) {
+		return NoImplementMethod(ctx, req)
+	}
+	targetUrl := c.buildURL(mode.Responses, req.Model, "/responses")
 	return c.SamePostJob(ctx, targetUrl, req, "application/json")
 
 }
 
 func (c *Client) CreateChatCompletions(ctx context.Context, req *v1.ChatCompletionRequest) (io.ReadCloser, http.Header, error) {
-	targetUrl := c.EndPoint + "/chat/completions"
-	return c.SamePostJob(ctx, targetUrl, req, "application/json")
+	if !c.Supports(mode.Chat) {
+		return NoImplementMethod(ctx, req)
+	}
+	targetUrl := c.buildURL(mode.Chat, req.Model, "/chat/completions")
+	reqBytes, err := sonic.Marshal(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	header, err := c.generateHeaderByContentType(ctx, "application/json")
+	if err != nil {
+		return nil, nil, err
+	}
+	// 上游出错时返回 *StatusError, 便于调用方(如 Anthropic Messages 转换)保留上游状态码
+	return RelayWithCheck(ctx, http.MethodPost, targetUrl, io.NopCloser(bytes.NewReader(reqBytes)), header, c.client)
 }
 
 func (c *Client) CreateCompletions(ctx context.Context, req *v1.CompletionsRequest) (io.ReadCloser, http.Header, error) {
-	targetUrl := c.EndPoint + "/completions"
+	if !c.Supports(mode.Completions) {
+		return NoImplementMethod(ctx, req)
+	}
+	targetUrl := c.buildURL(mode.Completions, req.Model, "/completions")
 	return c.SamePostJob(ctx, targetUrl, req, "application/json")
 }
 
 func (c *Client) Models(ctx context.Context) (*v1.ModelResponse, error) {
 	var err error
-	targetUrl := c.EndPoint + "/models"
-	data, _, err := Relay(ctx, http.MethodGet, targetUrl, nil, nil, c.client)
+	if !c.Supports(mode.Models) {
+		return nil, v1.NoImplementError
+	}
+	targetUrl := c.buildURL(mode.Models, "", "/models")
+	header, err := c.generateHeaderByContentType(ctx, "application/json")
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := Relay(ctx, http.MethodGet, targetUrl, nil, header, c.client)
 	if err != nil {
 		return nil, err
 	}
@@ -116,17 +168,26 @@ func (c *Client) Models(ctx context.Context) (*v1.ModelResponse, error) {
 }
 
 func (c *Client) CreateEmbeddings(ctx context.Context, req *v1.EmbeddingsRequest) (io.ReadCloser, http.Header, error) {
-	targetUrl := c.EndPoint + "/embeddings"
+	if !c.Supports(mode.Embedding) {
+		return NoImplementMethod(ctx, req)
+	}
+	targetUrl := c.buildURL(mode.Embedding, req.Model, "/embeddings")
 	return c.SamePostJob(ctx, targetUrl, req, "application/json")
 }
 
 func (c *Client) CreateSpeech(ctx context.Context, req *v1.AudioSpeechRequest) (io.ReadCloser, http.Header, error) {
-	targetUrl := c.EndPoint + "/audio/speech"
+	if !c.Supports(mode.Audio) {
+		return NoImplementMethod(ctx, req)
+	}
+	targetUrl := c.buildURL(mode.Audio, req.Model, "/audio/speech")
 	return c.SamePostJob(ctx, targetUrl, req, "application/json")
 }
 
 func (c *Client) CreateTranslation(ctx context.Context, req *v1.TranslationRequest) (io.ReadCloser, http.Header, error) {
-	targetUrl := c.EndPoint + "/audio/translations"
+	if !c.Supports(mode.Translate) {
+		return NoImplementMethod(ctx, req)
+	}
+	targetUrl := c.buildURL(mode.Translate, req.Model, "/audio/translations")
 
 	// 创建一个字节缓冲区来存储请求体
 	var buf bytes.Buffer
@@ -183,14 +244,19 @@ func (c *Client) CreateTranslation(ctx context.Context, req *v1.TranslationReque
 	// 生成请求头
 	header := http.Header{}
 	header.Set("Content-Type", writer.FormDataContentType())
-	header.Set("Authorization", "Bearer "+c.APIKey)
+	if err := c.prepareHeader(ctx, header); err != nil {
+		return nil, nil, err
+	}
 
 	// 返回请求
 	return Relay(ctx, http.MethodPost, targetUrl, io.NopCloser(&buf), header, c.client)
 }
 
 func (c *Client) CreateTranscription(ctx context.Context, req *v1.TranscriptionRequest) (io.ReadCloser, http.Header, error) {
-	targetUrl := c.EndPoint + "/audio/transcriptions"
+	if !c.Supports(mode.Transcriptions) {
+		return NoImplementMethod(ctx, req)
+	}
+	targetUrl := c.buildURL(mode.Transcriptions, req.Model, "/audio/transcriptions")
 
 	// 创建一个字节缓冲区来存储请求体
 	var buf bytes.Buffer
@@ -278,19 +344,27 @@ func (c *Client) CreateTranscription(ctx context.Context, req *v1.TranscriptionR
 	// 生成请求头
 	header := http.Header{}
 	header.Set("Content-Type", writer.FormDataContentType())
-	header.Set("Authorization", "Bearer "+c.APIKey)
+	if err := c.prepareHeader(ctx, header); err != nil {
+		return nil, nil, err
+	}
 
 	// 返回请求
 	return Relay(ctx, http.MethodPost, targetUrl, io.NopCloser(&buf), header, c.client)
 }
 
 func (c *Client) CreateImage(ctx context.Context, req *v1.ImageGenerateRequest) (io.ReadCloser, http.Header, error) {
-	targetUrl := c.EndPoint + "/images/generations"
+	if !c.Supports(mode.Image) {
+		return NoImplementMethod(ctx, req)
+	}
+	targetUrl := c.buildURL(mode.Image, req.Model, "/images/generations")
 	return c.SamePostJob(ctx, targetUrl, req, "application/json")
 }
 
 func (c *Client) CreateImageEdit(ctx context.Context, req *v1.ImageEditRequest) (io.ReadCloser, http.Header, error) {
-	targetUrl := c.EndPoint + "/images/edits"
+	if !c.Supports(mode.ImageEdit) {
+		return NoImplementMethod(ctx, req)
+	}
+	targetUrl := c.buildURL(mode.ImageEdit, req.Model, "/images/edits")
 
 	// 创建一个字节缓冲区来存储请求体
 	var buf bytes.Buffer
@@ -392,14 +466,19 @@ func (c *Client) CreateImageEdit(ctx context.Context, req *v1.ImageEditRequest)
 	// 生成请求头
 	header := http.Header{}
 	header.Set("Content-Type", writer.FormDataContentType())
-	header.Set("Authorization", "Bearer "+c.APIKey)
+	if err := c.prepareHeader(ctx, header); err != nil {
+		return nil, nil, err
+	}
 
 	// 返回请求
 	return Relay(ctx, http.MethodPost, targetUrl, io.NopCloser(&buf), header, c.client)
 }
 
 func (c *Client) CreateImageVariation(ctx context.Context, req *v1.ImageVariationRequest) (io.ReadCloser, http.Header, error) {
-	targetUrl := c.EndPoint + "/images/variations"
+	if !c.Supports(mode.ImageVariation) {
+		return NoImplementMethod(ctx, req)
+	}
+	targetUrl := c.buildURL(mode.ImageVariation, req.Model, "/images/variations")
 
 	// 创建一个字节缓冲区来存储请求体
 	var buf bytes.Buffer
@@ -463,20 +542,29 @@ func (c *Client) CreateImageVariation(ctx context.Context, req *v1.ImageVariatio
 	// 生成请求头
 	header := http.Header{}
 	header.Set("Content-Type", writer.FormDataContentType())
-	header.Set("Authorization", "Bearer "+c.APIKey)
+	if err := c.prepareHeader(ctx, header); err != nil {
+		return nil, nil, err
+	}
 
 	// 返回请求
 	return Relay(ctx, http.MethodPost, targetUrl, io.NopCloser(&buf), header, c.client)
 }
 
 func (c *Client) CreateRerank(ctx context.Context, req *v1.RerankRequest) (io.ReadCloser, http.Header, error) {
-	targetUrl := c.EndPoint + "/rerank"
+	if !c.Supports(mode.Rerank) {
+		return NoImplementMethod(ctx, req)
+	}
+	targetUrl := c.buildURL(mode.Rerank, req.Model, "/rerank")
 	return c.SamePostJob(ctx, targetUrl, req, "application/json")
 }
 
 func (c *Client) CreateVideoSubmit(ctx context.Context, req *v1.VideoRequest) (*v1.VideoResponse, error) {
 	var err error
-	targetUrl := c.EndPoint + "/videos/submit"
+	if !c.Supports(mode.VideoSubmit) {
+		_, _, err = NoImplementMethod(ctx, req)
+		return nil, err
+	}
+	targetUrl := c.buildURL(mode.VideoSubmit, req.Model, "/videos/submit")
 	respBody, _, err := c.SamePostJob(ctx, targetUrl, req, "application/json")
 	if err != nil {
 		return nil, fmt.Errorf("relay error: %w", err)
@@ -499,8 +587,15 @@ func (c *Client) CreateVideoSubmit(ctx context.Context, req *v1.VideoRequest) (*
 
 func (c *Client) GetVideoStatus(ctx context.Context, externalID string) (bool, any, error) {
 	var err error
-	targetUrl := c.EndPoint + "/videos/status"
-	header := c.generateHeaderByContentType("application/json")
+	if !c.Supports(mode.VideoStatus) {
+		_, _, err = NoImplementMethod(ctx, externalID)
+		return false, nil, err
+	}
+	targetUrl := c.buildURL(mode.VideoStatus, "", "/videos/status")
+	header, err := c.generateHeaderByContentType(ctx, "application/json")
+	if err != nil {
+		return false, nil, err
+	}
 	req := v1.VideoStatusRequest{
 		RequestId: externalID,
 	}
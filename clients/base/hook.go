@@ -0,0 +1,71 @@
+package base
+
+import (
+	"context"
+	"github.com/jiu-u/oai-adapter/constant/mode"
+	"net/http"
+)
+
+// URLBuilder 根据接口类型与模型名生成完整请求地址, path 为 OpenAI 风格的默认路径, 如 /chat/completions
+type URLBuilder func(m mode.Mode, model, path string) string
+
+// AuthFunc 为请求头设置鉴权信息, 可用于 api-key 请求头或需要刷新的令牌
+type AuthFunc func(ctx context.Context, header http.Header) error
+
+func (c *Client) SetURLBuilder(builder URLBuilder) {
+	c.urlBuilder = builder
+}
+
+func (c *Client) SetAuthFunc(authFunc AuthFunc) {
+	c.authFunc = authFunc
+}
+
+// SetHeaders 设置每个请求都会附带的额外请求头, 如 OpenRouter 的 HTTP-Referer
+func (c *Client) SetHeaders(header http.Header) {
+	c.headers = header.Clone()
+}
+
+// SetSupportedModes 限制客户端支持的接口, 未列出的接口返回 NoImplementError, 传空表示全部支持
+func (c *Client) SetSupportedModes(modes ...mode.Mode) {
+	if len(modes) == 0 {
+		c.modes = nil
+		return
+	}
+	c.modes = make(map[mode.Mode]bool, len(modes))
+	for _, m := range modes {
+		c.modes[m] = true
+	}
+}
+
+// Supports 判断客户端是否支持该接口
+func (c *Client) Supports(m mode.Mode) bool {
+	return c.modes == nil || c.modes[m]
+}
+
+func (c *Client) buildURL(m mode.Mode, model, path string) string {
+	if c.urlBuilder != nil {
+		return c.urlBuilder(m, model, path)
+	}
+	return c.EndPoint + path
+}
+
+// PrepareHeader 写入额外请求头与鉴权信息, 供自行发送请求的客户端复用同一套鉴权
+func (c *Client) PrepareHeader(ctx context.Context, header http.Header) error {
+	return c.prepareHeader(ctx, header)
+}
+
+// prepareHeader 写入额外请求头与鉴权信息
+func (c *Client) prepareHeader(ctx context.Context, header http.Header) error {
+	for key, values := range c.headers {
+		header[key] = append([]string(nil), values...)
+	}
+	return c.setAuthHeader(ctx, header)
+}
+
+func (c *Client) setAuthHeader(ctx context.Context, header http.Header) error {
+	if c.authFunc != nil {
+		return c.authFunc(ctx, header)
+	}
+	header.Set("Authorization", "Bearer "+c.APIKey)
+	return nil
+}
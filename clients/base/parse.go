@@ -0,0 +1,42 @@
+package base
+
+import (
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+)
+
+// chatResponseWithError 上游可能在 2xx 之外直接返回 OpenAI 风格的错误对象
+type chatResponseWithError struct {
+	v1.ChatCompletionResponse
+	Error *v1.OpenAIError `json:"error,omitempty"`
+}
+
+// ParseChatResponse 解析 ChatCompletionResponse, 上游返回错误对象时返回错误
+func ParseChatResponse(data []byte) (*v1.ChatCompletionResponse, error) {
+	var resp chatResponseWithError
+	if err := sonic.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("upstream error: %s", resp.Error.Message)
+	}
+	return &resp.ChatCompletionResponse, nil
+}
+
+type chatStreamResponseWithError struct {
+	v1.ChatCompletionStreamResponse
+	Error *v1.OpenAIError `json:"error,omitempty"`
+}
+
+// ParseChatStreamResponse 解析一条 chat.completion.chunk, 上游返回错误对象时返回错误
+func ParseChatStreamResponse(data []byte) (*v1.ChatCompletionStreamResponse, error) {
+	var chunk chatStreamResponseWithError
+	if err := sonic.Unmarshal(data, &chunk); err != nil {
+		return nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	if chunk.Error != nil {
+		return nil, fmt.Errorf("upstream error: %s", chunk.Error.Message)
+	}
+	return &chunk.ChatCompletionStreamResponse, nil
+}
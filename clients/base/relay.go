@@ -33,3 +33,28 @@ func RelayHttpRequest(ctx context.Context, method, targetURL string, body io.Rea
 func RelayRequest(req *http.Request, client *http.Client) (*http.Response, error) {
 	return client.Do(req)
 }
+
+// StatusError 上游返回非 2xx 状态码时的错误
+type StatusError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("upstream status %d: %s", e.StatusCode, string(e.Body))
+}
+
+// RelayWithCheck 与 Relay 相同, 但上游返回非 2xx 状态码时读取响应体并返回 *StatusError
+// 需要对响应体做协议转换的客户端应使用该方法
+func RelayWithCheck(ctx context.Context, method, targetURL string, body io.ReadCloser, header http.Header, client *http.Client) (io.ReadCloser, http.Header, error) {
+	resp, err := RelayHttpRequest(ctx, method, targetURL, body, header, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		errBody, _ := io.ReadAll(resp.Body)
+		return nil, resp.Header, &StatusError{StatusCode: resp.StatusCode, Body: errBody}
+	}
+	return resp.Body, resp.Header, nil
+}
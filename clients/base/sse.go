@@ -0,0 +1,99 @@
+package base
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/bytedance/sonic"
+	"io"
+	"net/http"
+)
+
+// SSEEvent 一条 server-sent event
+type SSEEvent struct {
+	Event string
+	Data  []byte
+}
+
+// ReadSSE 逐条读取 SSE 事件并交给 handler 处理, handler 返回错误时停止读取
+func ReadSSE(r io.Reader, handler func(ev *SSEEvent) error) error {
+	reader := bufio.NewReader(r)
+	ev := new(SSEEvent)
+	var data [][]byte
+	dispatch := func() error {
+		if len(data) == 0 && ev.Event == "" {
+			return nil
+		}
+		ev.Data = bytes.Join(data, []byte("\n"))
+		err := handler(ev)
+		ev = new(SSEEvent)
+		data = nil
+		return err
+	}
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			line = bytes.TrimRight(line, "\r\n")
+			switch {
+			case len(line) == 0:
+				if hErr := dispatch(); hErr != nil {
+					return hErr
+				}
+			case line[0] == ':':
+				// 注释/心跳
+			case bytes.HasPrefix(line, []byte("event:")):
+				ev.Event = string(bytes.TrimSpace(line[6:]))
+			case bytes.HasPrefix(line, []byte("data:")):
+				data = append(data, bytes.TrimPrefix(line[5:], []byte(" ")))
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				return dispatch()
+			}
+			return err
+		}
+	}
+}
+
+// WriteSSEData 以 "data: {json}\n\n" 的格式写出一条数据
+func WriteSSEData(w io.Writer, v any) error {
+	data, err := sonic.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshal error: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+	return err
+}
+
+// WriteSSEEvent 以 "event: name\ndata: {json}\n\n" 的格式写出一条事件
+func WriteSSEEvent(w io.Writer, event string, v any) error {
+	data, err := sonic.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshal error: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
+
+// WriteSSEDone 写出 OpenAI 风格的流结束标记
+func WriteSSEDone(w io.Writer) error {
+	_, err := io.WriteString(w, "data: [DONE]\n\n")
+	return err
+}
+
+// NewStreamHeader 流式响应使用的响应头
+func NewStreamHeader() http.Header {
+	header := http.Header{}
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	return header
+}
+
+// NewJsonHeader 转换后的非流式响应使用的响应头
+func NewJsonHeader() http.Header {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	return header
+}
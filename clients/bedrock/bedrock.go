@@ -0,0 +1,288 @@
+package bedrock
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var DefaultRegion = "us-east-1"
+
+type Client struct {
+	*base.Client
+	signer *Signer
+	now    func() time.Time
+}
+
+// NewClient endPoint 可以是区域名(如 us-east-1)或完整地址, apiKey 格式为 AccessKeyID:SecretAccessKey[:SessionToken]
+func NewClient(endPoint, apiKey string) *Client {
+	var credentials Credentials
+	parts := strings.SplitN(apiKey, ":", 3)
+	credentials.AccessKeyID = parts[0]
+	if len(parts) > 1 {
+		credentials.SecretAccessKey = parts[1]
+	}
+	if len(parts) > 2 {
+		credentials.SessionToken = parts[2]
+	}
+	endPoint = strings.TrimSpace(endPoint)
+	if endPoint != "" && !strings.Contains(endPoint, "://") {
+		return NewClientWithCredentials("", endPoint, credentials)
+	}
+	return NewClientWithCredentials(endPoint, "", credentials)
+}
+
+// NewClientWithCredentials endPoint 为空时使用区域默认地址, region 为空时从地址中解析
+func NewClientWithCredentials(endPoint, region string, credentials Credentials) *Client {
+	endPoint = strings.TrimRight(strings.TrimSpace(endPoint), "/")
+	if region == "" {
+		region = regionFromEndPoint(endPoint)
+	}
+	if endPoint == "" {
+		endPoint = "https://bedrock-runtime." + region + ".amazonaws.com"
+	}
+	return &Client{
+		Client: base.NewClient(endPoint, credentials.AccessKeyID),
+		signer: &Signer{Credentials: credentials, Region: region, Service: "bedrock"},
+		now:    time.Now,
+	}
+}
+
+// regionFromEndPoint 从 bedrock-runtime.{region}.amazonaws.com 中解析区域
+func regionFromEndPoint(endPoint string) string {
+	host := endPoint
+	if _, after, ok := strings.Cut(host, "://"); ok {
+		host = after
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) >= 4 && strings.HasPrefix(labels[0], "bedrock") {
+		return labels[1]
+	}
+	return DefaultRegion
+}
+
+// send 对请求签名后发送, 非 2xx 响应返回 *base.StatusError
+func (c *Client) send(ctx context.Context, method, targetUrl string, body []byte, header http.Header) (io.ReadCloser, http.Header, error) {
+	if err := c.signer.Sign(method, targetUrl, header, body, c.now()); err != nil {
+		return nil, nil, err
+	}
+	var reqBody io.ReadCloser
+	if body != nil {
+		reqBody = io.NopCloser(bytes.NewReader(body))
+	}
+	return base.RelayWithCheck(ctx, method, targetUrl, reqBody, header, c.HTTPClient())
+}
+
+// InvokeModel 调用 /model/{modelId}/{action}, action 为 converse、converse-stream、invoke 等
+func (c *Client) InvokeModel(ctx context.Context, modelId, action string, req any) (io.ReadCloser, http.Header, error) {
+	reqBytes, err := sonic.Marshal(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	targetUrl := c.EndPoint + "/model/" + uriEncode(modelId) + "/" + action
+	return c.send(ctx, http.MethodPost, targetUrl, reqBytes, header)
+}
+
+func (c *Client) RelayRequest(ctx context.Context, method, targetPath string, body io.ReadCloser, header http.Header) (io.ReadCloser, http.Header, error) {
+	var bodyBytes []byte
+	if body != nil {
+		var err error
+		bodyBytes, err = io.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("read all error: %w", err)
+		}
+	}
+	header.Del("Authorization")
+	return c.send(ctx, method, c.EndPoint+targetPath, bodyBytes, header)
+}
+
+func (c *Client) CreateChatCompletions(ctx context.Context, req *v1.ChatCompletionRequest) (io.ReadCloser, http.Header, error) {
+	newReq, err := ConvertChatRequest(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.Stream {
+		respBody, _, err := c.InvokeModel(ctx, req.Model, "converse-stream", newReq)
+		if err != nil {
+			return nil, nil, err
+		}
+		includeUsage := req.StreamOptions != nil && req.StreamOptions.IncludeUsage
+		return ConvertStreamResponse(respBody, req.Model, includeUsage), base.NewStreamHeader(), nil
+	}
+	respBody, _, err := c.InvokeModel(ctx, req.Model, "converse", newReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read all error: %w", err)
+	}
+	var resp ConverseResponse
+	if err = sonic.Unmarshal(respBytes, &resp); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	newRespBytes, err := sonic.Marshal(ConvertChatResponse(&resp, req.Model))
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(newRespBytes)), base.NewJsonHeader(), nil
+}
+
+// CreateEmbeddings 通过 InvokeModel 调用 Titan 或 Cohere 向量模型
+func (c *Client) CreateEmbeddings(ctx context.Context, req *v1.EmbeddingsRequest) (io.ReadCloser, http.Header, error) {
+	inputs, err := req.InputStrings()
+	if err != nil {
+		return nil, nil, err
+	}
+	newResp := v1.EmbeddingsResponse{
+		Object: "list",
+		Model:  req.Model,
+		Data:   make([]v1.EmbeddingsData, 0, len(inputs)),
+	}
+	var embeddings [][]float64
+	switch {
+	case strings.Contains(req.Model, "amazon.titan-embed"):
+		// Titan 每次只接受一条输入
+		for _, input := range inputs {
+			var resp TitanEmbeddingResponse
+			err = c.invokeJson(ctx, req.Model, &TitanEmbeddingRequest{InputText: input, Dimensions: req.Dimensions}, &resp)
+			if err != nil {
+				return nil, nil, err
+			}
+			embeddings = append(embeddings, resp.Embedding)
+			newResp.Usage.PromptTokens += resp.InputTextTokenCount
+		}
+	case strings.Contains(req.Model, "cohere.embed"):
+		var resp CohereEmbeddingResponse
+		err = c.invokeJson(ctx, req.Model, &CohereEmbeddingRequest{Texts: inputs, InputType: "search_document"}, &resp)
+		if err != nil {
+			return nil, nil, err
+		}
+		embeddings = resp.Embeddings
+	default:
+		return nil, nil, fmt.Errorf("unsupported embedding model: %s", req.Model)
+	}
+	newResp.Usage.TotalTokens = newResp.Usage.PromptTokens
+	for i, embedding := range embeddings {
+		values := make([]any, 0, len(embedding))
+		for _, value := range embedding {
+			values = append(values, value)
+		}
+		newResp.Data = append(newResp.Data, v1.EmbeddingsData{
+			Object:    "embedding",
+			Index:     i,
+			Embedding: values,
+		})
+	}
+	newRespBytes, err := sonic.Marshal(newResp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(newRespBytes)), base.NewJsonHeader(), nil
+}
+
+func (c *Client) invokeJson(ctx context.Context, modelId string, req, resp any) error {
+	respBody, _, err := c.InvokeModel(ctx, modelId, "invoke", req)
+	if err != nil {
+		return err
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return fmt.Errorf("read all error: %w", err)
+	}
+	if err = sonic.Unmarshal(respBytes, resp); err != nil {
+		return fmt.Errorf("unmarshal error: %w", err)
+	}
+	return nil
+}
+
+// Models 调用控制面 ListFoundationModels, 仅在使用官方地址时可用
+func (c *Client) Models(ctx context.Context) (*v1.ModelResponse, error) {
+	if !strings.Contains(c.EndPoint, "bedrock-runtime.") {
+		return nil, errors.New("models is only supported with the default bedrock-runtime endpoint")
+	}
+	targetUrl := strings.Replace(c.EndPoint, "bedrock-runtime.", "bedrock.", 1) + "/foundation-models"
+	body, _, err := c.send(ctx, http.MethodGet, targetUrl, nil, http.Header{})
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	dataBytes, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("read all error: %w", err)
+	}
+	var list FoundationModelList
+	if err = sonic.Unmarshal(dataBytes, &list); err != nil {
+		return nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	resp := &v1.ModelResponse{Object: "list", Data: make([]v1.Model, 0, len(list.ModelSummaries))}
+	now := time.Now().Unix()
+	for _, model := range list.ModelSummaries {
+		resp.Data = append(resp.Data, v1.Model{
+			ID:      model.ModelId,
+			Object:  "model",
+			Created: now,
+			OwnedBy: model.ProviderName,
+		})
+	}
+	return resp, nil
+}
+
+func (c *Client) CreateResponses(ctx context.Context, req *v1.ResponsesRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateCompletions(ctx context.Context, req *v1.CompletionsRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateRerank(ctx context.Context, req *v1.RerankRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImage(ctx context.Context, req *v1.ImageGenerateRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImageEdit(ctx context.Context, req *v1.ImageEditRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImageVariation(ctx context.Context, req *v1.ImageVariationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateSpeech(ctx context.Context, req *v1.AudioSpeechRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateTranslation(ctx context.Context, req *v1.TranslationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateTranscription(ctx context.Context, req *v1.TranscriptionRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateVideoSubmit(ctx context.Context, req *v1.VideoRequest) (*v1.VideoResponse, error) {
+	_, _, err := base.NoImplementMethod(ctx, req)
+	return nil, err
+}
+
+func (c *Client) GetVideoStatus(ctx context.Context, externalID string) (bool, any, error) {
+	_, _, err := base.NoImplementMethod(ctx, externalID)
+	return false, nil, err
+}
@@ -0,0 +1,433 @@
+package bedrock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/pkg/rr"
+	"github.com/jiu-u/oai-adapter/tools"
+	"io"
+	"strings"
+	"time"
+)
+
+var emptyInputSchema = json.RawMessage(`{"type":"object","properties":{}}`)
+
+// documentFormats MIME 类型到 Converse 文档格式的映射
+var documentFormats = map[string]string{
+	"application/pdf":    "pdf",
+	"text/csv":           "csv",
+	"text/html":          "html",
+	"text/plain":         "txt",
+	"text/markdown":      "md",
+	"application/msword": "doc",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": "docx",
+	"application/vnd.ms-excel": "xls",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": "xlsx",
+}
+
+// ConvertChatRequest 将 ChatCompletionRequest 转换为 Converse 请求
+func ConvertChatRequest(req *v1.ChatCompletionRequest) (*ConverseRequest, error) {
+	newReq := &ConverseRequest{
+		InferenceConfig: &InferenceConfig{
+			MaxTokens:     req.MaxOutputTokens(),
+			StopSequences: req.StopSequences(),
+		},
+	}
+	if req.Temperature != 0 {
+		temperature := min(req.Temperature, 1)
+		newReq.InferenceConfig.Temperature = &temperature
+	}
+	if req.TopP != 0 {
+		topP := req.TopP
+		newReq.InferenceConfig.TopP = &topP
+	}
+
+	// 连续相同角色的消息需要合并, tool 结果需放在 user 消息中
+	for i := range req.Messages {
+		msg := &req.Messages[i]
+		var role string
+		var blocks []ContentBlock
+		var err error
+		switch msg.Role {
+		case "system", "developer":
+			text := messageText(msg)
+			if text != "" {
+				newReq.System = append(newReq.System, SystemContent{Text: text})
+			}
+			continue
+		case "tool":
+			role = "user"
+			blocks = []ContentBlock{toolResultBlock(msg)}
+		case "assistant":
+			role = "assistant"
+			blocks, err = assistantToBlocks(msg)
+		default:
+			role = "user"
+			blocks, err = messageToBlocks(msg)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+		if n := len(newReq.Messages); n > 0 && newReq.Messages[n-1].Role == role {
+			newReq.Messages[n-1].Content = append(newReq.Messages[n-1].Content, blocks...)
+			continue
+		}
+		newReq.Messages = append(newReq.Messages, Message{Role: role, Content: blocks})
+	}
+
+	var toolSpecs []Tool
+	for _, tool := range req.Tools {
+		if tool.Type != "" && tool.Type != "function" {
+			continue
+		}
+		schema := tool.Function.Parameters
+		if len(schema) == 0 || string(schema) == "null" {
+			schema = emptyInputSchema
+		}
+		toolSpecs = append(toolSpecs, Tool{ToolSpec: ToolSpec{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: InputSchema{Json: schema},
+		}})
+	}
+	// Converse 没有 none, 此时仅不传 toolChoice; 历史消息含 toolUse/toolResult 时必须携带 toolConfig
+	choiceType, name := req.ParseToolChoice()
+	if len(toolSpecs) > 0 {
+		newReq.ToolConfig = &ToolConfig{Tools: toolSpecs}
+		switch choiceType {
+		case v1.ToolChoiceAuto:
+			newReq.ToolConfig.ToolChoice = &ToolChoice{Auto: &struct{}{}}
+		case v1.ToolChoiceRequired:
+			newReq.ToolConfig.ToolChoice = &ToolChoice{Any: &struct{}{}}
+		case v1.ToolChoiceFunction:
+			newReq.ToolConfig.ToolChoice = &ToolChoice{Tool: &SpecificToolChoice{Name: name}}
+		}
+	}
+	return newReq, nil
+}
+
+func messageText(msg *v1.Message) string {
+	if msg.IsStringContent() {
+		return msg.StringContent()
+	}
+	var texts []string
+	if mediaContents, err := msg.ParseContent(); err == nil {
+		for _, mediaContent := range mediaContents {
+			if mediaContent.Type == v1.ContentTypeText {
+				texts = append(texts, mediaContent.Text)
+			}
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+func messageToBlocks(msg *v1.Message) ([]ContentBlock, error) {
+	if len(msg.Content) == 0 || string(msg.Content) == "null" {
+		return nil, nil
+	}
+	mediaContents, err := msg.ParseContent()
+	if err != nil {
+		return nil, err
+	}
+	blocks := make([]ContentBlock, 0, len(mediaContents))
+	for i, mediaContent := range mediaContents {
+		switch mediaContent.Type {
+		case v1.ContentTypeText:
+			if mediaContent.Text == "" {
+				continue
+			}
+			blocks = append(blocks, ContentBlock{Text: mediaContent.Text})
+		case v1.ContentTypeImageURL:
+			if mediaContent.ImageUrl == nil || mediaContent.ImageUrl.Url == "" {
+				return nil, errors.New("image_url is empty")
+			}
+			image, err := urlToImage(mediaContent.ImageUrl.Url)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, ContentBlock{Image: image})
+		case v1.ContentTypeFile:
+			if mediaContent.File == nil {
+				return nil, errors.New("file is empty")
+			}
+			fileData, _ := mediaContent.File.FileData.(string)
+			mimeType, data, ok := tools.ParseDataURL(fileData)
+			if !ok {
+				return nil, errors.New("only base64 file_data is supported")
+			}
+			format, ok := documentFormats[mimeType]
+			if !ok {
+				return nil, fmt.Errorf("unsupported document type: %s", mimeType)
+			}
+			// 文档名只允许字母、数字、空格、连字符与括号
+			name := fmt.Sprintf("document-%d", i+1)
+			blocks = append(blocks, ContentBlock{Document: &DocumentBlock{
+				Format: format,
+				Name:   name,
+				Source: BytesSource{Bytes: data},
+			}})
+		default:
+			return nil, fmt.Errorf("unsupported content type: %s", mediaContent.Type)
+		}
+	}
+	return blocks, nil
+}
+
+func urlToImage(url string) (*ImageBlock, error) {
+	mimeType, data, ok := tools.ParseDataURL(url)
+	if !ok {
+		// Converse 只接受图片字节, 网络图片需要先下载
+		f, err := tools.NewImageFileData(url, true)
+		if err != nil {
+			return nil, err
+		}
+		mimeType, data, ok = tools.ParseDataURL(f.URL)
+		if !ok {
+			return nil, fmt.Errorf("invalid image url: %s", url)
+		}
+	}
+	format := strings.TrimPrefix(mimeType, "image/")
+	if format == "jpg" {
+		format = "jpeg"
+	}
+	return &ImageBlock{Format: format, Source: BytesSource{Bytes: data}}, nil
+}
+
+func assistantToBlocks(msg *v1.Message) ([]ContentBlock, error) {
+	blocks, err := messageToBlocks(msg)
+	if err != nil {
+		return nil, err
+	}
+	for _, toolCall := range msg.ToolCalls {
+		input := json.RawMessage(toolCall.Function.Arguments)
+		if len(strings.TrimSpace(toolCall.Function.Arguments)) == 0 || !json.Valid(input) {
+			input = json.RawMessage("{}")
+		}
+		blocks = append(blocks, ContentBlock{ToolUse: &ToolUseBlock{
+			ToolUseId: toolCall.Id,
+			Name:      toolCall.Function.Name,
+			Input:     input,
+		}})
+	}
+	return blocks, nil
+}
+
+func toolResultBlock(msg *v1.Message) ContentBlock {
+	text := messageText(msg)
+	content := ToolResultContent{Text: text}
+	// JSON 对象结果使用 json 字段
+	if strings.HasPrefix(strings.TrimSpace(text), "{") && json.Valid([]byte(text)) {
+		content = ToolResultContent{Json: json.RawMessage(text)}
+	}
+	return ContentBlock{ToolResult: &ToolResultBlock{
+		ToolUseId: msg.ToolCallId,
+		Content:   []ToolResultContent{content},
+	}}
+}
+
+// ConvertFinishReason Converse stopReason 转 OpenAI finish_reason
+func ConvertFinishReason(stopReason string) string {
+	switch stopReason {
+	case "end_turn", "stop_sequence":
+		return "stop"
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	case "guardrail_intervened", "content_filtered":
+		return "content_filter"
+	case "":
+		return ""
+	default:
+		return "stop"
+	}
+}
+
+func convertUsage(usage TokenUsage) v1.Usage {
+	return v1.Usage{
+		PromptTokens:     usage.InputTokens,
+		CompletionTokens: usage.OutputTokens,
+		TotalTokens:      usage.InputTokens + usage.OutputTokens,
+	}
+}
+
+// ConvertChatResponse 将 Converse 响应转换为 ChatCompletionResponse
+func ConvertChatResponse(resp *ConverseResponse, model string) *v1.ChatCompletionResponse {
+	message := v1.Message{Role: "assistant"}
+	var texts []string
+	for _, block := range resp.Output.Message.Content {
+		switch {
+		case block.ToolUse != nil:
+			arguments := string(block.ToolUse.Input)
+			if arguments == "" {
+				arguments = "{}"
+			}
+			message.ToolCalls = append(message.ToolCalls, v1.ToolCall{
+				Id:       block.ToolUse.ToolUseId,
+				Type:     "function",
+				Function: v1.Function{Name: block.ToolUse.Name, Arguments: arguments},
+			})
+		case block.Text != "":
+			texts = append(texts, block.Text)
+		}
+	}
+	if len(texts) > 0 || len(message.ToolCalls) == 0 {
+		message.SetStringContent(strings.Join(texts, ""))
+	} else {
+		message.Content = json.RawMessage("null")
+	}
+	return &v1.ChatCompletionResponse{
+		ID:      "chatcmpl-" + rr.GenString(24),
+		Model:   model,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Choices: []v1.Choice{{
+			Message:      message,
+			FinishReason: ConvertFinishReason(resp.StopReason),
+		}},
+		Usage: convertUsage(resp.Usage),
+	}
+}
+
+// streamConverter 维护 ConverseStream 事件到 chat.completion.chunk 的转换状态
+type streamConverter struct {
+	id        string
+	model     string
+	created   int64
+	toolIndex map[int]int // contentBlockIndex -> tool call index
+	usage     *TokenUsage
+}
+
+func (s *streamConverter) chunk(delta v1.Delta, finishReason string) *v1.ChatCompletionStreamResponse {
+	return &v1.ChatCompletionStreamResponse{
+		ID:      s.id,
+		Model:   s.model,
+		Object:  "chat.completion.chunk",
+		Created: s.created,
+		Choices: []v1.ChoiceWithDelta{{Delta: delta, FinishReason: finishReason}},
+	}
+}
+
+func (s *streamConverter) convert(msg *EventMessage) (*v1.ChatCompletionStreamResponse, error) {
+	if messageType := msg.Headers[":message-type"]; messageType == "exception" || messageType == "error" {
+		var exception ExceptionEvent
+		_ = sonic.Unmarshal(msg.Payload, &exception)
+		errorType := msg.Headers[":exception-type"]
+		if errorType == "" {
+			errorType = msg.Headers[":error-code"]
+		}
+		if exception.Message == "" {
+			exception.Message = msg.Headers[":error-message"]
+		}
+		return nil, fmt.Errorf("bedrock stream error: %s: %s", errorType, exception.Message)
+	}
+	switch msg.Headers[":event-type"] {
+	case "messageStart":
+		return s.chunk(v1.Delta{Role: "assistant"}, ""), nil
+	case "contentBlockStart":
+		var ev ContentBlockStartEvent
+		if err := sonic.Unmarshal(msg.Payload, &ev); err != nil {
+			return nil, fmt.Errorf("unmarshal error: %w", err)
+		}
+		if ev.Start.ToolUse == nil {
+			return nil, nil
+		}
+		index := len(s.toolIndex)
+		s.toolIndex[ev.ContentBlockIndex] = index
+		return s.chunk(v1.Delta{Role: "assistant", ToolCalls: []v1.ToolCall{{
+			Id:       ev.Start.ToolUse.ToolUseId,
+			Type:     "function",
+			Function: v1.Function{Name: ev.Start.ToolUse.Name},
+			Index:    &index,
+		}}}, ""), nil
+	case "contentBlockDelta":
+		var ev ContentBlockDeltaEvent
+		if err := sonic.Unmarshal(msg.Payload, &ev); err != nil {
+			return nil, fmt.Errorf("unmarshal error: %w", err)
+		}
+		if ev.Delta.ToolUse != nil {
+			index, ok := s.toolIndex[ev.ContentBlockIndex]
+			if !ok || ev.Delta.ToolUse.Input == "" {
+				return nil, nil
+			}
+			return s.chunk(v1.Delta{Role: "assistant", ToolCalls: []v1.ToolCall{{
+				Type:     "function",
+				Function: v1.Function{Arguments: ev.Delta.ToolUse.Input},
+				Index:    &index,
+			}}}, ""), nil
+		}
+		if ev.Delta.Text == "" {
+			return nil, nil
+		}
+		return s.chunk(v1.Delta{Role: "assistant", Content: ev.Delta.Text}, ""), nil
+	case "messageStop":
+		var ev MessageStopEvent
+		if err := sonic.Unmarshal(msg.Payload, &ev); err != nil {
+			return nil, fmt.Errorf("unmarshal error: %w", err)
+		}
+		return s.chunk(v1.Delta{Role: "assistant"}, ConvertFinishReason(ev.StopReason)), nil
+	case "metadata":
+		var ev MetadataEvent
+		if err := sonic.Unmarshal(msg.Payload, &ev); err != nil {
+			return nil, fmt.Errorf("unmarshal error: %w", err)
+		}
+		s.usage = &ev.Usage
+	}
+	return nil, nil
+}
+
+// ConvertStreamResponse 将 ConverseStream 的 event-stream 二进制帧转换为 OpenAI chat.completion.chunk 流
+func ConvertStreamResponse(respBody io.ReadCloser, model string, includeUsage bool) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		defer respBody.Close()
+		converter := &streamConverter{
+			id:        "chatcmpl-" + rr.GenString(24),
+			model:     model,
+			created:   time.Now().Unix(),
+			toolIndex: make(map[int]int),
+		}
+		decoder := NewEventStreamDecoder(respBody)
+		for {
+			msg, err := decoder.Decode()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			chunk, err := converter.convert(msg)
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			if chunk == nil {
+				continue
+			}
+			if err = base.WriteSSEData(pw, chunk); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		if includeUsage && converter.usage != nil {
+			usageChunk := converter.chunk(v1.Delta{}, "")
+			usageChunk.Choices = []v1.ChoiceWithDelta{}
+			usageChunk.Usage = convertUsage(*converter.usage)
+			if err := base.WriteSSEData(pw, usageChunk); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		_ = base.WriteSSEDone(pw)
+		pw.Close()
+	}()
+	return pr
+}
@@ -0,0 +1,162 @@
+package bedrock
+
+import "encoding/json"
+
+// https://docs.aws.amazon.com/bedrock/latest/APIReference/API_runtime_Converse.html
+
+type (
+	ConverseRequest struct {
+		Messages        []Message        `json:"messages"`
+		System          []SystemContent  `json:"system,omitempty"`
+		InferenceConfig *InferenceConfig `json:"inferenceConfig,omitempty"`
+		ToolConfig      *ToolConfig      `json:"toolConfig,omitempty"`
+	}
+	Message struct {
+		Role    string         `json:"role"` // user 或 assistant
+		Content []ContentBlock `json:"content"`
+	}
+	ContentBlock struct {
+		Text       string           `json:"text,omitempty"`
+		Image      *ImageBlock      `json:"image,omitempty"`
+		Document   *DocumentBlock   `json:"document,omitempty"`
+		ToolUse    *ToolUseBlock    `json:"toolUse,omitempty"`
+		ToolResult *ToolResultBlock `json:"toolResult,omitempty"`
+	}
+	SystemContent struct {
+		Text string `json:"text"`
+	}
+	ImageBlock struct {
+		Format string      `json:"format"` // png, jpeg, gif, webp
+		Source BytesSource `json:"source"`
+	}
+	DocumentBlock struct {
+		Format string      `json:"format"` // pdf, csv, doc, docx, xls, xlsx, html, txt, md
+		Name   string      `json:"name"`
+		Source BytesSource `json:"source"`
+	}
+	BytesSource struct {
+		Bytes string `json:"bytes"` // base64
+	}
+	ToolUseBlock struct {
+		ToolUseId string          `json:"toolUseId"`
+		Name      string          `json:"name"`
+		Input     json.RawMessage `json:"input"`
+	}
+	ToolResultBlock struct {
+		ToolUseId string              `json:"toolUseId"`
+		Content   []ToolResultContent `json:"content"`
+		Status    string              `json:"status,omitempty"` // success 或 error
+	}
+	ToolResultContent struct {
+		Text string          `json:"text,omitempty"`
+		Json json.RawMessage `json:"json,omitempty"`
+	}
+	InferenceConfig struct {
+		MaxTokens     int      `json:"maxTokens,omitempty"`
+		Temperature   *float64 `json:"temperature,omitempty"`
+		TopP          *float64 `json:"topP,omitempty"`
+		StopSequences []string `json:"stopSequences,omitempty"`
+	}
+	ToolConfig struct {
+		Tools      []Tool      `json:"tools"`
+		ToolChoice *ToolChoice `json:"toolChoice,omitempty"`
+	}
+	Tool struct {
+		ToolSpec ToolSpec `json:"toolSpec"`
+	}
+	ToolSpec struct {
+		Name        string      `json:"name"`
+		Description string      `json:"description,omitempty"`
+		InputSchema InputSchema `json:"inputSchema"`
+	}
+	InputSchema struct {
+		Json json.RawMessage `json:"json"`
+	}
+	// ToolChoice auto、any、tool 三选一
+	ToolChoice struct {
+		Auto *struct{}           `json:"auto,omitempty"`
+		Any  *struct{}           `json:"any,omitempty"`
+		Tool *SpecificToolChoice `json:"tool,omitempty"`
+	}
+	SpecificToolChoice struct {
+		Name string `json:"name"`
+	}
+)
+
+type (
+	ConverseResponse struct {
+		Output     ConverseOutput `json:"output"`
+		StopReason string         `json:"stopReason"`
+		Usage      TokenUsage     `json:"usage"`
+	}
+	ConverseOutput struct {
+		Message Message `json:"message"`
+	}
+	TokenUsage struct {
+		InputTokens  int `json:"inputTokens"`
+		OutputTokens int `json:"outputTokens"`
+		TotalTokens  int `json:"totalTokens"`
+	}
+)
+
+// ConverseStream 事件, 事件类型由 :event-type 头给出
+type (
+	ContentBlockStartEvent struct {
+		ContentBlockIndex int `json:"contentBlockIndex"`
+		Start             struct {
+			ToolUse *struct {
+				ToolUseId string `json:"toolUseId"`
+				Name      string `json:"name"`
+			} `json:"toolUse,omitempty"`
+		} `json:"start"`
+	}
+	ContentBlockDeltaEvent struct {
+		ContentBlockIndex int `json:"contentBlockIndex"`
+		Delta             struct {
+			Text    string `json:"text,omitempty"`
+			ToolUse *struct {
+				Input string `json:"input"`
+			} `json:"toolUse,omitempty"`
+		} `json:"delta"`
+	}
+	MessageStopEvent struct {
+		StopReason string `json:"stopReason"`
+	}
+	MetadataEvent struct {
+		Usage TokenUsage `json:"usage"`
+	}
+	ExceptionEvent struct {
+		Message string `json:"message"`
+	}
+)
+
+// embeddings, InvokeModel 的请求体由模型决定
+type (
+	TitanEmbeddingRequest struct {
+		InputText  string `json:"inputText"`
+		Dimensions int    `json:"dimensions,omitempty"`
+	}
+	TitanEmbeddingResponse struct {
+		Embedding           []float64 `json:"embedding"`
+		InputTextTokenCount int       `json:"inputTextTokenCount"`
+	}
+	CohereEmbeddingRequest struct {
+		Texts     []string `json:"texts"`
+		InputType string   `json:"input_type"`
+	}
+	CohereEmbeddingResponse struct {
+		Embeddings [][]float64 `json:"embeddings"`
+	}
+)
+
+// models, 位于控制面 bedrock.{region}.amazonaws.com
+type (
+	FoundationModelList struct {
+		ModelSummaries []FoundationModelSummary `json:"modelSummaries"`
+	}
+	FoundationModelSummary struct {
+		ModelId      string `json:"modelId"`
+		ModelName    string `json:"modelName"`
+		ProviderName string `json:"providerName"`
+	}
+)
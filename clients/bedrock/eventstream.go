@@ -0,0 +1,110 @@
+package bedrock
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// https://docs.aws.amazon.com/transcribe/latest/dg/event-stream.html
+// 帧格式: total_length(4) headers_length(4) prelude_crc(4) headers payload message_crc(4)
+
+const maxEventMessageSize = 16 << 20
+
+// EventMessage event-stream 消息, 这里只保留字符串类型的头
+type EventMessage struct {
+	Headers map[string]string
+	Payload []byte
+}
+
+type EventStreamDecoder struct {
+	r *bufio.Reader
+}
+
+func NewEventStreamDecoder(r io.Reader) *EventStreamDecoder {
+	return &EventStreamDecoder{r: bufio.NewReader(r)}
+}
+
+// Decode 读取下一条消息, 流结束时返回 io.EOF
+func (d *EventStreamDecoder) Decode() (*EventMessage, error) {
+	prelude := make([]byte, 12)
+	if _, err := io.ReadFull(d.r, prelude); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, errors.New("event stream truncated")
+		}
+		return nil, err
+	}
+	totalLength := binary.BigEndian.Uint32(prelude[0:4])
+	headersLength := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[0:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return nil, errors.New("event stream prelude crc mismatch")
+	}
+	if totalLength < 16 || totalLength > maxEventMessageSize || headersLength > totalLength-16 {
+		return nil, fmt.Errorf("invalid event stream message length: %d", totalLength)
+	}
+	message := make([]byte, totalLength)
+	copy(message, prelude)
+	if _, err := io.ReadFull(d.r, message[12:]); err != nil {
+		return nil, fmt.Errorf("read event stream message error: %w", err)
+	}
+	if crc32.ChecksumIEEE(message[:totalLength-4]) != binary.BigEndian.Uint32(message[totalLength-4:]) {
+		return nil, errors.New("event stream message crc mismatch")
+	}
+	headers, err := decodeHeaders(message[12 : 12+headersLength])
+	if err != nil {
+		return nil, err
+	}
+	return &EventMessage{
+		Headers: headers,
+		Payload: message[12+headersLength : totalLength-4],
+	}, nil
+}
+
+func decodeHeaders(data []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	errInvalid := errors.New("invalid event stream header")
+	for len(data) > 0 {
+		nameLength := int(data[0])
+		if len(data) < 1+nameLength+1 {
+			return nil, errInvalid
+		}
+		name := string(data[1 : 1+nameLength])
+		valueType := data[1+nameLength]
+		data = data[2+nameLength:]
+		// 各类型值的长度, 变长类型(bytes、string)使用 2 字节长度前缀
+		var size int
+		switch valueType {
+		case 0, 1: // bool true / false
+			size = 0
+		case 2: // byte
+			size = 1
+		case 3: // short
+			size = 2
+		case 4: // int
+			size = 4
+		case 5, 8: // long, timestamp
+			size = 8
+		case 9: // uuid
+			size = 16
+		case 6, 7: // bytes, string
+			if len(data) < 2 {
+				return nil, errInvalid
+			}
+			size = int(binary.BigEndian.Uint16(data[0:2]))
+			data = data[2:]
+		default:
+			return nil, fmt.Errorf("unknown event stream header type: %d", valueType)
+		}
+		if len(data) < size {
+			return nil, errInvalid
+		}
+		if valueType == 7 {
+			headers[name] = string(data[:size])
+		}
+		data = data[size:]
+	}
+	return headers, nil
+}
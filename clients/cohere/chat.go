@@ -0,0 +1,298 @@
+package cohere
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/pkg/rr"
+	"io"
+	"strings"
+	"time"
+)
+
+// ConvertChatRequest 将 ChatCompletionRequest 转换为 /v2/chat 请求, v2 的消息结构与 OpenAI 基本一致
+func ConvertChatRequest(req *v1.ChatCompletionRequest) (*ChatRequest, error) {
+	newReq := &ChatRequest{
+		Model:            req.Model,
+		Stream:           req.Stream,
+		MaxTokens:        req.MaxOutputTokens(),
+		StopSequences:    req.StopSequences(),
+		P:                req.TopP,
+		Seed:             req.Seed,
+		FrequencyPenalty: req.FrequencyPenalty,
+		PresencePenalty:  req.PresencePenalty,
+		Messages:         make([]Message, 0, len(req.Messages)),
+	}
+	if req.Temperature != 0 {
+		temperature := req.Temperature
+		newReq.Temperature = &temperature
+	}
+	for i := range req.Messages {
+		msg := &req.Messages[i]
+		role := msg.Role
+		if role == "developer" {
+			role = "system"
+		}
+		content, err := convertContent(msg)
+		if err != nil {
+			return nil, err
+		}
+		newMsg := Message{Role: role, Content: content, ToolCallId: msg.ToolCallId}
+		for _, toolCall := range msg.ToolCalls {
+			newMsg.ToolCalls = append(newMsg.ToolCalls, ToolCall{
+				Id:       toolCall.Id,
+				Type:     "function",
+				Function: ToolFunction{Name: toolCall.Function.Name, Arguments: toolCall.Function.Arguments},
+			})
+		}
+		newReq.Messages = append(newReq.Messages, newMsg)
+	}
+	for _, tool := range req.Tools {
+		if tool.Type != "" && tool.Type != "function" {
+			continue
+		}
+		newReq.Tools = append(newReq.Tools, Tool{
+			Type: "function",
+			Function: FunctionDefinition{
+				Name:        tool.Function.Name,
+				Description: tool.Function.Description,
+				Parameters:  tool.Function.Parameters,
+			},
+		})
+	}
+	if len(newReq.Tools) > 0 {
+		// Cohere 只支持 REQUIRED 与 NONE, 指定函数时退化为 REQUIRED
+		switch choiceType, _ := req.ParseToolChoice(); choiceType {
+		case v1.ToolChoiceRequired, v1.ToolChoiceFunction:
+			newReq.ToolChoice = "REQUIRED"
+		case v1.ToolChoiceNone:
+			newReq.ToolChoice = "NONE"
+		}
+	}
+	if req.ResponseFormat != nil {
+		switch req.ResponseFormat.Type {
+		case "json_object":
+			newReq.ResponseFormat = &ResponseFormat{Type: "json_object"}
+		case "json_schema":
+			newReq.ResponseFormat = &ResponseFormat{Type: "json_object"}
+			if req.ResponseFormat.JsonSchema != nil {
+				newReq.ResponseFormat.JsonSchema = req.ResponseFormat.JsonSchema.Schema
+			}
+		}
+	}
+	return newReq, nil
+}
+
+// convertContent 字符串内容直接透传, 数组内容仅保留文本与图片
+func convertContent(msg *v1.Message) (json.RawMessage, error) {
+	if len(msg.Content) == 0 || string(msg.Content) == "null" {
+		return nil, nil
+	}
+	if msg.IsStringContent() {
+		return msg.Content, nil
+	}
+	mediaContents, err := msg.ParseContent()
+	if err != nil {
+		return nil, err
+	}
+	contents := make([]Content, 0, len(mediaContents))
+	for _, mediaContent := range mediaContents {
+		switch mediaContent.Type {
+		case v1.ContentTypeText:
+			contents = append(contents, Content{Type: "text", Text: mediaContent.Text})
+		case v1.ContentTypeImageURL:
+			if mediaContent.ImageUrl == nil || mediaContent.ImageUrl.Url == "" {
+				return nil, errors.New("image_url is empty")
+			}
+			contents = append(contents, Content{Type: "image_url", ImageUrl: &ImageUrl{Url: mediaContent.ImageUrl.Url}})
+		default:
+			return nil, fmt.Errorf("unsupported content type: %s", mediaContent.Type)
+		}
+	}
+	content, err := sonic.Marshal(contents)
+	if err != nil {
+		return nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return content, nil
+}
+
+// ConvertFinishReason Cohere finish_reason 转 OpenAI finish_reason
+func ConvertFinishReason(finishReason string) string {
+	switch finishReason {
+	case "COMPLETE", "STOP_SEQUENCE":
+		return "stop"
+	case "MAX_TOKENS":
+		return "length"
+	case "TOOL_CALL":
+		return "tool_calls"
+	case "ERROR_TOXIC":
+		return "content_filter"
+	case "":
+		return ""
+	default:
+		return "stop"
+	}
+}
+
+func convertUsage(usage *Usage) v1.Usage {
+	if usage == nil {
+		return v1.Usage{}
+	}
+	// 优先使用实际 token 数, 其次为计费 token 数
+	var input, output float64
+	if usage.Tokens != nil {
+		input, output = usage.Tokens.InputTokens, usage.Tokens.OutputTokens
+	} else if usage.BilledUnits != nil {
+		input, output = usage.BilledUnits.InputTokens, usage.BilledUnits.OutputTokens
+	}
+	return v1.Usage{
+		PromptTokens:     int(input),
+		CompletionTokens: int(output),
+		TotalTokens:      int(input + output),
+	}
+}
+
+// ConvertChatResponse 将 /v2/chat 响应转换为 ChatCompletionResponse
+func ConvertChatResponse(resp *ChatResponse, model string) *v1.ChatCompletionResponse {
+	message := v1.Message{Role: "assistant"}
+	var texts []string
+	for _, content := range resp.Message.Content {
+		if content.Type == "text" {
+			texts = append(texts, content.Text)
+		}
+	}
+	for _, toolCall := range resp.Message.ToolCalls {
+		arguments := toolCall.Function.Arguments
+		if arguments == "" {
+			arguments = "{}"
+		}
+		message.ToolCalls = append(message.ToolCalls, v1.ToolCall{
+			Id:       toolCall.Id,
+			Type:     "function",
+			Function: v1.Function{Name: toolCall.Function.Name, Arguments: arguments},
+		})
+	}
+	if len(texts) > 0 || len(message.ToolCalls) == 0 {
+		message.SetStringContent(strings.Join(texts, ""))
+	} else {
+		message.Content = json.RawMessage("null")
+	}
+	id := resp.ID
+	if id == "" {
+		id = "chatcmpl-" + rr.GenString(24)
+	}
+	return &v1.ChatCompletionResponse{
+		ID:      id,
+		Model:   model,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Choices: []v1.Choice{{
+			Message:      message,
+			FinishReason: ConvertFinishReason(resp.FinishReason),
+		}},
+		Usage: convertUsage(resp.Usage),
+	}
+}
+
+// streamConverter 维护 /v2/chat 流式事件到 chat.completion.chunk 的转换状态
+type streamConverter struct {
+	id           string
+	model        string
+	created      int64
+	includeUsage bool
+}
+
+func (s *streamConverter) chunk(delta v1.Delta, finishReason string) *v1.ChatCompletionStreamResponse {
+	return &v1.ChatCompletionStreamResponse{
+		ID:      s.id,
+		Model:   s.model,
+		Object:  "chat.completion.chunk",
+		Created: s.created,
+		Choices: []v1.ChoiceWithDelta{{Delta: delta, FinishReason: finishReason}},
+	}
+}
+
+func (s *streamConverter) convert(ev *StreamEvent) (chunks []*v1.ChatCompletionStreamResponse, err error) {
+	switch ev.Type {
+	case "message-start":
+		if ev.ID != "" {
+			s.id = ev.ID
+		}
+		chunks = append(chunks, s.chunk(v1.Delta{Role: "assistant"}, ""))
+	case "content-delta":
+		if ev.Delta == nil || ev.Delta.Message == nil || ev.Delta.Message.Content == nil {
+			return nil, nil
+		}
+		chunks = append(chunks, s.chunk(v1.Delta{Role: "assistant", Content: ev.Delta.Message.Content.Text}, ""))
+	case "tool-call-start", "tool-call-delta":
+		if ev.Delta == nil || ev.Delta.Message == nil || ev.Delta.Message.ToolCalls == nil {
+			return nil, nil
+		}
+		index := ev.Index
+		toolCall := ev.Delta.Message.ToolCalls
+		chunks = append(chunks, s.chunk(v1.Delta{Role: "assistant", ToolCalls: []v1.ToolCall{{
+			Id:       toolCall.Id,
+			Type:     "function",
+			Function: v1.Function{Name: toolCall.Function.Name, Arguments: toolCall.Function.Arguments},
+			Index:    &index,
+		}}}, ""))
+	case "message-end":
+		if ev.Delta == nil {
+			return nil, nil
+		}
+		if ev.Delta.Error != "" {
+			return nil, fmt.Errorf("cohere stream error: %s", ev.Delta.Error)
+		}
+		chunks = append(chunks, s.chunk(v1.Delta{Role: "assistant"}, ConvertFinishReason(ev.Delta.FinishReason)))
+		if s.includeUsage {
+			usageChunk := s.chunk(v1.Delta{}, "")
+			usageChunk.Choices = []v1.ChoiceWithDelta{}
+			usageChunk.Usage = convertUsage(ev.Delta.Usage)
+			chunks = append(chunks, usageChunk)
+		}
+	}
+	return chunks, nil
+}
+
+// ConvertStreamResponse 将 /v2/chat 的 SSE 流转换为 OpenAI chat.completion.chunk 流
+func ConvertStreamResponse(respBody io.ReadCloser, model string, includeUsage bool) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		defer respBody.Close()
+		converter := &streamConverter{
+			id:           "chatcmpl-" + rr.GenString(24),
+			model:        model,
+			created:      time.Now().Unix(),
+			includeUsage: includeUsage,
+		}
+		err := base.ReadSSE(respBody, func(sse *base.SSEEvent) error {
+			if len(sse.Data) == 0 {
+				return nil
+			}
+			var ev StreamEvent
+			if err := sonic.Unmarshal(sse.Data, &ev); err != nil {
+				return fmt.Errorf("unmarshal error: %w", err)
+			}
+			chunks, err := converter.convert(&ev)
+			if err != nil {
+				return err
+			}
+			for _, chunk := range chunks {
+				if err = base.WriteSSEData(pw, chunk); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		_ = base.WriteSSEDone(pw)
+		pw.Close()
+	}()
+	return pr
+}
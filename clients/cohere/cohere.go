@@ -0,0 +1,252 @@
+package cohere
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/constant"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultInputType 请求未指定 input_type 时使用, v3 及以后的向量模型必填
+var DefaultInputType = "search_document"
+
+type Client struct {
+	*base.Client
+	baseUrl string
+}
+
+func NewClient(endPoint, apiKey string) *Client {
+	if endPoint == "" {
+		endPoint = constant.CohereDefaultURL
+	}
+	endPoint = strings.TrimSpace(endPoint)
+	endPoint = strings.TrimRight(endPoint, "/")
+	return &Client{
+		Client:  base.NewClient(endPoint+"/v2", apiKey),
+		baseUrl: endPoint,
+	}
+}
+
+func (c *Client) generateHeader() http.Header {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("Authorization", "Bearer "+c.APIKey)
+	return header
+}
+
+func (c *Client) postJson(ctx context.Context, path string, req, resp any) (io.ReadCloser, error) {
+	reqBytes, err := sonic.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal error: %w", err)
+	}
+	body := io.NopCloser(bytes.NewReader(reqBytes))
+	respBody, _, err := base.RelayWithCheck(ctx, http.MethodPost, c.EndPoint+path, body, c.generateHeader(), c.HTTPClient())
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return respBody, nil
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return nil, fmt.Errorf("read all error: %w", err)
+	}
+	if err = sonic.Unmarshal(respBytes, resp); err != nil {
+		return nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	return nil, nil
+}
+
+func marshalResponse(resp any) (io.ReadCloser, http.Header, error) {
+	respBytes, err := sonic.Marshal(resp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(respBytes)), base.NewJsonHeader(), nil
+}
+
+func (c *Client) CreateChatCompletions(ctx context.Context, req *v1.ChatCompletionRequest) (io.ReadCloser, http.Header, error) {
+	newReq, err := ConvertChatRequest(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.Stream {
+		respBody, err := c.postJson(ctx, "/chat", newReq, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		includeUsage := req.StreamOptions != nil && req.StreamOptions.IncludeUsage
+		return ConvertStreamResponse(respBody, req.Model, includeUsage), base.NewStreamHeader(), nil
+	}
+	var resp ChatResponse
+	if _, err = c.postJson(ctx, "/chat", newReq, &resp); err != nil {
+		return nil, nil, err
+	}
+	return marshalResponse(ConvertChatResponse(&resp, req.Model))
+}
+
+// encodeBase64 与 OpenAI 一致, 使用小端 float32 序列
+func encodeBase64(embedding []float64) string {
+	buf := make([]byte, 4*len(embedding))
+	for i, v := range embedding {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(float32(v)))
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
+
+func (c *Client) CreateEmbeddings(ctx context.Context, req *v1.EmbeddingsRequest) (io.ReadCloser, http.Header, error) {
+	inputs, err := req.InputStrings()
+	if err != nil {
+		return nil, nil, err
+	}
+	newReq := &EmbedRequest{
+		Model:           req.Model,
+		Texts:           inputs,
+		InputType:       req.InputType,
+		EmbeddingTypes:  []string{"float"},
+		OutputDimension: req.Dimensions,
+	}
+	if newReq.InputType == "" {
+		newReq.InputType = DefaultInputType
+	}
+	var resp EmbedResponse
+	if _, err = c.postJson(ctx, "/embed", newReq, &resp); err != nil {
+		return nil, nil, err
+	}
+	newResp := embeddingsResponse{
+		Object: "list",
+		Model:  req.Model,
+		Data:   make([]embeddingsData, 0, len(resp.Embeddings.Float)),
+	}
+	for i, embedding := range resp.Embeddings.Float {
+		data := embeddingsData{Object: "embedding", Index: i, Embedding: embedding}
+		if req.EncodingFormat == "base64" {
+			data.Embedding = encodeBase64(embedding)
+		}
+		newResp.Data = append(newResp.Data, data)
+	}
+	if resp.Meta != nil && resp.Meta.BilledUnits != nil {
+		newResp.Usage.PromptTokens = int(resp.Meta.BilledUnits.InputTokens)
+		newResp.Usage.TotalTokens = newResp.Usage.PromptTokens
+	}
+	return marshalResponse(newResp)
+}
+
+func (c *Client) CreateRerank(ctx context.Context, req *v1.RerankRequest) (io.ReadCloser, http.Header, error) {
+	newReq := &RerankRequest{
+		Model:     req.Model,
+		Query:     req.Query,
+		Documents: req.Documents,
+		TopN:      req.TopN,
+	}
+	var resp RerankResponse
+	if _, err := c.postJson(ctx, "/rerank", newReq, &resp); err != nil {
+		return nil, nil, err
+	}
+	newResp := v1.RerankResponse{
+		ID:      resp.ID,
+		Results: make([]v1.RerankResult, 0, len(resp.Results)),
+	}
+	for _, result := range resp.Results {
+		newResult := v1.RerankResult{Index: result.Index, RelevanceScore: result.RelevanceScore}
+		// v2 不再返回文档内容, 需要时从请求中回填
+		if req.ReturnDocuments && result.Index >= 0 && result.Index < len(req.Documents) {
+			newResult.Document = v1.RerankDocument{Text: req.Documents[result.Index]}
+		}
+		newResp.Results = append(newResp.Results, newResult)
+	}
+	if resp.Meta != nil && resp.Meta.BilledUnits != nil && resp.Meta.BilledUnits.InputTokens > 0 {
+		newResp.Usage = &v1.Usage{
+			PromptTokens: int(resp.Meta.BilledUnits.InputTokens),
+			TotalTokens:  int(resp.Meta.BilledUnits.InputTokens),
+		}
+	}
+	return marshalResponse(newResp)
+}
+
+func (c *Client) Models(ctx context.Context) (*v1.ModelResponse, error) {
+	resp := &v1.ModelResponse{Object: "list", Data: make([]v1.Model, 0)}
+	now := time.Now().Unix()
+	pageToken := ""
+	for {
+		targetUrl := c.baseUrl + "/v1/models?page_size=1000"
+		if pageToken != "" {
+			targetUrl += "&page_token=" + url.QueryEscape(pageToken)
+		}
+		body, _, err := base.RelayWithCheck(ctx, http.MethodGet, targetUrl, nil, c.generateHeader(), c.HTTPClient())
+		if err != nil {
+			return nil, err
+		}
+		dataBytes, err := io.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read all error: %w", err)
+		}
+		var list ModelList
+		if err = sonic.Unmarshal(dataBytes, &list); err != nil {
+			return nil, fmt.Errorf("unmarshal error: %w", err)
+		}
+		for _, model := range list.Models {
+			resp.Data = append(resp.Data, v1.Model{ID: model.Name, Object: "model", Created: now, OwnedBy: "cohere"})
+		}
+		if list.NextPageToken == "" {
+			break
+		}
+		pageToken = list.NextPageToken
+	}
+	return resp, nil
+}
+
+func (c *Client) CreateResponses(ctx context.Context, req *v1.ResponsesRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateCompletions(ctx context.Context, req *v1.CompletionsRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImage(ctx context.Context, req *v1.ImageGenerateRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImageEdit(ctx context.Context, req *v1.ImageEditRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImageVariation(ctx context.Context, req *v1.ImageVariationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateSpeech(ctx context.Context, req *v1.AudioSpeechRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateTranslation(ctx context.Context, req *v1.TranslationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateTranscription(ctx context.Context, req *v1.TranscriptionRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateVideoSubmit(ctx context.Context, req *v1.VideoRequest) (*v1.VideoResponse, error) {
+	_, _, err := base.NoImplementMethod(ctx, req)
+	return nil, err
+}
+
+func (c *Client) GetVideoStatus(ctx context.Context, externalID string) (bool, any, error) {
+	_, _, err := base.NoImplementMethod(ctx, externalID)
+	return false, nil, err
+}
@@ -0,0 +1,174 @@
+package cohere
+
+import (
+	"encoding/json"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+)
+
+// https://docs.cohere.com/reference/chat
+
+type (
+	ChatRequest struct {
+		Model            string          `json:"model"`
+		Messages         []Message       `json:"messages"`
+		Tools            []Tool          `json:"tools,omitempty"`
+		ToolChoice       string          `json:"tool_choice,omitempty"` // REQUIRED 或 NONE
+		Stream           bool            `json:"stream"`
+		MaxTokens        int             `json:"max_tokens,omitempty"`
+		StopSequences    []string        `json:"stop_sequences,omitempty"`
+		Temperature      *float64        `json:"temperature,omitempty"`
+		P                float64         `json:"p,omitempty"`
+		Seed             int             `json:"seed,omitempty"`
+		FrequencyPenalty float64         `json:"frequency_penalty,omitempty"`
+		PresencePenalty  float64         `json:"presence_penalty,omitempty"`
+		ResponseFormat   *ResponseFormat `json:"response_format,omitempty"`
+	}
+	Message struct {
+		Role       string          `json:"role"` // system, user, assistant, tool
+		Content    json.RawMessage `json:"content,omitempty"`
+		ToolCalls  []ToolCall      `json:"tool_calls,omitempty"`
+		ToolCallId string          `json:"tool_call_id,omitempty"`
+		ToolPlan   string          `json:"tool_plan,omitempty"`
+	}
+	Content struct {
+		Type     string    `json:"type"` // text, image_url
+		Text     string    `json:"text,omitempty"`
+		ImageUrl *ImageUrl `json:"image_url,omitempty"`
+	}
+	ImageUrl struct {
+		Url string `json:"url"`
+	}
+	ToolCall struct {
+		Id       string       `json:"id,omitempty"`
+		Type     string       `json:"type,omitempty"`
+		Function ToolFunction `json:"function"`
+	}
+	ToolFunction struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments,omitempty"`
+	}
+	Tool struct {
+		Type     string             `json:"type"`
+		Function FunctionDefinition `json:"function"`
+	}
+	FunctionDefinition struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description,omitempty"`
+		Parameters  json.RawMessage `json:"parameters,omitempty"`
+	}
+	ResponseFormat struct {
+		Type       string `json:"type"` // text, json_object
+		JsonSchema any    `json:"json_schema,omitempty"`
+	}
+)
+
+type (
+	ChatResponse struct {
+		ID           string          `json:"id"`
+		FinishReason string          `json:"finish_reason"`
+		Message      ResponseMessage `json:"message"`
+		Usage        *Usage          `json:"usage,omitempty"`
+	}
+	ResponseMessage struct {
+		Role      string     `json:"role"`
+		Content   []Content  `json:"content"`
+		ToolCalls []ToolCall `json:"tool_calls"`
+		ToolPlan  string     `json:"tool_plan"`
+	}
+	Usage struct {
+		BilledUnits *BilledUnits `json:"billed_units,omitempty"`
+		Tokens      *Tokens      `json:"tokens,omitempty"`
+	}
+	BilledUnits struct {
+		InputTokens  float64 `json:"input_tokens"`
+		OutputTokens float64 `json:"output_tokens"`
+		SearchUnits  float64 `json:"search_units"`
+	}
+	Tokens struct {
+		InputTokens  float64 `json:"input_tokens"`
+		OutputTokens float64 `json:"output_tokens"`
+	}
+	// StreamEvent 流式事件, 类型由 type 字段给出
+	StreamEvent struct {
+		Type  string `json:"type"`
+		ID    string `json:"id,omitempty"`
+		Index int    `json:"index"`
+		Delta *struct {
+			Message *struct {
+				Content *struct {
+					Text string `json:"text"`
+				} `json:"content,omitempty"`
+				ToolCalls *ToolCall `json:"tool_calls,omitempty"`
+				ToolPlan  string    `json:"tool_plan,omitempty"`
+			} `json:"message,omitempty"`
+			FinishReason string `json:"finish_reason,omitempty"`
+			Usage        *Usage `json:"usage,omitempty"`
+			Error        string `json:"error,omitempty"`
+		} `json:"delta,omitempty"`
+	}
+)
+
+// https://docs.cohere.com/reference/embed
+
+type (
+	EmbedRequest struct {
+		Model           string   `json:"model"`
+		Texts           []string `json:"texts"`
+		InputType       string   `json:"input_type"` // search_document, search_query, classification, clustering
+		EmbeddingTypes  []string `json:"embedding_types"`
+		OutputDimension int      `json:"output_dimension,omitempty"`
+		Truncate        string   `json:"truncate,omitempty"`
+	}
+	EmbedResponse struct {
+		ID         string `json:"id"`
+		Embeddings struct {
+			Float [][]float64 `json:"float"`
+		} `json:"embeddings"`
+		Meta *Meta `json:"meta,omitempty"`
+	}
+	Meta struct {
+		BilledUnits *BilledUnits `json:"billed_units,omitempty"`
+	}
+	// embeddingsResponse base64 编码时 embedding 为字符串, 不能使用 v1.EmbeddingsData
+	embeddingsResponse struct {
+		Object string           `json:"object"`
+		Model  string           `json:"model"`
+		Data   []embeddingsData `json:"data"`
+		Usage  v1.Usage         `json:"usage"`
+	}
+	embeddingsData struct {
+		Object    string `json:"object"`
+		Index     int    `json:"index"`
+		Embedding any    `json:"embedding"`
+	}
+)
+
+// https://docs.cohere.com/reference/rerank
+
+type (
+	RerankRequest struct {
+		Model           string   `json:"model"`
+		Query           string   `json:"query"`
+		Documents       []string `json:"documents"`
+		TopN            int      `json:"top_n,omitempty"`
+		MaxTokensPerDoc int      `json:"max_tokens_per_doc,omitempty"`
+	}
+	RerankResponse struct {
+		ID      string `json:"id"`
+		Results []struct {
+			Index          int     `json:"index"`
+			RelevanceScore float64 `json:"relevance_score"`
+		} `json:"results"`
+		Meta *Meta `json:"meta,omitempty"`
+	}
+)
+
+type (
+	ModelList struct {
+		Models []struct {
+			Name      string   `json:"name"`
+			Endpoints []string `json:"endpoints"`
+		} `json:"models"`
+		NextPageToken string `json:"next_page_token"`
+	}
+)
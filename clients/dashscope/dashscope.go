@@ -0,0 +1,183 @@
+package dashscope
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/constant"
+	"github.com/jiu-u/oai-adapter/pkg/task"
+	"github.com/jiu-u/oai-adapter/tools"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// WaitInterval 同步等待图片任务时检查任务结果的间隔
+var WaitInterval = 500 * time.Millisecond
+
+// Client 聊天, 向量与模型列表走 compatible-mode, 图片走 DashScope 原生异步任务接口
+type Client struct {
+	*base.Client
+	baseUrl string
+}
+
+func NewClient(endPoint, apiKey string) *Client {
+	if endPoint == "" {
+		endPoint = constant.DashScopeDefaultURL
+	}
+	endPoint = strings.TrimSpace(endPoint)
+	endPoint = strings.TrimRight(endPoint, "/")
+	return &Client{
+		Client:  base.NewClient(endPoint+"/compatible-mode/v1", apiKey),
+		baseUrl: endPoint,
+	}
+}
+
+func (c *Client) generateHeader() http.Header {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("Authorization", "Bearer "+c.APIKey)
+	return header
+}
+
+func (c *Client) doTask(ctx context.Context, method, targetUrl string, body io.ReadCloser, header http.Header) (*TaskResponse, error) {
+	respBody, _, err := base.RelayWithCheck(ctx, method, targetUrl, body, header, c.HTTPClient())
+	if err != nil {
+		return nil, err
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return nil, fmt.Errorf("read all error: %w", err)
+	}
+	var resp TaskResponse
+	if err = sonic.Unmarshal(respBytes, &resp); err != nil {
+		return nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	return &resp, nil
+}
+
+// CreateImage 提交 Wanx 异步任务, 由任务管理器轮询, 结束后以 ImageGenerateResponse 返回
+func (c *Client) CreateImage(ctx context.Context, req *v1.ImageGenerateRequest) (io.ReadCloser, http.Header, error) {
+	newReq := &ImageSynthesisRequest{
+		Model: req.Model,
+		Input: ImageInput{Prompt: req.Prompt},
+		Parameters: ImageParameters{
+			Size: strings.ReplaceAll(req.Size, "x", "*"),
+			N:    req.N,
+		},
+	}
+	reqBytes, err := sonic.Marshal(newReq)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	header := c.generateHeader()
+	header.Set("X-DashScope-Async", "enable")
+	targetUrl := c.baseUrl + "/api/v1/services/aigc/text2image/image-synthesis"
+	submitResp, err := c.doTask(ctx, http.MethodPost, targetUrl, io.NopCloser(bytes.NewReader(reqBytes)), header)
+	if err != nil {
+		return nil, nil, err
+	}
+	if submitResp.Output.TaskId == "" {
+		return nil, nil, fmt.Errorf("submit image task error: %s %s", submitResp.Code, submitResp.Message)
+	}
+
+	poller := base.NewPoller(c.GetTaskStatus)
+	taskID := c.TaskManager().CreatePollingTask(ctx, submitResp.Output.TaskId, poller, nil)
+	result, err := task.Wait(ctx, c.TaskManager(), taskID, WaitInterval)
+	if err != nil {
+		return nil, nil, err
+	}
+	taskResp, ok := result.Data.(*TaskResponse)
+	if !ok {
+		return nil, nil, fmt.Errorf("image task %s has no result", submitResp.Output.TaskId)
+	}
+	if taskResp.Output.TaskStatus != "SUCCEEDED" {
+		return nil, nil, fmt.Errorf("image task %s: %s %s", taskResp.Output.TaskStatus, taskResp.Output.Code, taskResp.Output.Message)
+	}
+
+	newResp := v1.ImageGenerateResponse{Created: time.Now().Unix(), Data: make([]v1.ImageGenData, 0, len(taskResp.Output.Results))}
+	for _, item := range taskResp.Output.Results {
+		// 部分图片可能因审核失败而没有链接
+		if item.Url == "" {
+			continue
+		}
+		if req.ResponseFormat == "b64_json" {
+			dataUrl, _, err := tools.GetNetImageB64(item.Url)
+			if err != nil {
+				return nil, nil, fmt.Errorf("download image error: %w", err)
+			}
+			_, data, _ := tools.ParseDataURL(dataUrl)
+			newResp.Data = append(newResp.Data, v1.ImageGenData{B64JSON: data})
+			continue
+		}
+		newResp.Data = append(newResp.Data, v1.ImageGenData{URL: item.Url})
+	}
+	respBytes, err := sonic.Marshal(newResp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(respBytes)), base.NewJsonHeader(), nil
+}
+
+// GetTaskStatus 查询异步任务, 任务进入终态时返回 completed
+func (c *Client) GetTaskStatus(ctx context.Context, externalID string) (bool, any, error) {
+	targetUrl := c.baseUrl + "/api/v1/tasks/" + url.PathEscape(externalID)
+	resp, err := c.doTask(ctx, http.MethodGet, targetUrl, nil, c.generateHeader())
+	if err != nil {
+		return false, nil, err
+	}
+	switch resp.Output.TaskStatus {
+	case "SUCCEEDED", "FAILED", "CANCELED", "UNKNOWN":
+		return true, resp, nil
+	default:
+		return false, resp, nil
+	}
+}
+
+func (c *Client) CreateResponses(ctx context.Context, req *v1.ResponsesRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateCompletions(ctx context.Context, req *v1.CompletionsRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateRerank(ctx context.Context, req *v1.RerankRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImageEdit(ctx context.Context, req *v1.ImageEditRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImageVariation(ctx context.Context, req *v1.ImageVariationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateSpeech(ctx context.Context, req *v1.AudioSpeechRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateTranslation(ctx context.Context, req *v1.TranslationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateTranscription(ctx context.Context, req *v1.TranscriptionRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateVideoSubmit(ctx context.Context, req *v1.VideoRequest) (*v1.VideoResponse, error) {
+	_, _, err := base.NoImplementMethod(ctx, req)
+	return nil, err
+}
+
+func (c *Client) GetVideoStatus(ctx context.Context, externalID string) (bool, any, error) {
+	_, _, err := base.NoImplementMethod(ctx, externalID)
+	return false, nil, err
+}
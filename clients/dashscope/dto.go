@@ -0,0 +1,39 @@
+package dashscope
+
+// https://help.aliyun.com/zh/model-studio/developer-reference/text-to-image-v2-api-reference
+
+type (
+	ImageSynthesisRequest struct {
+		Model      string          `json:"model"`
+		Input      ImageInput      `json:"input"`
+		Parameters ImageParameters `json:"parameters"`
+	}
+	ImageInput struct {
+		Prompt         string `json:"prompt"`
+		NegativePrompt string `json:"negative_prompt,omitempty"`
+	}
+	ImageParameters struct {
+		Size  string `json:"size,omitempty"` // 1024*1024
+		N     int    `json:"n,omitempty"`
+		Seed  int    `json:"seed,omitempty"`
+		Style string `json:"style,omitempty"`
+	}
+)
+
+// TaskResponse 异步任务提交与查询的响应
+type TaskResponse struct {
+	RequestId string `json:"request_id"`
+	Output    struct {
+		TaskId     string `json:"task_id"`
+		TaskStatus string `json:"task_status"` // PENDING, RUNNING, SUCCEEDED, FAILED, CANCELED, UNKNOWN
+		Results    []struct {
+			Url     string `json:"url"`
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"results"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"output"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,90 @@
+package elevenlabs
+
+import (
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"strings"
+	"unicode/utf8"
+)
+
+// ConvertOutputFormat 将 OpenAI response_format 转换为 ElevenLabs output_format 与对应的 Content-Type
+// ElevenLabs 不支持 aac 与 flac, 退化为 mp3
+func ConvertOutputFormat(format string) (outputFormat, contentType string) {
+	switch format {
+	case "opus":
+		return "opus_48000_128", "audio/opus"
+	case "wav":
+		return "wav_44100", "audio/wav"
+	case "pcm":
+		// OpenAI 的 pcm 为 24kHz 16bit 小端
+		return "pcm_24000", "audio/pcm"
+	default:
+		return "mp3_44100_128", "audio/mpeg"
+	}
+}
+
+// ConvertSpeechModel OpenAI 模型名映射为 ElevenLabs 模型, eleven_ 开头的模型直接透传
+func ConvertSpeechModel(model string) string {
+	if strings.HasPrefix(model, "eleven_") {
+		return model
+	}
+	return DefaultSpeechModel
+}
+
+// ConvertTranscriptionModel whisper 等模型名映射为 scribe
+func ConvertTranscriptionModel(model string) string {
+	if strings.HasPrefix(model, "scribe") {
+		return model
+	}
+	return DefaultTranscriptionModel
+}
+
+// isSentenceEnd 判断单词是否以句末标点结尾
+func isSentenceEnd(word string) bool {
+	r, _ := utf8.DecodeLastRuneInString(strings.TrimSpace(word))
+	switch r {
+	case '.', '?', '!', '。', '？', '！':
+		return true
+	}
+	return false
+}
+
+// ConvertTranscriptionResponse 将 speech-to-text 结果转换为 verbose_json, 按句末标点或说话人切换划分 segment
+func ConvertTranscriptionResponse(resp *TranscriptionResponse) *v1.TranscriptionVerboseResponse {
+	newResp := &v1.TranscriptionVerboseResponse{
+		Language: resp.LanguageCode,
+		Text:     resp.Text,
+		Words:    make([]v1.TranscriptionWord, 0, len(resp.Words)),
+	}
+	var segment *v1.TranscriptionSegment
+	var speaker string
+	flush := func() {
+		if segment != nil {
+			segment.Text = strings.TrimSpace(segment.Text)
+			newResp.Segments = append(newResp.Segments, *segment)
+			segment = nil
+		}
+	}
+	for _, word := range resp.Words {
+		newResp.Duration = max(newResp.Duration, word.End)
+		if word.Type == "word" {
+			newResp.Words = append(newResp.Words, v1.TranscriptionWord{Word: word.Text, Start: word.Start, End: word.End})
+			if segment != nil && word.SpeakerId != speaker {
+				flush()
+			}
+			speaker = word.SpeakerId
+		}
+		if segment == nil {
+			if word.Type == "spacing" {
+				continue
+			}
+			segment = &v1.TranscriptionSegment{Id: len(newResp.Segments), Start: word.Start}
+		}
+		segment.Text += word.Text
+		segment.End = word.End
+		if word.Type == "word" && isSentenceEnd(word.Text) {
+			flush()
+		}
+	}
+	flush()
+	return newResp
+}
@@ -0,0 +1,47 @@
+package elevenlabs
+
+// https://elevenlabs.io/docs/api-reference/text-to-speech/convert
+
+type (
+	SpeechRequest struct {
+		Text          string         `json:"text"`
+		ModelId       string         `json:"model_id,omitempty"`
+		VoiceSettings *VoiceSettings `json:"voice_settings,omitempty"`
+		PreviousText  string         `json:"previous_text,omitempty"`
+	}
+	VoiceSettings struct {
+		Stability       float64 `json:"stability"`
+		SimilarityBoost float64 `json:"similarity_boost"`
+		Speed           float64 `json:"speed,omitempty"` // 0.7 ~ 1.2
+	}
+	VoiceList struct {
+		Voices []struct {
+			VoiceId string `json:"voice_id"`
+			Name    string `json:"name"`
+		} `json:"voices"`
+	}
+)
+
+// https://elevenlabs.io/docs/api-reference/speech-to-text/convert
+
+type (
+	TranscriptionResponse struct {
+		LanguageCode        string  `json:"language_code"`
+		LanguageProbability float64 `json:"language_probability"`
+		Text                string  `json:"text"`
+		Words               []Word  `json:"words"`
+	}
+	Word struct {
+		Text      string  `json:"text"`
+		Start     float64 `json:"start"`
+		End       float64 `json:"end"`
+		Type      string  `json:"type"` // word, spacing, audio_event
+		SpeakerId string  `json:"speaker_id,omitempty"`
+	}
+)
+
+type Model struct {
+	ModelId           string `json:"model_id"`
+	Name              string `json:"name"`
+	CanDoTextToSpeech bool   `json:"can_do_text_to_speech"`
+}
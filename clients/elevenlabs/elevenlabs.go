@@ -0,0 +1,264 @@
+package elevenlabs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/constant"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	DefaultSpeechModel        = "eleven_multilingual_v2"
+	DefaultTranscriptionModel = "scribe_v1"
+	// 默认音色参数, speed 由请求覆盖
+	DefaultStability       = 0.5
+	DefaultSimilarityBoost = 0.75
+)
+
+type Client struct {
+	*base.Client
+	mu     sync.Mutex
+	voices map[string]string // 小写音色名 -> voice_id
+}
+
+func NewClient(endPoint, apiKey string) *Client {
+	if endPoint == "" {
+		endPoint = constant.ElevenLabsDefaultURL
+	}
+	endPoint = strings.TrimSpace(endPoint)
+	endPoint = strings.TrimRight(endPoint, "/")
+	c := &Client{
+		Client: base.NewClient(endPoint+"/v1", apiKey),
+	}
+	c.SetAuthFunc(func(ctx context.Context, header http.Header) error {
+		header.Set("xi-api-key", apiKey)
+		return nil
+	})
+	return c
+}
+
+// generateHeader 鉴权由 SetAuthFunc 设置的 xi-api-key 完成
+func (c *Client) generateHeader(ctx context.Context, contentType string) (http.Header, error) {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	if err := c.PrepareHeader(ctx, header); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+func (c *Client) getJson(ctx context.Context, path string, resp any) error {
+	header, err := c.generateHeader(ctx, "")
+	if err != nil {
+		return err
+	}
+	respBody, _, err := base.RelayWithCheck(ctx, http.MethodGet, c.EndPoint+path, nil, header, c.HTTPClient())
+	if err != nil {
+		return err
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return fmt.Errorf("read all error: %w", err)
+	}
+	if err = sonic.Unmarshal(respBytes, resp); err != nil {
+		return fmt.Errorf("unmarshal error: %w", err)
+	}
+	return nil
+}
+
+// VoiceID 将音色名解析为 voice_id, 找不到同名音色时视为 voice_id 直接使用
+func (c *Client) VoiceID(ctx context.Context, voice string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.voices == nil {
+		var list VoiceList
+		if err := c.getJson(ctx, "/voices", &list); err != nil {
+			return "", fmt.Errorf("list voices error: %w", err)
+		}
+		c.voices = make(map[string]string, len(list.Voices))
+		for _, v := range list.Voices {
+			c.voices[strings.ToLower(v.Name)] = v.VoiceId
+		}
+	}
+	if id, ok := c.voices[strings.ToLower(voice)]; ok {
+		return id, nil
+	}
+	return voice, nil
+}
+
+func (c *Client) CreateSpeech(ctx context.Context, req *v1.AudioSpeechRequest) (io.ReadCloser, http.Header, error) {
+	voiceId, err := c.VoiceID(ctx, req.Voice)
+	if err != nil {
+		return nil, nil, err
+	}
+	newReq := &SpeechRequest{
+		Text:    req.Input,
+		ModelId: ConvertSpeechModel(req.Model),
+		// 没有对应的 instructions 字段, 作为前文影响语气与韵律
+		PreviousText: req.Instructions,
+	}
+	if req.Speed != 0 {
+		newReq.VoiceSettings = &VoiceSettings{
+			Stability:       DefaultStability,
+			SimilarityBoost: DefaultSimilarityBoost,
+			Speed:           min(max(req.Speed, 0.7), 1.2),
+		}
+	}
+	reqBytes, err := sonic.Marshal(newReq)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	outputFormat, contentType := ConvertOutputFormat(req.ResponseFormat)
+	targetUrl := c.EndPoint + "/text-to-speech/" + url.PathEscape(voiceId) + "?output_format=" + outputFormat
+	reqHeader, err := c.generateHeader(ctx, "application/json")
+	if err != nil {
+		return nil, nil, err
+	}
+	respBody, _, err := base.RelayWithCheck(ctx, http.MethodPost, targetUrl, io.NopCloser(bytes.NewReader(reqBytes)), reqHeader, c.HTTPClient())
+	if err != nil {
+		return nil, nil, err
+	}
+	header := http.Header{}
+	header.Set("Content-Type", contentType)
+	return respBody, header, nil
+}
+
+func (c *Client) CreateTranscription(ctx context.Context, req *v1.TranscriptionRequest) (io.ReadCloser, http.Header, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, err := writer.CreateFormFile("file", req.File.Filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create form file error: %w", err)
+	}
+	file, err := req.File.Open()
+	if err != nil {
+		return nil, nil, fmt.Errorf("open file error: %w", err)
+	}
+	defer file.Close()
+	if _, err = io.Copy(part, file); err != nil {
+		return nil, nil, fmt.Errorf("copy file content error: %w", err)
+	}
+	if err = writer.WriteField("model_id", ConvertTranscriptionModel(req.Model)); err != nil {
+		return nil, nil, fmt.Errorf("write model_id field error: %w", err)
+	}
+	if req.Language != "" {
+		if err = writer.WriteField("language_code", req.Language); err != nil {
+			return nil, nil, fmt.Errorf("write language_code field error: %w", err)
+		}
+	}
+	if err = writer.WriteField("timestamps_granularity", "word"); err != nil {
+		return nil, nil, fmt.Errorf("write timestamps_granularity field error: %w", err)
+	}
+	if err = writer.Close(); err != nil {
+		return nil, nil, fmt.Errorf("close multipart writer error: %w", err)
+	}
+
+	targetUrl := c.EndPoint + "/speech-to-text"
+	header, err := c.generateHeader(ctx, writer.FormDataContentType())
+	if err != nil {
+		return nil, nil, err
+	}
+	respBody, _, err := base.RelayWithCheck(ctx, http.MethodPost, targetUrl, io.NopCloser(&buf), header, c.HTTPClient())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read all error: %w", err)
+	}
+	var resp TranscriptionResponse
+	if err = sonic.Unmarshal(respBytes, &resp); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+
+	var newResp any
+	switch req.ResponseFormat {
+	case "text":
+		header := http.Header{}
+		header.Set("Content-Type", "text/plain; charset=utf-8")
+		return io.NopCloser(strings.NewReader(resp.Text)), header, nil
+	case "verbose_json":
+		newResp = ConvertTranscriptionResponse(&resp)
+	default:
+		newResp = &v1.TranscriptionResponse{Text: resp.Text}
+	}
+	respBytes, err = sonic.Marshal(newResp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(respBytes)), base.NewJsonHeader(), nil
+}
+
+func (c *Client) Models(ctx context.Context) (*v1.ModelResponse, error) {
+	var models []Model
+	if err := c.getJson(ctx, "/models", &models); err != nil {
+		return nil, err
+	}
+	resp := &v1.ModelResponse{Object: "list", Data: make([]v1.Model, 0, len(models)+1)}
+	now := time.Now().Unix()
+	for _, model := range models {
+		resp.Data = append(resp.Data, v1.Model{ID: model.ModelId, Object: "model", Created: now, OwnedBy: "elevenlabs"})
+	}
+	resp.Data = append(resp.Data, v1.Model{ID: DefaultTranscriptionModel, Object: "model", Created: now, OwnedBy: "elevenlabs"})
+	return resp, nil
+}
+
+func (c *Client) CreateResponses(ctx context.Context, req *v1.ResponsesRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateChatCompletions(ctx context.Context, req *v1.ChatCompletionRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateCompletions(ctx context.Context, req *v1.CompletionsRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateEmbeddings(ctx context.Context, req *v1.EmbeddingsRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateRerank(ctx context.Context, req *v1.RerankRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImage(ctx context.Context, req *v1.ImageGenerateRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImageEdit(ctx context.Context, req *v1.ImageEditRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImageVariation(ctx context.Context, req *v1.ImageVariationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateTranslation(ctx context.Context, req *v1.TranslationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateVideoSubmit(ctx context.Context, req *v1.VideoRequest) (*v1.VideoResponse, error) {
+	_, _, err := base.NoImplementMethod(ctx, req)
+	return nil, err
+}
+
+func (c *Client) GetVideoStatus(ctx context.Context, externalID string) (bool, any, error) {
+	_, _, err := base.NoImplementMethod(ctx, externalID)
+	return false, nil, err
+}
@@ -0,0 +1,188 @@
+package emulate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ChatRerankWindowSize 每次提交给模型排序的文档数
+	ChatRerankWindowSize = 20
+	// ChatRerankStep 滑动窗口每次前移的文档数, 小于窗口大小时相邻窗口重叠, 使靠后的相关文档能逐步上浮
+	ChatRerankStep = 10
+	// ChatRerankDocLength 单个文档在提示词中保留的最大字符数
+	ChatRerankDocLength = 2000
+)
+
+const chatRerankPrompt = `You are a search relevance ranker. Rank the following %d passages by their relevance to the query, most relevant first.
+
+Query: %s
+
+%s
+Respond with only a JSON object of the form {"ranking": [3, 1, 2]} that lists every passage number exactly once.`
+
+type Chatter interface {
+	CreateChatCompletions(ctx context.Context, req *v1.ChatCompletionRequest) (io.ReadCloser, http.Header, error)
+}
+
+// ChatRerank 使用聊天模型进行 listwise 排序, 文档较多时从后向前滑动窗口排序
+// 分数由最终名次换算, 仅保证顺序有意义
+func ChatRerank(ctx context.Context, c Chatter, model string, req *v1.RerankRequest) (io.ReadCloser, http.Header, error) {
+	if model == "" {
+		model = req.Model
+	}
+	order := make([]int, len(req.Documents))
+	for i := range order {
+		order[i] = i
+	}
+	usage := &v1.Usage{}
+	windowSize := max(ChatRerankWindowSize, 2)
+	step := min(max(ChatRerankStep, 1), windowSize)
+	for end := len(order); end > 0; end -= step {
+		start := max(end-windowSize, 0)
+		window := order[start:end]
+		ranking, err := rankWindow(ctx, c, model, req, window, usage)
+		if err != nil {
+			return nil, nil, err
+		}
+		reordered := make([]int, 0, len(window))
+		for _, i := range ranking {
+			reordered = append(reordered, window[i])
+		}
+		copy(window, reordered)
+		if start == 0 {
+			break
+		}
+	}
+	scores := make([]float64, len(order))
+	for rank, index := range order {
+		scores[index] = float64(len(order)-rank) / float64(len(order))
+	}
+	return newRerankResponse(req, scores, usage)
+}
+
+// rankWindow 返回窗口内文档的新顺序(窗口内下标)
+func rankWindow(ctx context.Context, c Chatter, model string, req *v1.RerankRequest, window []int, usage *v1.Usage) ([]int, error) {
+	if len(window) < 2 {
+		return make([]int, len(window)), nil
+	}
+	var passages strings.Builder
+	for i, index := range window {
+		doc := []rune(req.Documents[index])
+		if len(doc) > ChatRerankDocLength {
+			doc = doc[:ChatRerankDocLength]
+		}
+		fmt.Fprintf(&passages, "[%d] %s\n", i+1, strings.ReplaceAll(string(doc), "\n", " "))
+	}
+	message := v1.Message{Role: "user"}
+	message.SetStringContent(fmt.Sprintf(chatRerankPrompt, len(window), req.Query, passages.String()))
+	respBody, _, err := c.CreateChatCompletions(ctx, &v1.ChatCompletionRequest{
+		Model:    model,
+		Messages: []v1.Message{message},
+	})
+	if err != nil {
+		return nil, err
+	}
+	respBytes, err := io.ReadAll(respBody)
+	respBody.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read all error: %w", err)
+	}
+	resp, err := base.ParseChatResponse(respBytes)
+	if err != nil {
+		return nil, err
+	}
+	usage.PromptTokens += resp.Usage.PromptTokens
+	usage.CompletionTokens += resp.Usage.CompletionTokens
+	usage.TotalTokens += resp.Usage.TotalTokens
+	var reply string
+	if len(resp.Choices) > 0 {
+		reply = resp.Choices[0].Message.StringContent()
+	}
+	return ParseRanking(reply, len(window)), nil
+}
+
+var (
+	jsonObjectRe = regexp.MustCompile(`(?s)[\[{].*[\]}]`)
+	numberRe     = regexp.MustCompile(`\d+`)
+)
+
+// ParseRanking 解析模型给出的排序, 返回从 0 开始的下标
+// 支持 {"ranking":[...]}、[...]、[{"index":1,"score":0.9}] 与 "[2] > [1]" 等形式
+// 重复与越界的编号被忽略, 未提及的文档按原顺序排在最后
+func ParseRanking(reply string, n int) []int {
+	var numbers []int
+	if raw := jsonObjectRe.FindString(reply); raw != "" {
+		numbers = parseJsonRanking(raw)
+	}
+	if numbers == nil {
+		for _, s := range numberRe.FindAllString(reply, -1) {
+			if number, err := strconv.Atoi(s); err == nil {
+				numbers = append(numbers, number)
+			}
+		}
+	}
+	seen := make([]bool, n)
+	ranking := make([]int, 0, n)
+	for _, number := range numbers {
+		if i := number - 1; i >= 0 && i < n && !seen[i] {
+			seen[i] = true
+			ranking = append(ranking, i)
+		}
+	}
+	for i := 0; i < n; i++ {
+		if !seen[i] {
+			ranking = append(ranking, i)
+		}
+	}
+	return ranking
+}
+
+type scoredPassage struct {
+	Index int     `json:"index"`
+	Score float64 `json:"score"`
+}
+
+func parseJsonRanking(raw string) []int {
+	var obj struct {
+		Ranking []int           `json:"ranking"`
+		Scores  []scoredPassage `json:"scores"`
+	}
+	if err := json.Unmarshal([]byte(raw), &obj); err == nil {
+		if len(obj.Ranking) > 0 {
+			return obj.Ranking
+		}
+		if len(obj.Scores) > 0 {
+			return sortScored(obj.Scores)
+		}
+	}
+	var list []int
+	if err := json.Unmarshal([]byte(raw), &list); err == nil && len(list) > 0 {
+		return list
+	}
+	var scored []scoredPassage
+	if err := json.Unmarshal([]byte(raw), &scored); err == nil && len(scored) > 0 {
+		return sortScored(scored)
+	}
+	return nil
+}
+
+func sortScored(scored []scoredPassage) []int {
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].Score > scored[j].Score
+	})
+	numbers := make([]int, 0, len(scored))
+	for _, s := range scored {
+		numbers = append(numbers, s.Index)
+	}
+	return numbers
+}
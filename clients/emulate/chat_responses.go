@@ -0,0 +1,439 @@
+package emulate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type Responder interface {
+	CreateResponses(ctx context.Context, req *v1.ResponsesRequest) (io.ReadCloser, http.Header, error)
+}
+
+// ConvertChatRequest 将 chat 请求转换为 Responses 请求
+// 显式发送 store:false, 上游默认会保存对话, 而 chat 请求没有这一语义
+func ConvertChatRequest(req *v1.ChatCompletionRequest) (*v1.ResponsesRequest, error) {
+	noStore := false
+	newReq := &v1.ResponsesRequest{
+		Model:             req.Model,
+		Store:             &noStore,
+		MaxOutputTokens:   uint(req.MaxCompletionTokens),
+		ParallelToolCalls: req.ParallelToolCalls,
+		Stream:            req.Stream,
+		Temperature:       req.Temperature,
+		TopP:              req.TopP,
+		User:              req.User,
+	}
+	if newReq.MaxOutputTokens == 0 {
+		newReq.MaxOutputTokens = uint(req.MaxTokens)
+	}
+	inputs := make([]v1.ResponsesInput, 0, len(req.Messages))
+	for i := range req.Messages {
+		items, err := convertChatMessage(&req.Messages[i])
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, items...)
+	}
+	var err error
+	if newReq.Input, err = json.Marshal(inputs); err != nil {
+		return nil, fmt.Errorf("marshal error: %w", err)
+	}
+	for _, tool := range req.Tools {
+		if tool.Type != "function" {
+			return nil, fmt.Errorf("unsupported tool type %s", tool.Type)
+		}
+		newTool := v1.ResponsesTool{Type: "function", Name: tool.Function.Name, Description: tool.Function.Description}
+		if len(tool.Function.Parameters) > 0 {
+			newTool.Parameters = tool.Function.Parameters
+		}
+		newReq.Tools = append(newReq.Tools, newTool)
+	}
+	if newReq.ToolChoice, err = convertChatToolChoice(req.ToolChoice); err != nil {
+		return nil, err
+	}
+	if format := req.ResponseFormat; format != nil {
+		switch format.Type {
+		case "json_schema":
+			if format.JsonSchema == nil {
+				return nil, fmt.Errorf("response_format json_schema is empty")
+			}
+			newReq.Text = &v1.ResponsesText{Format: &v1.ResponsesText{
+				Type:        format.Type,
+				Name:        format.JsonSchema.Name,
+				Schema:      format.JsonSchema.Schema,
+				Description: format.JsonSchema.Description,
+				Strict:      format.JsonSchema.Strict,
+			}}
+		case "json_object":
+			newReq.Text = &v1.ResponsesText{Format: &v1.ResponsesText{Type: format.Type}}
+		}
+	}
+	if effort := chatReasoningEffort(req); effort != "" {
+		newReq.Reasoning = &v1.Reasoning{Effort: effort}
+	}
+	return newReq, nil
+}
+
+// chatReasoningEffort 兼容 reasoning_effect 字段与 extra_body 或顶层中的 reasoning_effort
+func chatReasoningEffort(req *v1.ChatCompletionRequest) string {
+	if req.ReasoningEffect != "" {
+		return req.ReasoningEffect
+	}
+	effort, ok := req.ExtraBody["reasoning_effort"]
+	if !ok {
+		effort = req.UnknownFields["reasoning_effort"]
+	}
+	switch effort := effort.(type) {
+	case string:
+		return effort
+	case json.RawMessage:
+		var s string
+		if json.Unmarshal(effort, &s) == nil {
+			return s
+		}
+	}
+	return ""
+}
+
+// convertChatMessage assistant 的 tool_calls 拆分为 function_call 项, tool 消息转为 function_call_output
+func convertChatMessage(message *v1.Message) ([]v1.ResponsesInput, error) {
+	if message.Role == "tool" {
+		return []v1.ResponsesInput{{
+			Type:   v1.FunctionCallOutputInputType,
+			CallId: message.ToolCallId,
+			Output: message.StringContent(),
+		}}, nil
+	}
+	var items []v1.ResponsesInput
+	if len(message.Content) > 0 && string(message.Content) != "null" {
+		content, err := convertChatContent(message)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v1.ResponsesInput{Type: v1.MessageInputType, Role: message.Role, Content: content})
+	}
+	for _, toolCall := range message.ToolCalls {
+		items = append(items, v1.ResponsesInput{
+			Type:      v1.FunctionCallInputType,
+			CallId:    toolCall.Id,
+			Name:      toolCall.Function.Name,
+			Arguments: toolCall.Function.Arguments,
+		})
+	}
+	return items, nil
+}
+
+// convertChatContent assistant 的文本为 output_text, 其余角色为 input_text
+func convertChatContent(message *v1.Message) (json.RawMessage, error) {
+	if message.IsStringContent() {
+		return message.Content, nil
+	}
+	contents, err := message.ParseContent()
+	if err != nil {
+		return nil, err
+	}
+	textType := v1.InputContentTypeText
+	if message.Role == "assistant" {
+		textType = v1.InputContentTypeOutputText
+	}
+	parts := make([]v1.InputContent, 0, len(contents))
+	for _, content := range contents {
+		switch content.Type {
+		case v1.ContentTypeText:
+			parts = append(parts, v1.InputContent{Type: textType, Text: content.Text})
+		case v1.ContentTypeImageURL:
+			if content.ImageUrl == nil {
+				return nil, fmt.Errorf("image_url is empty")
+			}
+			parts = append(parts, v1.InputContent{Type: v1.InputContentTypeImage, ImageUrl: content.ImageUrl.Url, Detail: content.ImageUrl.Detail})
+		case v1.ContentTypeFile:
+			if content.File == nil {
+				return nil, fmt.Errorf("file is empty")
+			}
+			part := v1.InputContent{Type: v1.InputContentTypeInputAudio, Filename: content.File.Filename}
+			if fileData, ok := content.File.FileData.(string); ok {
+				part.FileData = fileData
+			}
+			if fileId, ok := content.File.FileId.(string); ok {
+				part.FileId = fileId
+			}
+			parts = append(parts, part)
+		default:
+			return nil, fmt.Errorf("unsupported content type %s", content.Type)
+		}
+	}
+	data, err := json.Marshal(parts)
+	if err != nil {
+		return nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return data, nil
+}
+
+// convertChatToolChoice {"type":"function","function":{"name":"x"}} 转为 {"type":"function","name":"x"}
+func convertChatToolChoice(toolChoice any) (json.RawMessage, error) {
+	if toolChoice == nil {
+		return nil, nil
+	}
+	if mode, ok := toolChoice.(string); ok {
+		return json.Marshal(mode)
+	}
+	raw, err := json.Marshal(toolChoice)
+	if err != nil {
+		return nil, fmt.Errorf("marshal error: %w", err)
+	}
+	var choice v1.ToolChoice
+	if err = json.Unmarshal(raw, &choice); err != nil {
+		return nil, fmt.Errorf("unmarshal tool_choice error: %w", err)
+	}
+	if choice.Type != "function" || choice.Function == nil {
+		return nil, fmt.Errorf("unsupported tool_choice %s", raw)
+	}
+	return json.Marshal(map[string]string{"type": "function", "name": choice.Function.Name})
+}
+
+func chatCompletionID(responseID string) string {
+	return "chatcmpl-" + strings.TrimPrefix(responseID, "resp_")
+}
+
+// chatFinishReason 有函数调用时为 tool_calls
+func chatFinishReason(resp *v1.ResponsesResponse, hasToolCalls bool) string {
+	if resp.IncompleteDetails != nil {
+		switch resp.IncompleteDetails.Reason {
+		case "max_output_tokens":
+			return "length"
+		case "content_filter":
+			return "content_filter"
+		}
+	}
+	if hasToolCalls {
+		return "tool_calls"
+	}
+	return "stop"
+}
+
+func chatUsage(usage *v1.Usage) v1.Usage {
+	if usage == nil {
+		return v1.Usage{}
+	}
+	return v1.Usage{
+		PromptTokens:     usage.InputTokens,
+		CompletionTokens: usage.OutputTokens,
+		TotalTokens:      usage.TotalTokens,
+	}
+}
+
+// ConvertChatResponse 合并 message 项的文本, function_call 项转为 tool_calls, 忽略 reasoning 等其他输出项
+func ConvertChatResponse(resp *v1.ResponsesResponse) (*v1.ChatCompletionResponse, error) {
+	message := v1.Message{Role: "assistant"}
+	var text, refusal strings.Builder
+	for _, output := range resp.Output {
+		switch output.Type {
+		case v1.MessageInputType:
+			var contents []v1.InputContent
+			if err := json.Unmarshal(output.Content, &contents); err != nil {
+				return nil, fmt.Errorf("unmarshal output content error: %w", err)
+			}
+			for _, content := range contents {
+				switch content.Type {
+				case v1.InputContentTypeOutputText:
+					text.WriteString(content.Text)
+				case v1.InputContentTypeRefusal:
+					if s, ok := content.Refusal.(string); ok {
+						refusal.WriteString(s)
+					}
+				}
+			}
+		case v1.FunctionCallInputType:
+			message.ToolCalls = append(message.ToolCalls, v1.ToolCall{
+				Id:       fmt.Sprint(output.CallId),
+				Type:     "function",
+				Function: v1.Function{Name: output.Name, Arguments: output.Arguments},
+			})
+		}
+	}
+	if text.Len() > 0 || len(message.ToolCalls) == 0 {
+		message.SetStringContent(text.String())
+	} else {
+		message.Content = json.RawMessage("null")
+	}
+	if refusal.Len() > 0 {
+		message.Refusal = refusal.String()
+	}
+	return &v1.ChatCompletionResponse{
+		ID:      chatCompletionID(resp.ID),
+		Object:  "chat.completion",
+		Created: int64(resp.CreatedAt),
+		Model:   resp.Model,
+		Choices: []v1.Choice{{
+			Index:        0,
+			Message:      message,
+			FinishReason: chatFinishReason(resp, len(message.ToolCalls) > 0),
+		}},
+		Usage: chatUsage(resp.Usage),
+	}, nil
+}
+
+// ChatCompletions 通过 CreateResponses 实现 chat completions, 用于仅支持 Responses API 的模型
+func ChatCompletions(ctx context.Context, r Responder, req *v1.ChatCompletionRequest) (io.ReadCloser, http.Header, error) {
+	newReq, err := ConvertChatRequest(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	respBody, respHeader, err := r.CreateResponses(ctx, newReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 上游出错时返回的是 JSON 错误而非事件流
+	if req.Stream && strings.HasPrefix(respHeader.Get("Content-Type"), "text/event-stream") {
+		return ConvertChatStream(respBody, req), base.NewStreamHeader(), nil
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read all error: %w", err)
+	}
+	var resp v1.ResponsesResponse
+	if err = sonic.Unmarshal(respBytes, &resp); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	if resp.Error != nil || resp.Object != "response" {
+		return nil, nil, fmt.Errorf("responses error: %s", respBytes)
+	}
+	chatResp, err := ConvertChatResponse(&resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	newRespBytes, err := sonic.Marshal(chatResp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(newRespBytes)), base.NewJsonHeader(), nil
+}
+
+// ConvertChatStream 将 Responses 事件流转换为 chat.completion.chunk 流
+func ConvertChatStream(respBody io.ReadCloser, req *v1.ChatCompletionRequest) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		defer respBody.Close()
+		s := &chatStream{w: pw, req: req, toolIndex: make(map[string]int)}
+		err := base.ReadSSE(respBody, func(sse *base.SSEEvent) error {
+			data := strings.TrimSpace(string(sse.Data))
+			if data == "" {
+				return nil
+			}
+			if data == "[DONE]" {
+				return io.EOF
+			}
+			var event v1.ResponsesStreamEvent
+			if err := sonic.Unmarshal([]byte(data), &event); err != nil {
+				return fmt.Errorf("unmarshal error: %w", err)
+			}
+			return s.handle(&event, data)
+		})
+		// 未收到 response.completed 连接就已断开, 不能输出 [DONE]
+		if (err == nil || err == io.EOF) && !s.finished {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil && err != io.EOF {
+			pw.CloseWithError(err)
+			return
+		}
+		if err = base.WriteSSEDone(pw); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.Close()
+	}()
+	return pr
+}
+
+type chatStream struct {
+	w         io.Writer
+	req       *v1.ChatCompletionRequest
+	id        string
+	model     string
+	created   int64
+	toolIndex map[string]int // function_call item id -> tool_calls index
+	finished  bool           // 已输出带 finish_reason 的 chunk
+}
+
+func (s *chatStream) writeChunk(delta v1.Delta, finishReason string) error {
+	return base.WriteSSEData(s.w, &v1.ChatCompletionStreamResponse{
+		ID:      s.id,
+		Object:  "chat.completion.chunk",
+		Created: s.created,
+		Model:   s.model,
+		Choices: []v1.ChoiceWithDelta{{Index: 0, Delta: delta, FinishReason: finishReason}},
+	})
+}
+
+func (s *chatStream) handle(event *v1.ResponsesStreamEvent, data string) error {
+	switch event.Type {
+	case v1.ResponseCreatedEvent:
+		if event.Response != nil {
+			s.id = chatCompletionID(event.Response.ID)
+			s.model = event.Response.Model
+			s.created = int64(event.Response.CreatedAt)
+		}
+		return s.writeChunk(v1.Delta{Role: "assistant"}, "")
+	case v1.ResponseOutputTextDeltaEvent:
+		return s.writeChunk(v1.Delta{Content: event.Delta}, "")
+	case "response.refusal.delta":
+		return s.writeChunk(v1.Delta{Refusal: event.Delta}, "")
+	case v1.ResponseOutputItemAddedEvent:
+		if event.Item == nil || event.Item.Type != v1.FunctionCallInputType {
+			return nil
+		}
+		index := len(s.toolIndex)
+		s.toolIndex[event.Item.ID] = index
+		return s.writeChunk(v1.Delta{ToolCalls: []v1.ToolCall{{
+			Index:    &index,
+			Id:       fmt.Sprint(event.Item.CallId),
+			Type:     "function",
+			Function: v1.Function{Name: event.Item.Name, Arguments: event.Item.Arguments},
+		}}}, "")
+	case v1.ResponseFunctionCallArgumentsDeltaEvent:
+		index, ok := s.toolIndex[event.ItemID]
+		if !ok {
+			return nil
+		}
+		return s.writeChunk(v1.Delta{ToolCalls: []v1.ToolCall{{
+			Index:    &index,
+			Function: v1.Function{Arguments: event.Delta},
+		}}}, "")
+	case v1.ResponseCompletedEvent, v1.ResponseIncompleteEvent:
+		resp := event.Response
+		if resp == nil {
+			resp = &v1.ResponsesResponse{}
+		}
+		if err := s.writeChunk(v1.Delta{}, chatFinishReason(resp, len(s.toolIndex) > 0)); err != nil {
+			return err
+		}
+		s.finished = true
+		if event.Response != nil && s.req.StreamOptions != nil && s.req.StreamOptions.IncludeUsage {
+			err := base.WriteSSEData(s.w, &v1.ChatCompletionStreamResponse{
+				ID:      s.id,
+				Object:  "chat.completion.chunk",
+				Created: s.created,
+				Model:   s.model,
+				Choices: []v1.ChoiceWithDelta{},
+				Usage:   chatUsage(event.Response.Usage),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return io.EOF
+	case "response.failed", "error":
+		return fmt.Errorf("responses stream error: %s", data)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package emulate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	completionsInstruction = "You are a text completion engine. Continue the user's text exactly from where it ends. " +
+		"Output only the continuation, without repeating the text and without any commentary."
+	insertInstruction = "You are a text completion engine. Write the text that belongs between PREFIX and SUFFIX so that " +
+		"PREFIX + your text + SUFFIX reads naturally. Output only the inserted text, without any commentary."
+)
+
+// toInt 兼容 JSON 反序列化得到的数值类型
+func toInt(v any) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	}
+	return 0
+}
+
+// ConvertCompletionsRequest 将 legacy completions 请求转换为 chat 请求, prompt 作为待续写的用户消息
+func ConvertCompletionsRequest(req *v1.CompletionsRequest) *v1.ChatCompletionRequest {
+	instruction := completionsInstruction
+	prompt := req.Prompt
+	if req.Suffix != "" {
+		instruction = insertInstruction
+		prompt = "PREFIX:\n" + req.Prompt + "\n\nSUFFIX:\n" + req.Suffix
+	}
+	if req.System != "" {
+		instruction = req.System
+	}
+	system := v1.Message{Role: "system"}
+	system.SetStringContent(instruction)
+	user := v1.Message{Role: "user"}
+	user.SetStringContent(prompt)
+	return &v1.ChatCompletionRequest{
+		Model:            req.Model,
+		Messages:         []v1.Message{system, user},
+		FrequencyPenalty: req.FrequencyPenalty,
+		LogitBias:        req.LogitBias,
+		MaxTokens:        int(req.MaxTokens),
+		N:                toInt(req.N),
+		PresencePenalty:  req.PresencePenalty,
+		Seed:             toInt(req.Seed),
+		Stop:             req.Stop,
+		Stream:           req.Stream,
+		StreamOptions:    req.StreamOptions,
+		Temperature:      req.Temperature,
+		TopP:             req.TopP,
+		User:             req.User,
+		ExtraBody:        req.ExtraBody,
+		UnknownFields:    req.UnknownFields,
+	}
+}
+
+func completionsID(chatID string) string {
+	return "cmpl-" + strings.TrimPrefix(chatID, "chatcmpl-")
+}
+
+// ConvertCompletionsResponse echo 为 true 时在结果前拼接 prompt
+func ConvertCompletionsResponse(resp *v1.ChatCompletionResponse, req *v1.CompletionsRequest) *v1.CompletionsResp {
+	newResp := &v1.CompletionsResp{
+		ID:      completionsID(resp.ID),
+		Object:  "text_completion",
+		Created: resp.Created,
+		Model:   resp.Model,
+		Choices: make([]v1.CompletionsChoice, 0, len(resp.Choices)),
+		Usage:   resp.Usage,
+	}
+	for _, choice := range resp.Choices {
+		text := choice.Message.StringContent()
+		if req.Echo {
+			text = req.Prompt + text
+		}
+		newResp.Choices = append(newResp.Choices, v1.CompletionsChoice{
+			Text:         text,
+			Index:        choice.Index,
+			FinishReason: choice.FinishReason,
+		})
+	}
+	return newResp
+}
+
+// Completions 通过 CreateChatCompletions 实现 legacy completions
+func Completions(ctx context.Context, c Chatter, req *v1.CompletionsRequest) (io.ReadCloser, http.Header, error) {
+	respBody, _, err := c.CreateChatCompletions(ctx, ConvertCompletionsRequest(req))
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.Stream {
+		return ConvertCompletionsStream(respBody, req), base.NewStreamHeader(), nil
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read all error: %w", err)
+	}
+	resp, err := base.ParseChatResponse(respBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	newRespBytes, err := sonic.Marshal(ConvertCompletionsResponse(resp, req))
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(newRespBytes)), base.NewJsonHeader(), nil
+}
+
+// ConvertCompletionsStream 将 chat.completion.chunk 流转换为 text_completion 流
+func ConvertCompletionsStream(respBody io.ReadCloser, req *v1.CompletionsRequest) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		defer respBody.Close()
+		echoed := make(map[int]bool)
+		err := base.ReadSSE(respBody, func(sse *base.SSEEvent) error {
+			data := strings.TrimSpace(string(sse.Data))
+			if data == "" {
+				return nil
+			}
+			if data == "[DONE]" {
+				return io.EOF
+			}
+			chunk, err := base.ParseChatStreamResponse([]byte(data))
+			if err != nil {
+				return err
+			}
+			newChunk := &v1.CompletionsResp{
+				ID:      completionsID(chunk.ID),
+				Object:  "text_completion",
+				Created: chunk.Created,
+				Model:   chunk.Model,
+				Choices: make([]v1.CompletionsChoice, 0, len(chunk.Choices)),
+				Usage:   chunk.Usage,
+			}
+			for _, choice := range chunk.Choices {
+				text := choice.Delta.Content
+				if req.Echo && !echoed[choice.Index] {
+					echoed[choice.Index] = true
+					text = req.Prompt + text
+				}
+				newChunk.Choices = append(newChunk.Choices, v1.CompletionsChoice{
+					Text:         text,
+					Index:        choice.Index,
+					FinishReason: choice.FinishReason,
+				})
+			}
+			return base.WriteSSEData(pw, newChunk)
+		})
+		if err != nil && err != io.EOF {
+			pw.CloseWithError(err)
+			return
+		}
+		if err = base.WriteSSEDone(pw); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.Close()
+	}()
+	return pr
+}
@@ -0,0 +1,163 @@
+package emulate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var (
+	// EmbeddingBatchSize 单次 embeddings 请求的最大输入数
+	EmbeddingBatchSize = 64
+	// RerankChunkSize 文档分块长度, 按字符近似 token
+	RerankChunkSize = 1024
+)
+
+type Embedder interface {
+	CreateEmbeddings(ctx context.Context, req *v1.EmbeddingsRequest) (io.ReadCloser, http.Header, error)
+}
+
+// ChunkDocument 将文档切分为至多 maxChunks 块, 相邻块重叠 overlap 个字符, maxChunks <= 0 时不切分
+func ChunkDocument(doc string, maxChunks, overlap int) []string {
+	runes := []rune(doc)
+	if maxChunks <= 0 || len(runes) <= RerankChunkSize {
+		return []string{doc}
+	}
+	overlap = min(max(overlap, 0), RerankChunkSize/2)
+	chunks := make([]string, 0, maxChunks)
+	for start := 0; start < len(runes) && len(chunks) < maxChunks; start += RerankChunkSize - overlap {
+		end := min(start+RerankChunkSize, len(runes))
+		chunks = append(chunks, string(runes[start:end]))
+		if end == len(runes) {
+			break
+		}
+	}
+	return chunks
+}
+
+// Embed 分批调用 CreateEmbeddings, 按输入顺序返回向量
+func Embed(ctx context.Context, e Embedder, model string, inputs []string) ([][]float64, v1.Usage, error) {
+	var usage v1.Usage
+	vectors := make([][]float64, len(inputs))
+	for start := 0; start < len(inputs); start += EmbeddingBatchSize {
+		end := min(start+EmbeddingBatchSize, len(inputs))
+		batch := make([]any, 0, end-start)
+		for _, input := range inputs[start:end] {
+			batch = append(batch, input)
+		}
+		respBody, _, err := e.CreateEmbeddings(ctx, &v1.EmbeddingsRequest{Model: model, Input: batch, EncodingFormat: "float"})
+		if err != nil {
+			return nil, usage, err
+		}
+		respBytes, err := io.ReadAll(respBody)
+		respBody.Close()
+		if err != nil {
+			return nil, usage, fmt.Errorf("read all error: %w", err)
+		}
+		var resp v1.EmbeddingsResponse
+		if err = sonic.Unmarshal(respBytes, &resp); err != nil {
+			return nil, usage, fmt.Errorf("unmarshal error: %w", err)
+		}
+		for i, data := range resp.Data {
+			index := start + data.Index
+			if data.Index == 0 && i > 0 {
+				// 部分服务不返回 index, 按顺序对应
+				index = start + i
+			}
+			if index >= end {
+				return nil, usage, fmt.Errorf("embedding index %d out of range", data.Index)
+			}
+			vector := make([]float64, len(data.Embedding))
+			for j, value := range data.Embedding {
+				f, ok := value.(float64)
+				if !ok {
+					return nil, usage, fmt.Errorf("unexpected embedding value type %T", value)
+				}
+				vector[j] = f
+			}
+			vectors[index] = vector
+		}
+		usage.PromptTokens += resp.Usage.PromptTokens
+		usage.TotalTokens += resp.Usage.TotalTokens
+	}
+	for i, vector := range vectors {
+		if vector == nil {
+			return nil, usage, fmt.Errorf("missing embedding for input %d", i)
+		}
+	}
+	return vectors, usage, nil
+}
+
+func CosineSimilarity(a, b []float64) float64 {
+	var dot, normA, normB float64
+	for i := 0; i < len(a) && i < len(b); i++ {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// EmbeddingRerank 以 query 与文档向量的余弦相似度排序, 文档分块时取各块的最高分
+func EmbeddingRerank(ctx context.Context, e Embedder, req *v1.RerankRequest) (io.ReadCloser, http.Header, error) {
+	inputs := []string{req.Query}
+	owners := []int{-1}
+	for i, doc := range req.Documents {
+		for _, chunk := range ChunkDocument(doc, req.MaxChunkPerDoc, req.OverLapTokens) {
+			inputs = append(inputs, chunk)
+			owners = append(owners, i)
+		}
+	}
+	vectors, usage, err := Embed(ctx, e, req.Model, inputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	scores := make([]float64, len(req.Documents))
+	for i := range scores {
+		scores[i] = math.Inf(-1)
+	}
+	for i := 1; i < len(vectors); i++ {
+		scores[owners[i]] = max(scores[owners[i]], CosineSimilarity(vectors[0], vectors[i]))
+	}
+	return newRerankResponse(req, scores, &usage)
+}
+
+// newRerankResponse 按分数降序输出, 处理 top_n 与 return_documents
+func newRerankResponse(req *v1.RerankRequest, scores []float64, usage *v1.Usage) (io.ReadCloser, http.Header, error) {
+	results := make([]v1.RerankResult, 0, len(scores))
+	for i, score := range scores {
+		result := v1.RerankResult{Index: i, RelevanceScore: score}
+		if req.ReturnDocuments {
+			result.Document.Text = req.Documents[i]
+		}
+		results = append(results, result)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].RelevanceScore > results[j].RelevanceScore
+	})
+	if req.TopN > 0 && req.TopN < len(results) {
+		results = results[:req.TopN]
+	}
+	newResp := v1.RerankResponse{
+		ID:      "rerank-" + strconv.FormatInt(time.Now().UnixNano(), 36),
+		Results: results,
+		Usage:   usage,
+	}
+	respBytes, err := sonic.Marshal(newResp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(respBytes)), base.NewJsonHeader(), nil
+}
@@ -0,0 +1,636 @@
+package emulate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/pkg/rr"
+	"github.com/jiu-u/oai-adapter/pkg/store"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	responsesStatusInProgress = "in_progress"
+	responsesStatusCompleted  = "completed"
+	responsesStatusIncomplete = "incomplete"
+)
+
+// ConvertResponsesRequest 将 Responses 请求转换为 chat 请求
+// 仅支持 function 工具, previous_response_id 需由调用方展开为 input
+func ConvertResponsesRequest(req *v1.ResponsesRequest) (*v1.ChatCompletionRequest, error) {
+	chatReq := &v1.ChatCompletionRequest{
+		Model:             req.Model,
+		MaxTokens:         int(req.MaxOutputTokens),
+		ParallelToolCalls: req.ParallelToolCalls,
+		Stream:            req.IsStream(),
+		Temperature:       req.Temperature,
+		TopP:              req.TopP,
+		User:              req.User,
+	}
+	if chatReq.Stream {
+		chatReq.StreamOptions = &v1.StreamOptions{IncludeUsage: true}
+	}
+	if req.Instructions != "" {
+		system := v1.Message{Role: "system"}
+		system.SetStringContent(req.Instructions)
+		chatReq.Messages = append(chatReq.Messages, system)
+	}
+	inputs, err := req.ParseInput()
+	if err != nil {
+		return nil, err
+	}
+	for _, input := range inputs {
+		switch input.Type {
+		case v1.MessageInputType, "":
+			message, err := convertInputMessage(&input)
+			if err != nil {
+				return nil, err
+			}
+			chatReq.Messages = append(chatReq.Messages, *message)
+		case v1.FunctionCallInputType:
+			toolCall := v1.ToolCall{
+				Id:       fmt.Sprint(input.CallId),
+				Type:     "function",
+				Function: v1.Function{Name: input.Name, Arguments: input.Arguments},
+			}
+			// 同一轮的多个调用合并到一条 assistant 消息
+			if n := len(chatReq.Messages); n > 0 && chatReq.Messages[n-1].Role == "assistant" {
+				chatReq.Messages[n-1].ToolCalls = append(chatReq.Messages[n-1].ToolCalls, toolCall)
+				continue
+			}
+			chatReq.Messages = append(chatReq.Messages, v1.Message{Role: "assistant", ToolCalls: []v1.ToolCall{toolCall}})
+		case v1.FunctionCallOutputInputType:
+			message := v1.Message{Role: "tool", ToolCallId: fmt.Sprint(input.CallId)}
+			message.SetStringContent(input.Output)
+			chatReq.Messages = append(chatReq.Messages, message)
+		case v1.ReasoningInputType, v1.ItemReferenceInputType:
+			// 推理内容与引用无法传递给 chat 接口
+		default:
+			return nil, fmt.Errorf("unsupported input type %s", input.Type)
+		}
+	}
+	for _, tool := range req.Tools {
+		if tool.Type != "function" {
+			return nil, fmt.Errorf("unsupported tool type %s", tool.Type)
+		}
+		function := v1.Function{Name: tool.Name, Description: tool.Description}
+		if tool.Parameters != nil {
+			if function.Parameters, err = json.Marshal(tool.Parameters); err != nil {
+				return nil, fmt.Errorf("marshal error: %w", err)
+			}
+		}
+		chatReq.Tools = append(chatReq.Tools, v1.Tool{Type: "function", Function: function})
+	}
+	if chatReq.ToolChoice, err = convertResponsesToolChoice(req.ToolChoice); err != nil {
+		return nil, err
+	}
+	if format := req.TextFormat(); format != nil {
+		switch format.Type {
+		case "json_schema":
+			chatReq.ResponseFormat = &v1.ResponseFormat{Type: format.Type, JsonSchema: &v1.FormatJsonSchema{
+				Name:        format.Name,
+				Description: format.Description,
+				Schema:      format.Schema,
+				Strict:      format.Strict,
+			}}
+		case "json_object":
+			chatReq.ResponseFormat = &v1.ResponseFormat{Type: format.Type}
+		}
+	}
+	if req.Reasoning != nil {
+		effort := req.Reasoning.Effort
+		if effort == "" {
+			effort = req.Reasoning.Effect
+		}
+		if effort != "" {
+			chatReq.ExtraBody = map[string]any{"reasoning_effort": effort}
+		}
+	}
+	return chatReq, nil
+}
+
+// convertInputMessage developer 转为 system, 纯文本内容合并为字符串以兼容只接受字符串的服务
+func convertInputMessage(input *v1.ResponsesInput) (*v1.Message, error) {
+	message := &v1.Message{Role: input.Role}
+	if message.Role == "developer" {
+		message.Role = "system"
+	}
+	if input.IsStringContent() {
+		message.SetStringContent(input.StringContent())
+		return message, nil
+	}
+	contents, err := input.ParseContent()
+	if err != nil {
+		return nil, err
+	}
+	var text strings.Builder
+	textOnly := true
+	parts := make([]v1.MediaContent, 0, len(contents))
+	for _, content := range contents {
+		switch content.Type {
+		case v1.InputContentTypeText, v1.InputContentTypeOutputText:
+			text.WriteString(content.Text)
+			parts = append(parts, v1.MediaContent{Type: v1.ContentTypeText, Text: content.Text})
+		case v1.InputContentTypeImage:
+			if content.ImageUrl == "" {
+				return nil, fmt.Errorf("input_image without image_url is not supported")
+			}
+			textOnly = false
+			parts = append(parts, v1.MediaContent{Type: v1.ContentTypeImageURL, ImageUrl: &v1.ImageUrl{Url: content.ImageUrl, Detail: content.Detail}})
+		case v1.InputContentTypeInputAudio:
+			if content.FileData == "" {
+				return nil, fmt.Errorf("input_file without file_data is not supported")
+			}
+			textOnly = false
+			parts = append(parts, v1.MediaContent{Type: v1.ContentTypeFile, File: &v1.File{FileData: content.FileData, Filename: content.Filename}})
+		case v1.InputContentTypeRefusal:
+		default:
+			return nil, fmt.Errorf("unsupported content type %s", content.Type)
+		}
+	}
+	if textOnly {
+		message.SetStringContent(text.String())
+		return message, nil
+	}
+	if message.Content, err = json.Marshal(parts); err != nil {
+		return nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return message, nil
+}
+
+// convertResponsesToolChoice {"type":"function","name":"x"} 转为 {"type":"function","function":{"name":"x"}}
+func convertResponsesToolChoice(raw json.RawMessage) (any, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var mode string
+	if err := json.Unmarshal(raw, &mode); err == nil {
+		return mode, nil
+	}
+	var choice struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(raw, &choice); err != nil {
+		return nil, fmt.Errorf("unmarshal tool_choice error: %w", err)
+	}
+	if choice.Type != "function" {
+		return nil, fmt.Errorf("unsupported tool_choice type %s", choice.Type)
+	}
+	return v1.ToolChoice{Type: "function", Function: &v1.FunctionChoice{Name: choice.Name}}, nil
+}
+
+// newResponsesResponse 创建回显请求参数的 response 对象
+// ID 随机生成而不沿用上游 chat ID, 部分服务的 chat ID 不唯一, 会导致存储的对话被覆盖
+func newResponsesResponse(req *v1.ResponsesRequest, model string, created int64) *v1.ResponsesResponse {
+	resp := &v1.ResponsesResponse{
+		ID:                 "resp_" + rr.GenString(24),
+		Object:             "response",
+		CreatedAt:          int(created),
+		Model:              model,
+		Status:             responsesStatusInProgress,
+		Instructions:       req.Instructions,
+		MaxOutputTokens:    int(req.MaxOutputTokens),
+		ParallelToolCalls:  req.ParallelToolCalls,
+		PreviousResponseID: req.PreviousResponseId,
+		Reasoning:          req.Reasoning,
+		Temperature:        req.Temperature,
+		Text:               req.Text,
+		ToolChoice:         req.ToolChoice,
+		Tools:              req.Tools,
+		TopP:               req.TopP,
+		Truncation:         req.Truncation,
+		User:               req.User,
+	}
+	if resp.Truncation == "" {
+		resp.Truncation = "disabled"
+	}
+	if req.Metadata != nil {
+		resp.Metadata, _ = json.Marshal(req.Metadata)
+	}
+	return resp
+}
+
+// completeResponses 按 finish_reason 设置最终状态
+func completeResponses(resp *v1.ResponsesResponse, finishReason string) {
+	switch finishReason {
+	case "length":
+		resp.Status = responsesStatusIncomplete
+		resp.IncompleteDetails = &v1.IncompleteDetails{Reason: "max_output_tokens"}
+	case "content_filter":
+		resp.Status = responsesStatusIncomplete
+		resp.IncompleteDetails = &v1.IncompleteDetails{Reason: "content_filter"}
+	default:
+		resp.Status = responsesStatusCompleted
+	}
+}
+
+func responsesUsage(usage v1.Usage) *v1.Usage {
+	return &v1.Usage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+		InputTokens:      usage.PromptTokens,
+		OutputTokens:     usage.CompletionTokens,
+	}
+}
+
+func outputTextContent(text string) v1.InputContent {
+	return v1.InputContent{Type: v1.InputContentTypeOutputText, Text: text, Annotations: []any{}}
+}
+
+func newMessageOutput(text string) (v1.ResponsesOutput, error) {
+	content, err := json.Marshal([]v1.InputContent{outputTextContent(text)})
+	if err != nil {
+		return v1.ResponsesOutput{}, fmt.Errorf("marshal error: %w", err)
+	}
+	return v1.ResponsesOutput{
+		ID:      "msg_" + rr.GenString(24),
+		Type:    v1.MessageInputType,
+		Role:    "assistant",
+		Status:  responsesStatusCompleted,
+		Content: content,
+	}, nil
+}
+
+func newFunctionCallOutput(toolCall *v1.ToolCall) v1.ResponsesOutput {
+	callID := toolCall.Id
+	if callID == "" {
+		callID = "call_" + rr.GenString(24)
+	}
+	return v1.ResponsesOutput{
+		ID:        "fc_" + rr.GenString(24),
+		Type:      v1.FunctionCallInputType,
+		Status:    responsesStatusCompleted,
+		CallId:    callID,
+		Name:      toolCall.Function.Name,
+		Arguments: toolCall.Function.Arguments,
+	}
+}
+
+// ConvertResponsesResponse 将 chat 回复转换为 message 与 function_call 输出项, 仅使用第一个 choice
+func ConvertResponsesResponse(chatResp *v1.ChatCompletionResponse, req *v1.ResponsesRequest) (*v1.ResponsesResponse, error) {
+	resp := newResponsesResponse(req, chatResp.Model, chatResp.Created)
+	resp.Usage = responsesUsage(chatResp.Usage)
+	if len(chatResp.Choices) == 0 {
+		completeResponses(resp, "")
+		return resp, nil
+	}
+	choice := chatResp.Choices[0]
+	if text := choice.Message.StringContent(); len(choice.Message.Content) > 0 && text != "" && text != "null" {
+		message, err := newMessageOutput(text)
+		if err != nil {
+			return nil, err
+		}
+		resp.Output = append(resp.Output, message)
+		resp.OutputText = text
+	}
+	for i := range choice.Message.ToolCalls {
+		resp.Output = append(resp.Output, newFunctionCallOutput(&choice.Message.ToolCalls[i]))
+	}
+	completeResponses(resp, choice.FinishReason)
+	return resp, nil
+}
+
+// Responses 通过 CreateChatCompletions 实现 Responses API
+// s 不为空时按 previous_response_id 还原历史对话, store 为 true 时保存本轮输入与输出
+func Responses(ctx context.Context, c Chatter, s store.ResponseStore, req *v1.ResponsesRequest) (io.ReadCloser, http.Header, error) {
+	inputs, err := req.ParseInput()
+	if err != nil {
+		return nil, nil, err
+	}
+	chatReq, err := convertWithHistory(ctx, s, req, inputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	respBody, _, err := c.CreateChatCompletions(ctx, chatReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	var record func(resp *v1.ResponsesResponse) error
+	// 与上游一致, 未设置 store 时默认保存
+	if s != nil && (req.Store == nil || *req.Store) {
+		record = func(resp *v1.ResponsesResponse) error {
+			// 流式响应结束时请求的 ctx 可能已取消
+			return s.Put(context.WithoutCancel(ctx), &store.Record{
+				ID:                 resp.ID,
+				PreviousResponseID: req.PreviousResponseId,
+				Input:              inputs,
+				Response:           resp,
+				CreatedAt:          time.Now(),
+			})
+		}
+	}
+	if chatReq.Stream {
+		return convertResponsesStream(respBody, req, record), base.NewStreamHeader(), nil
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read all error: %w", err)
+	}
+	chatResp, err := base.ParseChatResponse(respBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := ConvertResponsesResponse(chatResp, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if record != nil {
+		if err = record(resp); err != nil {
+			return nil, nil, fmt.Errorf("store response error: %w", err)
+		}
+	}
+	newRespBytes, err := sonic.Marshal(resp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(newRespBytes)), base.NewJsonHeader(), nil
+}
+
+// convertWithHistory 将历史对话拼接在本轮输入之前再转换
+func convertWithHistory(ctx context.Context, s store.ResponseStore, req *v1.ResponsesRequest, inputs []v1.ResponsesInput) (*v1.ChatCompletionRequest, error) {
+	if req.PreviousResponseId == "" {
+		return ConvertResponsesRequest(req)
+	}
+	if s == nil {
+		return nil, fmt.Errorf("previous_response_id requires a response store")
+	}
+	history, err := store.History(ctx, s, req.PreviousResponseId)
+	if err != nil {
+		return nil, err
+	}
+	expanded := *req
+	if expanded.Input, err = json.Marshal(append(history, inputs...)); err != nil {
+		return nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return ConvertResponsesRequest(&expanded)
+}
+
+// ConvertResponsesStream 将 chat.completion.chunk 流转换为 Responses 事件流
+func ConvertResponsesStream(respBody io.ReadCloser, req *v1.ResponsesRequest) io.ReadCloser {
+	return convertResponsesStream(respBody, req, nil)
+}
+
+// convertResponsesStream record 不为空时在 response.completed 之前保存最终结果
+func convertResponsesStream(respBody io.ReadCloser, req *v1.ResponsesRequest, record func(*v1.ResponsesResponse) error) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		defer respBody.Close()
+		s := &responsesStream{w: pw, req: req, current: -1, calls: make(map[int]int), record: record}
+		err := base.ReadSSE(respBody, func(sse *base.SSEEvent) error {
+			data := strings.TrimSpace(string(sse.Data))
+			if data == "" {
+				return nil
+			}
+			if data == "[DONE]" {
+				return io.EOF
+			}
+			chunk, err := base.ParseChatStreamResponse([]byte(data))
+			if err != nil {
+				return err
+			}
+			return s.handle(chunk)
+		})
+		// 既没有 [DONE] 也没有 finish_reason 说明上游连接中途断开, 不能输出 response.completed
+		if err == nil && s.finishReason == "" {
+			err = io.ErrUnexpectedEOF
+		}
+		if err == nil || err == io.EOF {
+			err = s.finish()
+		}
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.Close()
+	}()
+	return pr
+}
+
+// responsesStream 消息输出项在新输出项开始时结束, 工具调用的参数可能交错到达, 调用项保持进行中直到流结束
+type responsesStream struct {
+	w            io.Writer
+	req          *v1.ResponsesRequest
+	resp         *v1.ResponsesResponse
+	seq          int
+	current      int // 进行中的消息输出项下标, -1 表示无
+	text         strings.Builder
+	calls        map[int]int // chat tool_call index -> 输出项下标
+	usage        v1.Usage
+	finishReason string
+	record       func(*v1.ResponsesResponse) error
+}
+
+func (s *responsesStream) emit(event *v1.ResponsesStreamEvent) error {
+	event.SequenceNumber = s.seq
+	s.seq++
+	return base.WriteSSEEvent(s.w, event.Type, event)
+}
+
+func (s *responsesStream) start(chunk *v1.ChatCompletionStreamResponse) error {
+	s.resp = newResponsesResponse(s.req, chunk.Model, chunk.Created)
+	if err := s.emit(&v1.ResponsesStreamEvent{Type: v1.ResponseCreatedEvent, Response: s.resp}); err != nil {
+		return err
+	}
+	return s.emit(&v1.ResponsesStreamEvent{Type: v1.ResponseInProgressEvent, Response: s.resp})
+}
+
+func (s *responsesStream) handle(chunk *v1.ChatCompletionStreamResponse) error {
+	if s.resp == nil {
+		if err := s.start(chunk); err != nil {
+			return err
+		}
+	}
+	if chunk.Usage.TotalTokens > 0 {
+		s.usage = chunk.Usage
+	}
+	for _, choice := range chunk.Choices {
+		if choice.Index != 0 {
+			continue
+		}
+		if choice.Delta.Content != "" {
+			if err := s.writeText(choice.Delta.Content); err != nil {
+				return err
+			}
+		}
+		for i := range choice.Delta.ToolCalls {
+			if err := s.writeToolCall(&choice.Delta.ToolCalls[i], i); err != nil {
+				return err
+			}
+		}
+		if choice.FinishReason != "" {
+			s.finishReason = choice.FinishReason
+		}
+	}
+	return nil
+}
+
+// addItem 追加输出项并返回其下标
+func (s *responsesStream) addItem(item v1.ResponsesOutput) (int, error) {
+	if err := s.finishMessage(); err != nil {
+		return 0, err
+	}
+	item.Status = responsesStatusInProgress
+	s.resp.Output = append(s.resp.Output, item)
+	outputIndex := len(s.resp.Output) - 1
+	return outputIndex, s.emit(&v1.ResponsesStreamEvent{Type: v1.ResponseOutputItemAddedEvent, OutputIndex: &outputIndex, Item: &item})
+}
+
+func (s *responsesStream) writeText(delta string) error {
+	outputIndex, contentIndex := s.current, 0
+	if outputIndex < 0 || s.resp.Output[outputIndex].Type != v1.MessageInputType {
+		item, err := newMessageOutput("")
+		if err != nil {
+			return err
+		}
+		item.Content = json.RawMessage("[]")
+		if outputIndex, err = s.addItem(item); err != nil {
+			return err
+		}
+		s.current = outputIndex
+		s.text.Reset()
+		part := outputTextContent("")
+		err = s.emit(&v1.ResponsesStreamEvent{
+			Type:         v1.ResponseContentPartAddedEvent,
+			ItemID:       item.ID,
+			OutputIndex:  &outputIndex,
+			ContentIndex: &contentIndex,
+			Part:         &part,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	s.text.WriteString(delta)
+	return s.emit(&v1.ResponsesStreamEvent{
+		Type:         v1.ResponseOutputTextDeltaEvent,
+		ItemID:       s.resp.Output[outputIndex].ID,
+		OutputIndex:  &outputIndex,
+		ContentIndex: &contentIndex,
+		Delta:        delta,
+	})
+}
+
+func (s *responsesStream) writeToolCall(toolCall *v1.ToolCall, i int) error {
+	index := toolCall.IndexOr(i)
+	outputIndex, ok := s.calls[index]
+	if !ok {
+		item := newFunctionCallOutput(toolCall)
+		item.Arguments = ""
+		var err error
+		if outputIndex, err = s.addItem(item); err != nil {
+			return err
+		}
+		s.calls[index] = outputIndex
+	}
+	if toolCall.Function.Arguments == "" {
+		return nil
+	}
+	s.resp.Output[outputIndex].Arguments += toolCall.Function.Arguments
+	return s.emit(&v1.ResponsesStreamEvent{
+		Type:        v1.ResponseFunctionCallArgumentsDeltaEvent,
+		ItemID:      s.resp.Output[outputIndex].ID,
+		OutputIndex: &outputIndex,
+		Delta:       toolCall.Function.Arguments,
+	})
+}
+
+// finishMessage 结束进行中的消息输出项
+func (s *responsesStream) finishMessage() error {
+	if s.current < 0 {
+		return nil
+	}
+	outputIndex := s.current
+	s.current = -1
+	item := &s.resp.Output[outputIndex]
+	item.Status = responsesStatusCompleted
+	text := s.text.String()
+	s.resp.OutputText += text
+	part := outputTextContent(text)
+	content, err := json.Marshal([]v1.InputContent{part})
+	if err != nil {
+		return fmt.Errorf("marshal error: %w", err)
+	}
+	item.Content = content
+	contentIndex := 0
+	err = s.emit(&v1.ResponsesStreamEvent{
+		Type:         v1.ResponseOutputTextDoneEvent,
+		ItemID:       item.ID,
+		OutputIndex:  &outputIndex,
+		ContentIndex: &contentIndex,
+		Text:         text,
+	})
+	if err != nil {
+		return err
+	}
+	err = s.emit(&v1.ResponsesStreamEvent{
+		Type:         v1.ResponseContentPartDoneEvent,
+		ItemID:       item.ID,
+		OutputIndex:  &outputIndex,
+		ContentIndex: &contentIndex,
+		Part:         &part,
+	})
+	if err != nil {
+		return err
+	}
+	return s.emit(&v1.ResponsesStreamEvent{Type: v1.ResponseOutputItemDoneEvent, OutputIndex: &outputIndex, Item: item})
+}
+
+// finishToolCalls 流结束时按输出顺序结束所有工具调用, 此时参数已完整
+func (s *responsesStream) finishToolCalls() error {
+	for outputIndex := range s.resp.Output {
+		item := &s.resp.Output[outputIndex]
+		if item.Type != v1.FunctionCallInputType || item.Status != responsesStatusInProgress {
+			continue
+		}
+		item.Status = responsesStatusCompleted
+		err := s.emit(&v1.ResponsesStreamEvent{
+			Type:        v1.ResponseFunctionCallArgumentsDoneEvent,
+			ItemID:      item.ID,
+			OutputIndex: &outputIndex,
+			Arguments:   item.Arguments,
+		})
+		if err != nil {
+			return err
+		}
+		if err = s.emit(&v1.ResponsesStreamEvent{Type: v1.ResponseOutputItemDoneEvent, OutputIndex: &outputIndex, Item: item}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *responsesStream) finish() error {
+	if s.resp == nil {
+		if err := s.start(&v1.ChatCompletionStreamResponse{Model: s.req.Model}); err != nil {
+			return err
+		}
+	}
+	if err := s.finishMessage(); err != nil {
+		return err
+	}
+	if err := s.finishToolCalls(); err != nil {
+		return err
+	}
+	s.resp.Usage = responsesUsage(s.usage)
+	completeResponses(s.resp, s.finishReason)
+	if s.record != nil {
+		if err := s.record(s.resp); err != nil {
+			return fmt.Errorf("store response error: %w", err)
+		}
+	}
+	eventType := v1.ResponseCompletedEvent
+	if s.resp.Status == responsesStatusIncomplete {
+		eventType = v1.ResponseIncompleteEvent
+	}
+	return s.emit(&v1.ResponsesStreamEvent{Type: eventType, Response: s.resp})
+}
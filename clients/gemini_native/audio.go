@@ -0,0 +1,314 @@
+package gemini_native
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/tools"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// https://ai.google.dev/gemini-api/docs/speech-generation
+// https://ai.google.dev/gemini-api/docs/audio
+
+var (
+	DefaultSpeechModel        = "gemini-2.5-flash-preview-tts"
+	DefaultTranscriptionModel = "gemini-2.5-flash"
+	DefaultVoice              = "Kore"
+	// SpeechSampleRate TTS 输出为 24kHz 16bit 单声道 PCM
+	SpeechSampleRate = 24000
+	// VoiceMapping OpenAI 音色映射为音色接近的 Gemini 预置音色, 未列出的音色名直接透传
+	VoiceMapping = map[string]string{
+		"alloy":   "Kore",
+		"ash":     "Orus",
+		"ballad":  "Iapetus",
+		"coral":   "Aoede",
+		"echo":    "Charon",
+		"fable":   "Puck",
+		"nova":    "Leda",
+		"onyx":    "Fenrir",
+		"sage":    "Sulafat",
+		"shimmer": "Zephyr",
+		"verse":   "Enceladus",
+	}
+)
+
+const (
+	transcribePrompt = "Generate a verbatim transcript of the speech in this audio. Output only the transcript."
+	translatePrompt  = "Translate the speech in this audio into English. Output only the English translation."
+	segmentsPrompt   = " Split it into segments of at most one sentence, with start and end times in seconds from the beginning of the audio."
+)
+
+// segmentsSchema 需要时间戳时要求模型按该结构输出
+var segmentsSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"language": map[string]any{"type": "string", "description": "ISO-639-1 code of the spoken language"},
+		"segments": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"start": map[string]any{"type": "number"},
+					"end":   map[string]any{"type": "number"},
+					"text":  map[string]any{"type": "string"},
+				},
+				"required": []string{"start", "end", "text"},
+			},
+		},
+	},
+	"required": []string{"segments"},
+}
+
+type (
+	transcriptSegments struct {
+		Language string              `json:"language"`
+		Segments []transcriptSegment `json:"segments"`
+	}
+	transcriptSegment struct {
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+		Text  string  `json:"text"`
+	}
+)
+
+func ConvertVoice(voice string) string {
+	if voice == "" {
+		return DefaultVoice
+	}
+	if v, ok := VoiceMapping[strings.ToLower(voice)]; ok {
+		return v
+	}
+	return voice
+}
+
+// ConvertSpeechRequest instructions 作为语气提示放在文本之前, Gemini TTS 通过自然语言控制风格
+func ConvertSpeechRequest(req *v1.AudioSpeechRequest) *GenerateContentRequest {
+	text := req.Input
+	if req.Instructions != "" {
+		text = req.Instructions + ": " + req.Input
+	}
+	return &GenerateContentRequest{
+		Contents: []Content{{Role: "user", Parts: []Part{{Text: text}}}},
+		GenerationConfig: &GenerationConfig{
+			ResponseModalities: []string{"AUDIO"},
+			SpeechConfig: &SpeechConfig{
+				VoiceConfig: &VoiceConfig{PrebuiltVoiceConfig: &PrebuiltVoiceConfig{VoiceName: ConvertVoice(req.Voice)}},
+			},
+		},
+	}
+}
+
+func (c *Client) generateContent(ctx context.Context, model string, req *GenerateContentRequest) (*GenerateContentResponse, error) {
+	respBody, _, err := c.GenerateContent(ctx, model, req, false)
+	if err != nil {
+		return nil, err
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return nil, fmt.Errorf("read all error: %w", err)
+	}
+	var resp GenerateContentResponse
+	if err = sonic.Unmarshal(respBytes, &resp); err != nil {
+		return nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	return &resp, nil
+}
+
+// CreateSpeech 返回的 PCM 仅能封装为 wav 或原样输出, mp3 等格式需要编码器, 直接报错而不是替换格式
+// 未指定 response_format 时返回 wav
+func (c *Client) CreateSpeech(ctx context.Context, req *v1.AudioSpeechRequest) (io.ReadCloser, http.Header, error) {
+	switch req.ResponseFormat {
+	case "", "wav", "pcm":
+	default:
+		return nil, nil, fmt.Errorf("unsupported response_format %s, gemini speech only supports wav and pcm", req.ResponseFormat)
+	}
+	model := req.Model
+	if !strings.Contains(model, "tts") || !strings.HasPrefix(strings.TrimPrefix(model, "models/"), "gemini") {
+		model = DefaultSpeechModel
+	}
+	resp, err := c.generateContent(ctx, model, ConvertSpeechRequest(req))
+	if err != nil {
+		return nil, nil, err
+	}
+	// 长文本的音频可能拆分为多个 part, 按顺序拼接第一个包含音频的候选中的所有 PCM
+	var pcm []byte
+	var mimeType string
+	for _, candidate := range resp.Candidates {
+		for _, part := range candidate.Content.Parts {
+			if part.InlineData == nil || !strings.HasPrefix(part.InlineData.MimeType, "audio/") {
+				continue
+			}
+			data, err := base64.StdEncoding.DecodeString(part.InlineData.Data)
+			if err != nil {
+				return nil, nil, fmt.Errorf("decode audio error: %w", err)
+			}
+			pcm = append(pcm, data...)
+			mimeType = part.InlineData.MimeType
+		}
+		if mimeType != "" {
+			break
+		}
+	}
+	if mimeType == "" {
+		return nil, nil, fmt.Errorf("no audio in response")
+	}
+	header := http.Header{}
+	if req.ResponseFormat == "pcm" {
+		header.Set("Content-Type", "audio/pcm")
+		return io.NopCloser(bytes.NewReader(pcm)), header, nil
+	}
+	header.Set("Content-Type", "audio/wav")
+	wav := tools.PCMToWav(pcm, tools.ParsePCMRate(mimeType, SpeechSampleRate), 1)
+	return io.NopCloser(bytes.NewReader(wav)), header, nil
+}
+
+// audioPart 上传的音频以 inlineData 发送, 单个请求上限 20MB
+func audioPart(file *multipart.FileHeader) (*Part, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open file error: %w", err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("read file error: %w", err)
+	}
+	mimeType := file.Header.Get("Content-Type")
+	if !strings.HasPrefix(mimeType, "audio/") && !strings.HasPrefix(mimeType, "video/") {
+		mimeType = mime.TypeByExtension(strings.ToLower(filepath.Ext(file.Filename)))
+	}
+	if mimeType == "" {
+		mimeType = "audio/mpeg"
+	}
+	return &Part{InlineData: &Blob{MimeType: mimeType, Data: base64.StdEncoding.EncodeToString(data)}}, nil
+}
+
+// transcribe 按 responseFormat 决定是否需要带时间戳的分段结果
+func (c *Client) transcribe(ctx context.Context, model string, file *multipart.FileHeader, prompt, language, hint string, temperature float64, responseFormat string) (io.ReadCloser, http.Header, error) {
+	if !strings.HasPrefix(strings.TrimPrefix(model, "models/"), "gemini") {
+		model = DefaultTranscriptionModel
+	}
+	part, err := audioPart(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	if language != "" {
+		prompt += " The audio is in language \"" + language + "\"."
+	}
+	if hint != "" {
+		prompt += " Context that may help with names and spelling: " + hint
+	}
+	timed := responseFormat == "verbose_json" || responseFormat == "srt" || responseFormat == "vtt"
+	config := &GenerationConfig{}
+	if temperature != 0 {
+		config.Temperature = &temperature
+	}
+	if timed {
+		prompt += segmentsPrompt
+		config.ResponseMimeType = "application/json"
+		config.ResponseSchema = segmentsSchema
+	}
+	resp, err := c.generateContent(ctx, model, &GenerateContentRequest{
+		Contents:         []Content{{Role: "user", Parts: []Part{{Text: prompt}, *part}}},
+		GenerationConfig: config,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	var text string
+	if len(resp.Candidates) > 0 {
+		for _, p := range resp.Candidates[0].Content.Parts {
+			if !p.Thought {
+				text += p.Text
+			}
+		}
+	}
+	text = strings.TrimSpace(text)
+
+	var newResp any = &v1.TranscriptionResponse{Text: text}
+	if timed {
+		var segments transcriptSegments
+		if err = sonic.UnmarshalString(text, &segments); err != nil {
+			return nil, nil, fmt.Errorf("unmarshal segments error: %w", err)
+		}
+		verbose := convertTranscriptSegments(&segments, language)
+		switch responseFormat {
+		case "srt":
+			return textResponse(FormatSRT(verbose.Segments))
+		case "vtt":
+			return textResponse(FormatVTT(verbose.Segments))
+		}
+		newResp = verbose
+	} else if responseFormat == "text" {
+		return textResponse(text)
+	}
+	respBytes, err := sonic.Marshal(newResp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(respBytes)), base.NewJsonHeader(), nil
+}
+
+func textResponse(text string) (io.ReadCloser, http.Header, error) {
+	header := http.Header{}
+	header.Set("Content-Type", "text/plain; charset=utf-8")
+	return io.NopCloser(strings.NewReader(text)), header, nil
+}
+
+func convertTranscriptSegments(segments *transcriptSegments, language string) *v1.TranscriptionVerboseResponse {
+	newResp := &v1.TranscriptionVerboseResponse{Language: segments.Language}
+	if newResp.Language == "" {
+		newResp.Language = language
+	}
+	texts := make([]string, 0, len(segments.Segments))
+	for i, segment := range segments.Segments {
+		text := strings.TrimSpace(segment.Text)
+		texts = append(texts, text)
+		newResp.Segments = append(newResp.Segments, v1.TranscriptionSegment{Id: i, Start: segment.Start, End: segment.End, Text: text})
+		newResp.Duration = max(newResp.Duration, segment.End)
+	}
+	newResp.Text = strings.Join(texts, " ")
+	return newResp
+}
+
+// formatTimestamp 格式化为 hh:mm:ss{sep}mmm, srt 使用逗号, vtt 使用点号
+func formatTimestamp(seconds float64, sep string) string {
+	ms := int64(seconds*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+func FormatSRT(segments []v1.TranscriptionSegment) string {
+	var sb strings.Builder
+	for i, segment := range segments {
+		fmt.Fprintf(&sb, "%d\n%s --> %s\n%s\n\n", i+1, formatTimestamp(segment.Start, ","), formatTimestamp(segment.End, ","), segment.Text)
+	}
+	return sb.String()
+}
+
+func FormatVTT(segments []v1.TranscriptionSegment) string {
+	var sb strings.Builder
+	sb.WriteString("WEBVTT\n\n")
+	for _, segment := range segments {
+		fmt.Fprintf(&sb, "%s --> %s\n%s\n\n", formatTimestamp(segment.Start, "."), formatTimestamp(segment.End, "."), segment.Text)
+	}
+	return sb.String()
+}
+
+func (c *Client) CreateTranscription(ctx context.Context, req *v1.TranscriptionRequest) (io.ReadCloser, http.Header, error) {
+	return c.transcribe(ctx, req.Model, req.File, transcribePrompt, req.Language, req.Prompt, req.Temperature, req.ResponseFormat)
+}
+
+func (c *Client) CreateTranslation(ctx context.Context, req *v1.TranslationRequest) (io.ReadCloser, http.Header, error) {
+	return c.transcribe(ctx, req.Model, req.File, translatePrompt, "", req.Prompt, req.Temperature, req.ResponseFormat)
+}
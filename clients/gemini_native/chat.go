@@ -0,0 +1,533 @@
+package gemini_native
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/pkg/rr"
+	"github.com/jiu-u/oai-adapter/tools"
+	"io"
+	"strings"
+	"time"
+)
+
+var toGeminiRoleMap = map[string]string{
+	"user":      "user",
+	"assistant": "model",
+	"tool":      "user",
+}
+
+// ReasoningBudget reasoning_effect 到 thinkingBudget 的映射
+var ReasoningBudget = map[string]int{
+	"low":    1024,
+	"medium": 8192,
+	"high":   24576,
+}
+
+// ConvertChatRequest 将 ChatCompletionRequest 转换为 generateContent 请求
+func ConvertChatRequest(req *v1.ChatCompletionRequest) (*GenerateContentRequest, error) {
+	newReq := &GenerateContentRequest{
+		GenerationConfig: &GenerationConfig{
+			StopSequences:    req.StopSequences(),
+			MaxOutputTokens:  req.MaxOutputTokens(),
+			TopP:             req.TopP,
+			Seed:             req.Seed,
+			PresencePenalty:  req.PresencePenalty,
+			FrequencyPenalty: req.FrequencyPenalty,
+			ResponseLogprobs: req.Logprobs,
+			Logprobs:         req.TopLogprobs,
+		},
+	}
+	if req.Temperature != 0 {
+		temperature := req.Temperature
+		newReq.GenerationConfig.Temperature = &temperature
+	}
+	if req.N > 1 {
+		newReq.GenerationConfig.CandidateCount = req.N
+	}
+	if budget, ok := ReasoningBudget[req.ReasoningEffect]; ok {
+		newReq.GenerationConfig.ThinkingConfig = &ThinkingConfig{ThinkingBudget: &budget}
+	}
+	if req.ResponseFormat != nil {
+		switch req.ResponseFormat.Type {
+		case "json_object":
+			newReq.GenerationConfig.ResponseMimeType = "application/json"
+		case "json_schema":
+			newReq.GenerationConfig.ResponseMimeType = "application/json"
+			if req.ResponseFormat.JsonSchema != nil && req.ResponseFormat.JsonSchema.Schema != nil {
+				newReq.GenerationConfig.ResponseSchema = CleanSchema(req.ResponseFormat.JsonSchema.Schema)
+			}
+		}
+	}
+
+	// functionResponse 需要函数名, 而 tool 消息只携带 tool_call_id
+	toolNames := make(map[string]string)
+	for _, msg := range req.Messages {
+		for _, toolCall := range msg.ToolCalls {
+			toolNames[toolCall.Id] = toolCall.Function.Name
+		}
+	}
+	var systemParts []Part
+	for i := range req.Messages {
+		msg := &req.Messages[i]
+		var parts []Part
+		var err error
+		switch msg.Role {
+		case "system", "developer":
+			parts, err = MessageToParts(msg)
+			if err != nil {
+				return nil, err
+			}
+			systemParts = append(systemParts, parts...)
+			continue
+		case "tool":
+			parts = []Part{toolResultPart(msg, toolNames[msg.ToolCallId])}
+		case "assistant":
+			parts, err = assistantToParts(msg)
+		default:
+			parts, err = MessageToParts(msg)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(parts) == 0 {
+			continue
+		}
+		role := toGeminiRoleMap[msg.Role]
+		if role == "" {
+			role = "user"
+		}
+		// 连续相同角色的消息合并, 并行函数调用的结果需要位于同一个 Content 中
+		if n := len(newReq.Contents); n > 0 && newReq.Contents[n-1].Role == role {
+			newReq.Contents[n-1].Parts = append(newReq.Contents[n-1].Parts, parts...)
+			continue
+		}
+		newReq.Contents = append(newReq.Contents, Content{Role: role, Parts: parts})
+	}
+	if len(systemParts) > 0 {
+		newReq.SystemInstruction = &Content{Parts: systemParts}
+	}
+
+	var declarations []FunctionDeclaration
+	for _, tool := range req.Tools {
+		if tool.Type != "" && tool.Type != "function" {
+			continue
+		}
+		declaration := FunctionDeclaration{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+		}
+		if len(tool.Function.Parameters) > 0 && string(tool.Function.Parameters) != "null" {
+			var parameters any
+			if err := json.Unmarshal(tool.Function.Parameters, &parameters); err != nil {
+				return nil, fmt.Errorf("unmarshal parameters error: %w", err)
+			}
+			declaration.Parameters = CleanSchema(parameters)
+		}
+		declarations = append(declarations, declaration)
+	}
+	if len(declarations) > 0 {
+		newReq.Tools = []Tool{{FunctionDeclarations: declarations}}
+		switch choiceType, name := req.ParseToolChoice(); choiceType {
+		case v1.ToolChoiceAuto:
+			newReq.ToolConfig = &ToolConfig{FunctionCallingConfig: &FunctionCallingConfig{Mode: "AUTO"}}
+		case v1.ToolChoiceNone:
+			newReq.ToolConfig = &ToolConfig{FunctionCallingConfig: &FunctionCallingConfig{Mode: "NONE"}}
+		case v1.ToolChoiceRequired:
+			newReq.ToolConfig = &ToolConfig{FunctionCallingConfig: &FunctionCallingConfig{Mode: "ANY"}}
+		case v1.ToolChoiceFunction:
+			newReq.ToolConfig = &ToolConfig{FunctionCallingConfig: &FunctionCallingConfig{
+				Mode:                 "ANY",
+				AllowedFunctionNames: []string{name},
+			}}
+		}
+	}
+	return newReq, nil
+}
+
+// MessageToParts 将消息内容转换为 Part, 支持文本、图片、音频与文件
+func MessageToParts(msg *v1.Message) ([]Part, error) {
+	if len(msg.Content) == 0 || string(msg.Content) == "null" {
+		return nil, nil
+	}
+	mediaContents, err := msg.ParseContent()
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]Part, 0, len(mediaContents))
+	for _, mediaContent := range mediaContents {
+		switch mediaContent.Type {
+		case v1.ContentTypeText:
+			if mediaContent.Text == "" {
+				continue
+			}
+			parts = append(parts, Part{Text: mediaContent.Text})
+		case v1.ContentTypeImageURL:
+			if mediaContent.ImageUrl == nil || mediaContent.ImageUrl.Url == "" {
+				return nil, errors.New("image_url is empty")
+			}
+			part, err := urlToPart(mediaContent.ImageUrl.Url)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, *part)
+		case v1.ContentTypeInputAudio:
+			if mediaContent.InputAudio == nil || mediaContent.InputAudio.Data == "" {
+				return nil, errors.New("audio_data is empty")
+			}
+			if mediaContent.InputAudio.Format == "" {
+				return nil, errors.New("audio_format is empty")
+			}
+			parts = append(parts, Part{InlineData: &Blob{
+				MimeType: "audio/" + mediaContent.InputAudio.Format,
+				Data:     mediaContent.InputAudio.Data,
+			}})
+		case v1.ContentTypeFile:
+			if mediaContent.File == nil {
+				return nil, errors.New("file is empty")
+			}
+			if fileData, ok := mediaContent.File.FileData.(string); ok && fileData != "" {
+				mimeType, data, ok := tools.ParseDataURL(fileData)
+				if !ok {
+					return nil, errors.New("file_data must be a base64 data url")
+				}
+				parts = append(parts, Part{InlineData: &Blob{MimeType: mimeType, Data: data}})
+				continue
+			}
+			if fileId, ok := mediaContent.File.FileId.(string); ok && fileId != "" {
+				parts = append(parts, Part{FileData: &FileData{FileUri: fileId}})
+				continue
+			}
+			return nil, errors.New("file_data and file_id are empty")
+		default:
+			return nil, fmt.Errorf("unsupported content type: %s", mediaContent.Type)
+		}
+	}
+	return parts, nil
+}
+
+func urlToPart(url string) (*Part, error) {
+	if mimeType, data, ok := tools.ParseDataURL(url); ok {
+		return &Part{InlineData: &Blob{MimeType: mimeType, Data: data}}, nil
+	}
+	f, err := tools.NewImageFileData(url, true)
+	if err != nil {
+		return nil, err
+	}
+	mimeType, data, ok := tools.ParseDataURL(f.URL)
+	if !ok {
+		return nil, fmt.Errorf("invalid image url: %s", url)
+	}
+	return &Part{InlineData: &Blob{MimeType: mimeType, Data: data}}, nil
+}
+
+func assistantToParts(msg *v1.Message) ([]Part, error) {
+	parts, err := MessageToParts(msg)
+	if err != nil {
+		return nil, err
+	}
+	for _, toolCall := range msg.ToolCalls {
+		args := json.RawMessage(toolCall.Function.Arguments)
+		if len(strings.TrimSpace(toolCall.Function.Arguments)) == 0 || !json.Valid(args) {
+			args = json.RawMessage("{}")
+		}
+		parts = append(parts, Part{FunctionCall: &FunctionCall{
+			Name: toolCall.Function.Name,
+			Args: args,
+		}})
+	}
+	return parts, nil
+}
+
+func toolResultPart(msg *v1.Message, name string) Part {
+	content := msg.StringContent()
+	// response 必须是对象, 非对象结果包装为 {"content": ...}
+	response := json.RawMessage(content)
+	if !strings.HasPrefix(strings.TrimSpace(content), "{") || !json.Valid(response) {
+		response, _ = json.Marshal(map[string]string{"content": content})
+	}
+	return Part{FunctionResponse: &FunctionResponse{Name: name, Response: response}}
+}
+
+// supportedSchemaKeys Gemini Schema 支持的字段, 其余 JSON Schema 字段会导致请求被拒绝
+var supportedSchemaKeys = map[string]bool{
+	"type": true, "format": true, "title": true, "description": true, "nullable": true,
+	"enum": true, "maxItems": true, "minItems": true, "properties": true, "required": true,
+	"minProperties": true, "maxProperties": true, "minLength": true, "maxLength": true,
+	"pattern": true, "example": true, "anyOf": true, "propertyOrdering": true, "default": true,
+	"items": true, "minimum": true, "maximum": true,
+}
+
+// CleanSchema 移除 Gemini 不支持的 JSON Schema 字段, 并将 ["string","null"] 形式的类型转换为 nullable
+func CleanSchema(schema any) any {
+	obj, ok := schema.(map[string]any)
+	if !ok {
+		return schema
+	}
+	cleaned := make(map[string]any, len(obj))
+	for key, value := range obj {
+		if !supportedSchemaKeys[key] {
+			continue
+		}
+		switch key {
+		case "type":
+			if types, ok := value.([]any); ok {
+				for _, t := range types {
+					if t == "null" {
+						cleaned["nullable"] = true
+					} else if _, exists := cleaned["type"]; !exists {
+						cleaned["type"] = t
+					}
+				}
+				continue
+			}
+			cleaned[key] = value
+		case "properties":
+			if props, ok := value.(map[string]any); ok {
+				newProps := make(map[string]any, len(props))
+				for name, prop := range props {
+					newProps[name] = CleanSchema(prop)
+				}
+				cleaned[key] = newProps
+				continue
+			}
+			cleaned[key] = value
+		case "items":
+			cleaned[key] = CleanSchema(value)
+		case "anyOf":
+			if items, ok := value.([]any); ok {
+				newItems := make([]any, 0, len(items))
+				for _, item := range items {
+					newItems = append(newItems, CleanSchema(item))
+				}
+				cleaned[key] = newItems
+				continue
+			}
+			cleaned[key] = value
+		default:
+			cleaned[key] = value
+		}
+	}
+	return cleaned
+}
+
+// ConvertFinishReason Gemini finishReason 转 OpenAI finish_reason
+func ConvertFinishReason(finishReason string, hasToolCalls bool) string {
+	switch finishReason {
+	case "":
+		return ""
+	case "STOP":
+		if hasToolCalls {
+			return "tool_calls"
+		}
+		return "stop"
+	case "MAX_TOKENS":
+		return "length"
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII", "IMAGE_SAFETY":
+		return "content_filter"
+	default:
+		if hasToolCalls {
+			return "tool_calls"
+		}
+		return "stop"
+	}
+}
+
+// ConvertUsage UsageMetadata 转 OpenAI Usage, 思考消耗的 token 计入 completion_tokens
+func ConvertUsage(usage *UsageMetadata) v1.Usage {
+	if usage == nil {
+		return v1.Usage{}
+	}
+	newUsage := v1.Usage{
+		PromptTokens:     usage.PromptTokenCount,
+		CompletionTokens: usage.CandidatesTokenCount + usage.ThoughtsTokenCount,
+		TotalTokens:      usage.TotalTokenCount,
+	}
+	if newUsage.TotalTokens == 0 {
+		newUsage.TotalTokens = newUsage.PromptTokens + newUsage.CompletionTokens
+	}
+	if usage.CachedContentTokenCount > 0 {
+		newUsage.PromptTokensDetails = &v1.PromptTokensDetails{CachedTokens: usage.CachedContentTokenCount}
+	}
+	if usage.ThoughtsTokenCount > 0 {
+		newUsage.CompletionTokensDetails = &v1.CompletionTokensDetails{ReasoningTokens: usage.ThoughtsTokenCount}
+	}
+	return newUsage
+}
+
+func newToolCallId() string {
+	return "call_" + rr.GenString(24)
+}
+
+func partsToMessage(parts []Part) (text string, toolCalls []v1.ToolCall) {
+	var texts []string
+	for _, part := range parts {
+		switch {
+		case part.Thought:
+			continue
+		case part.FunctionCall != nil:
+			args := string(part.FunctionCall.Args)
+			if args == "" {
+				args = "{}"
+			}
+			id := part.FunctionCall.ID
+			if id == "" {
+				id = newToolCallId()
+			}
+			toolCalls = append(toolCalls, v1.ToolCall{
+				Id:       id,
+				Type:     "function",
+				Function: v1.Function{Name: part.FunctionCall.Name, Arguments: args},
+			})
+		case part.Text != "":
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, ""), toolCalls
+}
+
+// ConvertChatResponse 将 generateContent 响应转换为 ChatCompletionResponse
+func ConvertChatResponse(resp *GenerateContentResponse, model string) *v1.ChatCompletionResponse {
+	if resp.ModelVersion != "" {
+		model = resp.ModelVersion
+	}
+	newResp := &v1.ChatCompletionResponse{
+		ID:      "chatcmpl-" + resp.ResponseId,
+		Model:   model,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Choices: make([]v1.Choice, 0, len(resp.Candidates)),
+		Usage:   ConvertUsage(resp.UsageMetadata),
+	}
+	if resp.ResponseId == "" {
+		newResp.ID = "chatcmpl-" + rr.GenString(24)
+	}
+	for _, candidate := range resp.Candidates {
+		text, toolCalls := partsToMessage(candidate.Content.Parts)
+		message := v1.Message{Role: "assistant", ToolCalls: toolCalls}
+		if text == "" && len(toolCalls) > 0 {
+			message.Content = json.RawMessage("null")
+		} else {
+			message.SetStringContent(text)
+		}
+		newResp.Choices = append(newResp.Choices, v1.Choice{
+			Index:        candidate.Index,
+			Message:      message,
+			FinishReason: ConvertFinishReason(candidate.FinishReason, len(toolCalls) > 0),
+		})
+	}
+	// 提示词被拦截时没有候选结果
+	if len(newResp.Choices) == 0 && resp.PromptFeedback != nil && resp.PromptFeedback.BlockReason != "" {
+		message := v1.Message{Role: "assistant"}
+		message.SetStringContent("")
+		newResp.Choices = append(newResp.Choices, v1.Choice{Message: message, FinishReason: "content_filter"})
+	}
+	return newResp
+}
+
+// streamConverter 维护 streamGenerateContent 到 chat.completion.chunk 的转换状态
+type streamConverter struct {
+	id           string
+	model        string
+	created      int64
+	started      map[int]bool
+	toolCount    map[int]int
+	usage        *UsageMetadata
+	includeUsage bool
+}
+
+func (s *streamConverter) chunk(choices []v1.ChoiceWithDelta) *v1.ChatCompletionStreamResponse {
+	// 上游未返回 responseId 时使用随机 id
+	if s.id == "" {
+		s.id = "chatcmpl-" + rr.GenString(24)
+	}
+	return &v1.ChatCompletionStreamResponse{
+		ID:      s.id,
+		Model:   s.model,
+		Object:  "chat.completion.chunk",
+		Created: s.created,
+		Choices: choices,
+	}
+}
+
+func (s *streamConverter) convert(resp *GenerateContentResponse) *v1.ChatCompletionStreamResponse {
+	if s.id == "" && resp.ResponseId != "" {
+		s.id = "chatcmpl-" + resp.ResponseId
+	}
+	if resp.ModelVersion != "" {
+		s.model = resp.ModelVersion
+	}
+	if resp.UsageMetadata != nil {
+		s.usage = resp.UsageMetadata
+	}
+	choices := make([]v1.ChoiceWithDelta, 0, len(resp.Candidates))
+	for _, candidate := range resp.Candidates {
+		text, toolCalls := partsToMessage(candidate.Content.Parts)
+		delta := v1.Delta{Role: "assistant", Content: text}
+		for i := range toolCalls {
+			index := s.toolCount[candidate.Index]
+			s.toolCount[candidate.Index]++
+			toolCalls[i].Index = &index
+		}
+		delta.ToolCalls = toolCalls
+		if text == "" && len(toolCalls) == 0 && candidate.FinishReason == "" && s.started[candidate.Index] {
+			continue
+		}
+		s.started[candidate.Index] = true
+		choices = append(choices, v1.ChoiceWithDelta{
+			Index:        candidate.Index,
+			Delta:        delta,
+			FinishReason: ConvertFinishReason(candidate.FinishReason, s.toolCount[candidate.Index] > 0),
+		})
+	}
+	if len(choices) == 0 {
+		return nil
+	}
+	return s.chunk(choices)
+}
+
+// ConvertStreamResponse 将 streamGenerateContent 的 SSE 流转换为 OpenAI chat.completion.chunk 流
+func ConvertStreamResponse(respBody io.ReadCloser, model string, includeUsage bool) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		defer respBody.Close()
+		converter := &streamConverter{
+			model:        model,
+			created:      time.Now().Unix(),
+			started:      make(map[int]bool),
+			toolCount:    make(map[int]int),
+			includeUsage: includeUsage,
+		}
+		err := base.ReadSSE(respBody, func(sse *base.SSEEvent) error {
+			if len(sse.Data) == 0 {
+				return nil
+			}
+			var resp GenerateContentResponse
+			if err := sonic.Unmarshal(sse.Data, &resp); err != nil {
+				return fmt.Errorf("unmarshal error: %w", err)
+			}
+			if chunk := converter.convert(&resp); chunk != nil {
+				return base.WriteSSEData(pw, chunk)
+			}
+			return nil
+		})
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if converter.includeUsage {
+			usageChunk := converter.chunk([]v1.ChoiceWithDelta{})
+			usageChunk.Usage = ConvertUsage(converter.usage)
+			if err = base.WriteSSEData(pw, usageChunk); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		_ = base.WriteSSEDone(pw)
+		pw.Close()
+	}()
+	return pr
+}
@@ -0,0 +1,166 @@
+package gemini_native
+
+import "encoding/json"
+
+// https://ai.google.dev/api/generate-content
+
+type (
+	GenerateContentRequest struct {
+		Contents          []Content         `json:"contents"`                    //必需。与模型的当前对话内容。
+		Tools             []Tool            `json:"tools,omitempty"`             //可选。Model 可能用于生成下一个响应的 Tools 列表。
+		ToolConfig        *ToolConfig       `json:"toolConfig,omitempty"`        //可选。请求中指定的任何 Tool 的工具配置
+		SafetySettings    []SafetySetting   `json:"safetySettings,omitempty"`    //可选。用于屏蔽不安全内容的唯一 SafetySetting 实例列表。
+		SystemInstruction *Content          `json:"systemInstruction,omitempty"` //可选。开发者设置的系统说明。
+		GenerationConfig  *GenerationConfig `json:"generationConfig,omitempty"`  //可选。模型生成和输出的配置选项。
+		CachedContent     string            `json:"cachedContent,omitempty"`     //可选。已缓存的内容的名称。
+	}
+	Content struct {
+		Role  string `json:"role,omitempty"`
+		Parts []Part `json:"parts"`
+	}
+	Part struct {
+		Text             string            `json:"text,omitempty"`
+		Thought          bool              `json:"thought,omitempty"`
+		ThoughtSignature string            `json:"thoughtSignature,omitempty"`
+		InlineData       *Blob             `json:"inlineData,omitempty"`
+		FileData         *FileData         `json:"fileData,omitempty"`
+		FunctionCall     *FunctionCall     `json:"functionCall,omitempty"`
+		FunctionResponse *FunctionResponse `json:"functionResponse,omitempty"`
+	}
+	Blob struct {
+		MimeType string `json:"mimeType"` // 来源数据的 IANA 标准 MIME 类型
+		Data     string `json:"data"`     // base64 编码的原始字节
+	}
+	FileData struct {
+		MimeType string `json:"mimeType,omitempty"`
+		FileUri  string `json:"fileUri"`
+	}
+	FunctionCall struct {
+		ID   string          `json:"id,omitempty"`
+		Name string          `json:"name"`
+		Args json.RawMessage `json:"args,omitempty"`
+	}
+	FunctionResponse struct {
+		ID       string          `json:"id,omitempty"`
+		Name     string          `json:"name"`
+		Response json.RawMessage `json:"response"` // 必须是 JSON 对象
+	}
+	Tool struct {
+		FunctionDeclarations []FunctionDeclaration `json:"functionDeclarations,omitempty"`
+		GoogleSearch         *struct{}             `json:"googleSearch,omitempty"`
+		CodeExecution        *struct{}             `json:"codeExecution,omitempty"`
+	}
+	FunctionDeclaration struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+		Parameters  any    `json:"parameters,omitempty"`
+	}
+	ToolConfig struct {
+		FunctionCallingConfig *FunctionCallingConfig `json:"functionCallingConfig,omitempty"`
+	}
+	FunctionCallingConfig struct {
+		Mode                 string   `json:"mode,omitempty"` // AUTO, ANY, NONE
+		AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+	}
+	SafetySetting struct {
+		Category  string `json:"category"`
+		Threshold string `json:"threshold"`
+	}
+	GenerationConfig struct {
+		StopSequences      []string        `json:"stopSequences,omitempty"`
+		ResponseMimeType   string          `json:"responseMimeType,omitempty"`
+		ResponseSchema     any             `json:"responseSchema,omitempty"`
+		ResponseModalities []string        `json:"responseModalities,omitempty"`
+		CandidateCount     int             `json:"candidateCount,omitempty"`
+		MaxOutputTokens    int             `json:"maxOutputTokens,omitempty"`
+		Temperature        *float64        `json:"temperature,omitempty"`
+		TopP               float64         `json:"topP,omitempty"`
+		TopK               int             `json:"topK,omitempty"`
+		Seed               int             `json:"seed,omitempty"`
+		PresencePenalty    float64         `json:"presencePenalty,omitempty"`
+		FrequencyPenalty   float64         `json:"frequencyPenalty,omitempty"`
+		ResponseLogprobs   bool            `json:"responseLogprobs,omitempty"`
+		Logprobs           int             `json:"logprobs,omitempty"`
+		ThinkingConfig     *ThinkingConfig `json:"thinkingConfig,omitempty"`
+		ImageConfig        *ImageConfig    `json:"imageConfig,omitempty"`
+		SpeechConfig       *SpeechConfig   `json:"speechConfig,omitempty"`
+	}
+	SpeechConfig struct {
+		VoiceConfig *VoiceConfig `json:"voiceConfig,omitempty"`
+	}
+	VoiceConfig struct {
+		PrebuiltVoiceConfig *PrebuiltVoiceConfig `json:"prebuiltVoiceConfig,omitempty"`
+	}
+	PrebuiltVoiceConfig struct {
+		VoiceName string `json:"voiceName"`
+	}
+	ImageConfig struct {
+		AspectRatio string `json:"aspectRatio,omitempty"`
+	}
+	ThinkingConfig struct {
+		IncludeThoughts bool `json:"includeThoughts,omitempty"`
+		ThinkingBudget  *int `json:"thinkingBudget,omitempty"`
+	}
+)
+
+type (
+	GenerateContentResponse struct {
+		Candidates     []Candidate     `json:"candidates"`
+		PromptFeedback *PromptFeedback `json:"promptFeedback,omitempty"`
+		UsageMetadata  *UsageMetadata  `json:"usageMetadata,omitempty"`
+		ModelVersion   string          `json:"modelVersion"`
+		ResponseId     string          `json:"responseId"`
+	}
+	Candidate struct {
+		Content      Content `json:"content"`
+		FinishReason string  `json:"finishReason,omitempty"`
+		Index        int     `json:"index"`
+	}
+	PromptFeedback struct {
+		BlockReason string `json:"blockReason,omitempty"`
+	}
+	// UsageMetadata 是关于生成请求的令牌使用情况的元数据。
+	UsageMetadata struct {
+		PromptTokenCount        int `json:"promptTokenCount"`
+		CachedContentTokenCount int `json:"cachedContentTokenCount"`
+		CandidatesTokenCount    int `json:"candidatesTokenCount"`
+		ThoughtsTokenCount      int `json:"thoughtsTokenCount"`
+		TotalTokenCount         int `json:"totalTokenCount"`
+	}
+)
+
+// embeddings
+type (
+	BatchEmbedContentsRequest struct {
+		Requests []EmbedContentRequest `json:"requests"`
+	}
+	EmbedContentRequest struct {
+		Model                string  `json:"model"`
+		Content              Content `json:"content"`
+		TaskType             string  `json:"taskType,omitempty"`
+		OutputDimensionality int     `json:"outputDimensionality,omitempty"`
+	}
+	BatchEmbedContentsResponse struct {
+		Embeddings []ContentEmbedding `json:"embeddings"`
+	}
+	ContentEmbedding struct {
+		Values []float64 `json:"values"`
+	}
+)
+
+// models
+type (
+	Model struct {
+		Name                       string   `json:"name"`
+		Version                    string   `json:"version"`
+		DisplayName                string   `json:"displayName"`
+		Description                string   `json:"description"`
+		InputTokenLimit            int      `json:"inputTokenLimit"`
+		OutputTokenLimit           int      `json:"outputTokenLimit"`
+		SupportedGenerationMethods []string `json:"supportedGenerationMethods"`
+	}
+	ModelList struct {
+		Models        []Model `json:"models"`
+		NextPageToken string  `json:"nextPageToken"`
+	}
+)
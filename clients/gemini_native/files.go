@@ -0,0 +1,220 @@
+package gemini_native
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/bytedance/sonic"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// https://ai.google.dev/gemini-api/docs/files
+
+var (
+	// DefaultInlineLimit 单个 inlineData 超过该字节数时改为通过 Files API 上传, 请求总大小上限为 20MB
+	DefaultInlineLimit = 8 << 20
+	// FilePollInterval 等待文件处理完成的轮询间隔
+	FilePollInterval = 2 * time.Second
+	// fileExpireMargin 文件保存 48 小时, 临近过期的缓存不再使用
+	fileExpireMargin = time.Hour
+)
+
+const (
+	FileStateProcessing = "PROCESSING"
+	FileStateActive     = "ACTIVE"
+	FileStateFailed     = "FAILED"
+)
+
+type (
+	File struct {
+		Name           string     `json:"name"`
+		DisplayName    string     `json:"displayName,omitempty"`
+		MimeType       string     `json:"mimeType"`
+		SizeBytes      string     `json:"sizeBytes,omitempty"`
+		Sha256Hash     string     `json:"sha256Hash,omitempty"`
+		Uri            string     `json:"uri"`
+		State          string     `json:"state"`
+		ExpirationTime time.Time  `json:"expirationTime"`
+		Error          *FileError `json:"error,omitempty"`
+	}
+	FileError struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	fileResponse struct {
+		File File `json:"file"`
+	}
+)
+
+// SetInlineLimit 设置 inlineData 转为 Files API 上传的阈值, 小于等于 0 时不上传
+func (c *Client) SetInlineLimit(limit int) {
+	c.inlineLimit = limit
+}
+
+// uploadURL {root}/upload/{version}/files
+func (c *Client) uploadURL() string {
+	return strings.TrimSuffix(c.EndPoint, "/"+c.version) + "/upload/" + c.version + "/files"
+}
+
+// UploadFile 使用 resumable 协议上传文件, 返回的文件可能仍在处理中
+func (c *Client) UploadFile(ctx context.Context, data []byte, mimeType, displayName string) (*File, error) {
+	reqBytes, err := sonic.Marshal(map[string]any{"file": map[string]string{"display_name": displayName}})
+	if err != nil {
+		return nil, fmt.Errorf("marshal error: %w", err)
+	}
+	header := c.generateHeader()
+	header.Set("X-Goog-Upload-Protocol", "resumable")
+	header.Set("X-Goog-Upload-Command", "start")
+	header.Set("X-Goog-Upload-Header-Content-Length", strconv.Itoa(len(data)))
+	header.Set("X-Goog-Upload-Header-Content-Type", mimeType)
+	respBody, respHeader, err := base.RelayWithCheck(ctx, http.MethodPost, c.uploadURL(), io.NopCloser(bytes.NewReader(reqBytes)), header, c.HTTPClient())
+	if err != nil {
+		return nil, fmt.Errorf("start upload error: %w", err)
+	}
+	respBody.Close()
+	uploadUrl := respHeader.Get("X-Goog-Upload-URL")
+	if uploadUrl == "" {
+		return nil, fmt.Errorf("start upload error: missing upload url")
+	}
+
+	// 上传需要明确的 Content-Length, 不能使用 chunked 编码
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadUrl, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("new request error: %w", err)
+	}
+	request.Header.Set("X-Goog-Upload-Offset", "0")
+	request.Header.Set("X-Goog-Upload-Command", "upload, finalize")
+	response, err := base.RelayRequest(request, c.HTTPClient())
+	if err != nil {
+		return nil, fmt.Errorf("upload error: %w", err)
+	}
+	defer response.Body.Close()
+	respBytes, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read all error: %w", err)
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, fmt.Errorf("upload error: %w", &base.StatusError{StatusCode: response.StatusCode, Body: respBytes})
+	}
+	var resp fileResponse
+	if err = sonic.Unmarshal(respBytes, &resp); err != nil {
+		return nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	return &resp.File, nil
+}
+
+// GetFile name 形如 files/abc-123
+func (c *Client) GetFile(ctx context.Context, name string) (*File, error) {
+	respBody, _, err := base.RelayWithCheck(ctx, http.MethodGet, c.EndPoint+"/"+name, nil, c.generateHeader(), c.HTTPClient())
+	if err != nil {
+		return nil, err
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return nil, fmt.Errorf("read all error: %w", err)
+	}
+	var file File
+	if err = sonic.Unmarshal(respBytes, &file); err != nil {
+		return nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	return &file, nil
+}
+
+// WaitFileActive 轮询直到文件状态为 ACTIVE, 视频等文件需要处理后才能使用
+func (c *Client) WaitFileActive(ctx context.Context, file *File) (*File, error) {
+	ticker := time.NewTicker(FilePollInterval)
+	defer ticker.Stop()
+	for {
+		switch file.State {
+		case FileStateActive, "":
+			return file, nil
+		case FileStateFailed:
+			if file.Error != nil {
+				return nil, fmt.Errorf("file %s processing failed: %s", file.Name, file.Error.Message)
+			}
+			return nil, fmt.Errorf("file %s processing failed", file.Name)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+		var err error
+		if file, err = c.GetFile(ctx, file.Name); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// uploadCached 按内容哈希缓存已上传的文件, 相同内容不重复上传
+func (c *Client) uploadCached(ctx context.Context, data []byte, mimeType string) (*File, error) {
+	sum := sha256.Sum256(data)
+	key := hex.EncodeToString(sum[:])
+	c.filesMu.Lock()
+	file, ok := c.files[key]
+	c.filesMu.Unlock()
+	if ok && time.Until(file.ExpirationTime) > fileExpireMargin {
+		return file, nil
+	}
+	file, err := c.UploadFile(ctx, data, mimeType, key)
+	if err != nil {
+		return nil, err
+	}
+	if file, err = c.WaitFileActive(ctx, file); err != nil {
+		return nil, err
+	}
+	if file.ExpirationTime.IsZero() {
+		file.ExpirationTime = time.Now().Add(48 * time.Hour)
+	}
+	c.filesMu.Lock()
+	for k, f := range c.files {
+		if time.Until(f.ExpirationTime) <= fileExpireMargin {
+			delete(c.files, k)
+		}
+	}
+	c.files[key] = file
+	c.filesMu.Unlock()
+	return file, nil
+}
+
+// uploadLargeInlineData 将超过阈值的 inlineData 上传后替换为 fileData
+func (c *Client) uploadLargeInlineData(ctx context.Context, req *GenerateContentRequest) error {
+	if c.inlineLimit <= 0 {
+		return nil
+	}
+	contents := make([]*Content, 0, len(req.Contents)+1)
+	if req.SystemInstruction != nil {
+		contents = append(contents, req.SystemInstruction)
+	}
+	for i := range req.Contents {
+		contents = append(contents, &req.Contents[i])
+	}
+	for _, content := range contents {
+		for i := range content.Parts {
+			part := &content.Parts[i]
+			if part.InlineData == nil || base64.StdEncoding.DecodedLen(len(part.InlineData.Data)) <= c.inlineLimit {
+				continue
+			}
+			data, err := base64.StdEncoding.DecodeString(part.InlineData.Data)
+			if err != nil {
+				return fmt.Errorf("decode inline data error: %w", err)
+			}
+			file, err := c.uploadCached(ctx, data, part.InlineData.MimeType)
+			if err != nil {
+				return fmt.Errorf("upload file error: %w", err)
+			}
+			part.FileData = &FileData{MimeType: part.InlineData.MimeType, FileUri: file.Uri}
+			part.InlineData = nil
+		}
+	}
+	return nil
+}
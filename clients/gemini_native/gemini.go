@@ -1,25 +1,240 @@
 package gemini_native
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
 	"github.com/jiu-u/oai-adapter/clients/base"
 	"github.com/jiu-u/oai-adapter/constant"
+	"io"
+	"net/http"
+	"net/url"
 	"strings"
+	"sync"
+	"time"
 )
 
 var GeminiVersion = "v1beta"
 
 type Client struct {
 	*base.Client
+	version     string
+	inlineLimit int
+	filesMu     sync.Mutex
+	files       map[string]*File // sha256 -> 已上传文件
 }
 
 func NewClient(endPoint, apiKey string) *Client {
+	return NewClientWithVersion(endPoint, apiKey, GeminiVersion)
+}
+
+func NewClientWithVersion(endPoint, apiKey, version string) *Client {
 	if endPoint == "" {
 		endPoint = constant.GeminiDefaultURL
 	}
 	endPoint = strings.TrimSpace(endPoint)
 	endPoint = strings.TrimRight(endPoint, "/")
-	endPoint = endPoint + "/" + GeminiVersion
+	endPoint = endPoint + "/" + version
 	return &Client{
-		Client: base.NewClient(endPoint, apiKey),
+		Client:      base.NewClient(endPoint, apiKey),
+		version:     version,
+		inlineLimit: DefaultInlineLimit,
+		files:       make(map[string]*File),
+	}
+}
+
+func (c *Client) generateHeader() http.Header {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("x-goog-api-key", c.APIKey)
+	return header
+}
+
+func (c *Client) RelayRequest(ctx context.Context, method, targetPath string, body io.ReadCloser, header http.Header) (io.ReadCloser, http.Header, error) {
+	header.Del("Authorization")
+	header.Set("x-goog-api-key", c.APIKey)
+	parsedURL, _ := url.Parse(c.EndPoint)
+	targetUrl := parsedURL.Scheme + "://" + parsedURL.Host + targetPath
+	return base.Relay(ctx, method, targetUrl, body, header, c.HTTPClient())
+}
+
+// ModelPath models/{model}, 兼容已带 models/ 前缀的模型名
+func ModelPath(model string) string {
+	return "models/" + strings.TrimPrefix(model, "models/")
+}
+
+// PostJson 向 {EndPoint}/{path} 发送 JSON 请求
+func (c *Client) PostJson(ctx context.Context, path string, req any) (io.ReadCloser, http.Header, error) {
+	reqBytes, err := sonic.Marshal(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	body := io.NopCloser(bytes.NewReader(reqBytes))
+	return base.RelayWithCheck(ctx, http.MethodPost, c.EndPoint+"/"+path, body, c.generateHeader(), c.HTTPClient())
+}
+
+// GenerateContent 调用 generateContent, stream 为 true 时调用 streamGenerateContent?alt=sse
+// 超过 inlineLimit 的 inlineData 会先通过 Files API 上传
+func (c *Client) GenerateContent(ctx context.Context, model string, req *GenerateContentRequest, stream bool) (io.ReadCloser, http.Header, error) {
+	if err := c.uploadLargeInlineData(ctx, req); err != nil {
+		return nil, nil, err
+	}
+	path := ModelPath(model) + ":generateContent"
+	if stream {
+		path = ModelPath(model) + ":streamGenerateContent?alt=sse"
+	}
+	return c.PostJson(ctx, path, req)
+}
+
+func (c *Client) CreateChatCompletions(ctx context.Context, req *v1.ChatCompletionRequest) (io.ReadCloser, http.Header, error) {
+	newReq, err := ConvertChatRequest(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	respBody, _, err := c.GenerateContent(ctx, req.Model, newReq, req.Stream)
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.Stream {
+		includeUsage := req.StreamOptions != nil && req.StreamOptions.IncludeUsage
+		return ConvertStreamResponse(respBody, req.Model, includeUsage), base.NewStreamHeader(), nil
+	}
+	return ConvertNoStreamResponse(respBody, req.Model)
+}
+
+// ConvertNoStreamResponse 读取 generateContent 响应并转换为 ChatCompletionResponse JSON
+func ConvertNoStreamResponse(respBody io.ReadCloser, model string) (io.ReadCloser, http.Header, error) {
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read all error: %w", err)
+	}
+	var resp GenerateContentResponse
+	if err = sonic.Unmarshal(respBytes, &resp); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	newRespBytes, err := sonic.Marshal(ConvertChatResponse(&resp, model))
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(newRespBytes)), base.NewJsonHeader(), nil
+}
+
+func (c *Client) CreateEmbeddings(ctx context.Context, req *v1.EmbeddingsRequest) (io.ReadCloser, http.Header, error) {
+	inputs, err := req.InputStrings()
+	if err != nil {
+		return nil, nil, err
+	}
+	newReq := &BatchEmbedContentsRequest{Requests: make([]EmbedContentRequest, 0, len(inputs))}
+	for _, input := range inputs {
+		newReq.Requests = append(newReq.Requests, EmbedContentRequest{
+			Model:                ModelPath(req.Model),
+			Content:              Content{Parts: []Part{{Text: input}}},
+			OutputDimensionality: req.Dimensions,
+		})
+	}
+	respBody, _, err := c.PostJson(ctx, ModelPath(req.Model)+":batchEmbedContents", newReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read all error: %w", err)
+	}
+	var resp BatchEmbedContentsResponse
+	if err = sonic.Unmarshal(respBytes, &resp); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	newResp := v1.EmbeddingsResponse{
+		Object: "list",
+		Model:  req.Model,
+		Data:   make([]v1.EmbeddingsData, 0, len(resp.Embeddings)),
 	}
+	for i, embedding := range resp.Embeddings {
+		values := make([]any, 0, len(embedding.Values))
+		for _, value := range embedding.Values {
+			values = append(values, value)
+		}
+		newResp.Data = append(newResp.Data, v1.EmbeddingsData{
+			Object:    "embedding",
+			Index:     i,
+			Embedding: values,
+		})
+	}
+	newRespBytes, err := sonic.Marshal(newResp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(newRespBytes)), base.NewJsonHeader(), nil
+}
+
+func (c *Client) Models(ctx context.Context) (*v1.ModelResponse, error) {
+	resp := &v1.ModelResponse{Object: "list", Data: make([]v1.Model, 0)}
+	pageToken := ""
+	now := time.Now().Unix()
+	for {
+		targetUrl := c.EndPoint + "/models?pageSize=1000"
+		if pageToken != "" {
+			targetUrl += "&pageToken=" + url.QueryEscape(pageToken)
+		}
+		body, _, err := base.RelayWithCheck(ctx, http.MethodGet, targetUrl, nil, c.generateHeader(), c.HTTPClient())
+		if err != nil {
+			return nil, err
+		}
+		dataBytes, err := io.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read all error: %w", err)
+		}
+		var list ModelList
+		if err = sonic.Unmarshal(dataBytes, &list); err != nil {
+			return nil, fmt.Errorf("unmarshal error: %w", err)
+		}
+		for _, model := range list.Models {
+			resp.Data = append(resp.Data, v1.Model{
+				ID:      strings.TrimPrefix(model.Name, "models/"),
+				Object:  "model",
+				Created: now,
+				OwnedBy: "google",
+			})
+		}
+		if list.NextPageToken == "" {
+			break
+		}
+		pageToken = list.NextPageToken
+	}
+	return resp, nil
+}
+
+func (c *Client) CreateResponses(ctx context.Context, req *v1.ResponsesRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateCompletions(ctx context.Context, req *v1.CompletionsRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateRerank(ctx context.Context, req *v1.RerankRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImageEdit(ctx context.Context, req *v1.ImageEditRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImageVariation(ctx context.Context, req *v1.ImageVariationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateVideoSubmit(ctx context.Context, req *v1.VideoRequest) (*v1.VideoResponse, error) {
+	_, _, err := base.NoImplementMethod(ctx, req)
+	return nil, err
+}
+
+func (c *Client) GetVideoStatus(ctx context.Context, externalID string) (bool, any, error) {
+	_, _, err := base.NoImplementMethod(ctx, externalID)
+	return false, nil, err
 }
@@ -0,0 +1,214 @@
+package gemini_native
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// https://ai.google.dev/gemini-api/docs/imagen
+
+var (
+	// ImagenAspectRatios Imagen 支持的宽高比
+	ImagenAspectRatios = []string{"1:1", "3:4", "4:3", "9:16", "16:9"}
+	// ImageAspectRatios Gemini 图像模型支持的宽高比
+	ImageAspectRatios = []string{"1:1", "2:3", "3:2", "3:4", "4:3", "4:5", "5:4", "9:16", "16:9", "21:9"}
+	// ImagenMaxSampleCount Imagen 单次请求最多生成的图片数
+	ImagenMaxSampleCount = 4
+)
+
+type (
+	PredictRequest struct {
+		Instances  []ImagenInstance `json:"instances"`
+		Parameters ImagenParameters `json:"parameters"`
+	}
+	ImagenInstance struct {
+		Prompt string `json:"prompt"`
+	}
+	ImagenParameters struct {
+		SampleCount      int            `json:"sampleCount,omitempty"`
+		AspectRatio      string         `json:"aspectRatio,omitempty"`
+		PersonGeneration string         `json:"personGeneration,omitempty"`
+		OutputOptions    *OutputOptions `json:"outputOptions,omitempty"`
+	}
+	OutputOptions struct {
+		MimeType           string `json:"mimeType,omitempty"`
+		CompressionQuality int    `json:"compressionQuality,omitempty"`
+	}
+	PredictResponse struct {
+		Predictions []ImagenPrediction `json:"predictions"`
+	}
+	ImagenPrediction struct {
+		BytesBase64Encoded string `json:"bytesBase64Encoded"`
+		MimeType           string `json:"mimeType"`
+	}
+)
+
+// IsImagenModel imagen 系列走 predict, 其余图像模型走 generateContent
+func IsImagenModel(model string) bool {
+	return strings.HasPrefix(strings.TrimPrefix(model, "models/"), "imagen")
+}
+
+// ConvertAspectRatio 将 OpenAI size(如 1024x1536) 转换为最接近的宽高比, 已是宽高比时直接使用
+func ConvertAspectRatio(size string, ratios []string) string {
+	for _, ratio := range ratios {
+		if size == ratio {
+			return ratio
+		}
+	}
+	w, h, ok := strings.Cut(size, "x")
+	if !ok {
+		return ""
+	}
+	width, err1 := strconv.ParseFloat(w, 64)
+	height, err2 := strconv.ParseFloat(h, 64)
+	if err1 != nil || err2 != nil || width <= 0 || height <= 0 {
+		return ""
+	}
+	target := math.Log(width / height)
+	best, bestDiff := "", math.MaxFloat64
+	for _, ratio := range ratios {
+		rw, rh, _ := strings.Cut(ratio, ":")
+		a, _ := strconv.ParseFloat(rw, 64)
+		b, _ := strconv.ParseFloat(rh, 64)
+		if diff := math.Abs(math.Log(a/b) - target); diff < bestDiff {
+			best, bestDiff = ratio, diff
+		}
+	}
+	return best
+}
+
+// ConvertOutputFormat png、jpeg 以外的格式(如 webp) 不支持, 交由上游使用默认格式
+func ConvertOutputFormat(format string) string {
+	switch format {
+	case "png":
+		return "image/png"
+	case "jpeg", "jpg":
+		return "image/jpeg"
+	}
+	return ""
+}
+
+func ConvertImagenRequest(req *v1.ImageGenerateRequest, n int) *PredictRequest {
+	newReq := &PredictRequest{
+		Instances: []ImagenInstance{{Prompt: req.Prompt}},
+		Parameters: ImagenParameters{
+			SampleCount: n,
+			AspectRatio: ConvertAspectRatio(req.Size, ImagenAspectRatios),
+		},
+	}
+	if mimeType := ConvertOutputFormat(req.OutputFormat); mimeType != "" {
+		newReq.Parameters.OutputOptions = &OutputOptions{MimeType: mimeType}
+		if mimeType == "image/jpeg" {
+			newReq.Parameters.OutputOptions.CompressionQuality = req.OutputCompression
+		}
+	}
+	return newReq
+}
+
+// Predict 调用 Imagen 的 models/{model}:predict
+func (c *Client) Predict(ctx context.Context, model string, req *PredictRequest) (*PredictResponse, error) {
+	respBody, _, err := c.PostJson(ctx, ModelPath(model)+":predict", req)
+	if err != nil {
+		return nil, err
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return nil, fmt.Errorf("read all error: %w", err)
+	}
+	var resp PredictResponse
+	if err = sonic.Unmarshal(respBytes, &resp); err != nil {
+		return nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	return &resp, nil
+}
+
+func (c *Client) createImagen(ctx context.Context, req *v1.ImageGenerateRequest) ([]v1.ImageGenData, error) {
+	data := make([]v1.ImageGenData, 0, max(req.N, 1))
+	for remain := max(req.N, 1); remain > 0; remain -= ImagenMaxSampleCount {
+		resp, err := c.Predict(ctx, req.Model, ConvertImagenRequest(req, min(remain, ImagenMaxSampleCount)))
+		if err != nil {
+			return nil, err
+		}
+		for _, prediction := range resp.Predictions {
+			data = append(data, v1.ImageGenData{B64JSON: prediction.BytesBase64Encoded})
+		}
+	}
+	return data, nil
+}
+
+// createGeminiImage Gemini 图像模型不支持 candidateCount, 按 N 重复请求
+func (c *Client) createGeminiImage(ctx context.Context, req *v1.ImageGenerateRequest) ([]v1.ImageGenData, error) {
+	newReq := &GenerateContentRequest{
+		Contents: []Content{{Role: "user", Parts: []Part{{Text: req.Prompt}}}},
+		GenerationConfig: &GenerationConfig{
+			ResponseModalities: []string{"TEXT", "IMAGE"},
+		},
+	}
+	if ratio := ConvertAspectRatio(req.Size, ImageAspectRatios); ratio != "" {
+		newReq.GenerationConfig.ImageConfig = &ImageConfig{AspectRatio: ratio}
+	}
+	data := make([]v1.ImageGenData, 0, max(req.N, 1))
+	for i := 0; i < max(req.N, 1); i++ {
+		respBody, _, err := c.GenerateContent(ctx, req.Model, newReq, false)
+		if err != nil {
+			return nil, err
+		}
+		respBytes, err := io.ReadAll(respBody)
+		respBody.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read all error: %w", err)
+		}
+		var resp GenerateContentResponse
+		if err = sonic.Unmarshal(respBytes, &resp); err != nil {
+			return nil, fmt.Errorf("unmarshal error: %w", err)
+		}
+		for _, candidate := range resp.Candidates {
+			var text string
+			var images []string
+			for _, part := range candidate.Content.Parts {
+				if part.InlineData != nil && strings.HasPrefix(part.InlineData.MimeType, "image/") {
+					images = append(images, part.InlineData.Data)
+				} else if !part.Thought {
+					text += part.Text
+				}
+			}
+			for _, image := range images {
+				data = append(data, v1.ImageGenData{B64JSON: image, RevisedPrompt: text})
+			}
+		}
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no image in response")
+	}
+	return data, nil
+}
+
+// CreateImage imagen 模型调用 predict, 其余模型通过 generateContent 请求 IMAGE 输出, 结果均以 b64_json 返回
+func (c *Client) CreateImage(ctx context.Context, req *v1.ImageGenerateRequest) (io.ReadCloser, http.Header, error) {
+	var data []v1.ImageGenData
+	var err error
+	if IsImagenModel(req.Model) {
+		data, err = c.createImagen(ctx, req)
+	} else {
+		data, err = c.createGeminiImage(ctx, req)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	respBytes, err := sonic.Marshal(v1.ImageGenerateResponse{Created: time.Now().Unix(), Data: data})
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(respBytes)), base.NewJsonHeader(), nil
+}
@@ -2,9 +2,14 @@ package gemini_oai
 
 import (
 	"context"
+	"encoding/base64"
 	v1 "github.com/jiu-u/oai-adapter/api/v1"
 	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/clients/gemini_native"
 	"github.com/jiu-u/oai-adapter/constant"
+	"github.com/jiu-u/oai-adapter/tools"
+	"io"
+	"net/http"
 	"strings"
 )
 
@@ -12,6 +17,10 @@ var GeminiVersion = "v1beta"
 
 type Client struct {
 	*base.Client
+	// native OpenAI 兼容接口不提供图像与音频接口, 通过原生接口实现
+	native *gemini_native.Client
+	// 含超过该字节数的内联媒体时, chat 改走原生接口以便通过 Files API 上传
+	inlineLimit int
 }
 
 func NewClient(endPoint, apiKey string) *Client {
@@ -20,9 +29,10 @@ func NewClient(endPoint, apiKey string) *Client {
 	}
 	endPoint = strings.TrimSpace(endPoint)
 	endPoint = strings.TrimRight(endPoint, "/")
-	endPoint = endPoint + "/" + GeminiVersion + "/openai"
 	return &Client{
-		Client: base.NewClient(endPoint, apiKey),
+		Client:      base.NewClient(endPoint+"/"+GeminiVersion+"/openai", apiKey),
+		native:      gemini_native.NewClientWithVersion(endPoint, apiKey, GeminiVersion),
+		inlineLimit: gemini_native.DefaultInlineLimit,
 	}
 }
 
@@ -32,12 +42,84 @@ func NewClientWithVersion(endPoint, apiKey, version string) *Client {
 	}
 	endPoint = strings.TrimSpace(endPoint)
 	endPoint = strings.TrimRight(endPoint, "/")
-	endPoint = endPoint + "/" + version + "/openai"
 	return &Client{
-		Client: base.NewClient(endPoint, apiKey),
+		Client:      base.NewClient(endPoint+"/"+version+"/openai", apiKey),
+		native:      gemini_native.NewClientWithVersion(endPoint, apiKey, version),
+		inlineLimit: gemini_native.DefaultInlineLimit,
 	}
 }
 
+// SetClient 同时设置原生接口使用的 http.Client
+func (c *Client) SetClient(client *http.Client) {
+	c.Client.SetClient(client)
+	c.native.SetClient(client)
+}
+
+// SetInlineLimit 设置内联媒体转为 Files API 上传的阈值, 小于等于 0 时始终走 OpenAI 兼容接口
+func (c *Client) SetInlineLimit(limit int) {
+	c.inlineLimit = limit
+	c.native.SetInlineLimit(limit)
+}
+
+// CreateChatCompletions OpenAI 兼容接口只接受内联媒体, 含大文件时改走原生接口
+func (c *Client) CreateChatCompletions(ctx context.Context, req *v1.ChatCompletionRequest) (io.ReadCloser, http.Header, error) {
+	if c.hasLargeInlineData(req) {
+		return c.native.CreateChatCompletions(ctx, req)
+	}
+	return c.Client.CreateChatCompletions(ctx, req)
+}
+
+func (c *Client) hasLargeInlineData(req *v1.ChatCompletionRequest) bool {
+	if c.inlineLimit <= 0 {
+		return false
+	}
+	for i := range req.Messages {
+		mediaContents, err := req.Messages[i].ParseContent()
+		if err != nil {
+			continue
+		}
+		for _, mediaContent := range mediaContents {
+			var data string
+			switch mediaContent.Type {
+			case v1.ContentTypeImageURL:
+				if mediaContent.ImageUrl != nil {
+					_, data, _ = tools.ParseDataURL(mediaContent.ImageUrl.Url)
+				}
+			case v1.ContentTypeInputAudio:
+				if mediaContent.InputAudio != nil {
+					data = mediaContent.InputAudio.Data
+				}
+			case v1.ContentTypeFile:
+				if mediaContent.File != nil {
+					if fileData, ok := mediaContent.File.FileData.(string); ok {
+						_, data, _ = tools.ParseDataURL(fileData)
+					}
+				}
+			}
+			if base64.StdEncoding.DecodedLen(len(data)) > c.inlineLimit {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *Client) CreateImage(ctx context.Context, req *v1.ImageGenerateRequest) (io.ReadCloser, http.Header, error) {
+	return c.native.CreateImage(ctx, req)
+}
+
+func (c *Client) CreateSpeech(ctx context.Context, req *v1.AudioSpeechRequest) (io.ReadCloser, http.Header, error) {
+	return c.native.CreateSpeech(ctx, req)
+}
+
+func (c *Client) CreateTranscription(ctx context.Context, req *v1.TranscriptionRequest) (io.ReadCloser, http.Header, error) {
+	return c.native.CreateTranscription(ctx, req)
+}
+
+func (c *Client) CreateTranslation(ctx context.Context, req *v1.TranslationRequest) (io.ReadCloser, http.Header, error) {
+	return c.native.CreateTranslation(ctx, req)
+}
+
 func (c *Client) CreateVideoSubmit(ctx context.Context, req *v1.VideoRequest) (*v1.VideoResponse, error) {
 	_, _, err := base.NoImplementMethod(ctx, req)
 	return nil, err
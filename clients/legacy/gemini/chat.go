@@ -57,6 +57,7 @@ func message2Parts(msg *api.Message) ([]Part, error) {
 			if mediaContent.ImageUrl.Url == "" {
 				return nil, errors.New("image_url is empty")
 			}
+			// legacy 客户端始终内联, 大文件请使用 gemini_oai / gemini_native, 超过阈值时会通过 Files API 上传
 			f, err := tools.NewImageFileData(mediaContent.ImageUrl.Url, true)
 			if err != nil {
 				return nil, err
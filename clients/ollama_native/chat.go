@@ -0,0 +1,389 @@
+package ollama_native
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/pkg/rr"
+	"github.com/jiu-u/oai-adapter/tools"
+	"io"
+	"strings"
+	"time"
+)
+
+// ConvertChatRequest 将 ChatCompletionRequest 转换为 /api/chat 请求, defaults 为客户端级别的默认运行参数
+func ConvertChatRequest(req *v1.ChatCompletionRequest, defaults *Options) (*ChatRequest, error) {
+	options, err := mergeOptions(req, defaults)
+	if err != nil {
+		return nil, err
+	}
+	keepAlive, err := requestKeepAlive(req)
+	if err != nil {
+		return nil, err
+	}
+	newReq := &ChatRequest{
+		Model:     req.Model,
+		Stream:    req.Stream,
+		Messages:  make([]Message, 0, len(req.Messages)),
+		Options:   options,
+		KeepAlive: keepAlive,
+	}
+	// tool 消息只携带 tool_call_id, Ollama 需要函数名
+	toolNames := make(map[string]string)
+	for _, msg := range req.Messages {
+		for _, toolCall := range msg.ToolCalls {
+			toolNames[toolCall.Id] = toolCall.Function.Name
+		}
+	}
+	for i := range req.Messages {
+		msg := &req.Messages[i]
+		role := msg.Role
+		if role == "developer" {
+			role = "system"
+		}
+		newMsg := Message{Role: role}
+		switch msg.Role {
+		case "tool":
+			newMsg.Content = msg.StringContent()
+			newMsg.ToolName = toolNames[msg.ToolCallId]
+		default:
+			content, images, err := convertContent(msg)
+			if err != nil {
+				return nil, err
+			}
+			newMsg.Content = content
+			newMsg.Images = images
+		}
+		for _, toolCall := range msg.ToolCalls {
+			args := json.RawMessage(toolCall.Function.Arguments)
+			if len(strings.TrimSpace(toolCall.Function.Arguments)) == 0 || !json.Valid(args) {
+				args = json.RawMessage("{}")
+			}
+			newMsg.ToolCalls = append(newMsg.ToolCalls, ToolCall{Function: ToolCallFunction{
+				Name:      toolCall.Function.Name,
+				Arguments: args,
+			}})
+		}
+		newReq.Messages = append(newReq.Messages, newMsg)
+	}
+
+	// Ollama 不支持 tool_choice, none 时直接不传工具
+	if choiceType, _ := req.ParseToolChoice(); choiceType != v1.ToolChoiceNone {
+		for _, tool := range req.Tools {
+			if tool.Type != "" && tool.Type != "function" {
+				continue
+			}
+			newReq.Tools = append(newReq.Tools, Tool{
+				Type: "function",
+				Function: ToolFunction{
+					Name:        tool.Function.Name,
+					Description: tool.Function.Description,
+					Parameters:  tool.Function.Parameters,
+				},
+			})
+		}
+	}
+
+	if req.ResponseFormat != nil {
+		switch req.ResponseFormat.Type {
+		case "json_object":
+			newReq.Format = json.RawMessage(`"json"`)
+		case "json_schema":
+			newReq.Format = json.RawMessage(`"json"`)
+			if req.ResponseFormat.JsonSchema != nil && req.ResponseFormat.JsonSchema.Schema != nil {
+				schema, err := sonic.Marshal(req.ResponseFormat.JsonSchema.Schema)
+				if err != nil {
+					return nil, fmt.Errorf("marshal error: %w", err)
+				}
+				newReq.Format = schema
+			}
+		}
+	}
+	return newReq, nil
+}
+
+// requestExtra 请求中 Ollama 特有的字段, 可写在 extra_body 中或直接写在请求体顶层, extra_body 优先
+func requestExtra(req *v1.ChatCompletionRequest) map[string]any {
+	extra := make(map[string]any, len(req.ExtraBody)+len(req.UnknownFields))
+	for k, v := range req.UnknownFields {
+		extra[k] = v
+	}
+	for k, v := range req.ExtraBody {
+		extra[k] = v
+	}
+	return extra
+}
+
+// requestKeepAlive 读取请求中的 keep_alive, 数字按秒处理
+func requestKeepAlive(req *v1.ChatCompletionRequest) (string, error) {
+	value, ok := requestExtra(req)["keep_alive"]
+	if !ok {
+		return "", nil
+	}
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("marshal error: %w", err)
+	}
+	var keepAlive any
+	if err = json.Unmarshal(valueBytes, &keepAlive); err != nil {
+		return "", fmt.Errorf("unmarshal error: %w", err)
+	}
+	switch keepAlive := keepAlive.(type) {
+	case string:
+		return keepAlive, nil
+	case float64:
+		return fmt.Sprintf("%gs", keepAlive), nil
+	}
+	return "", fmt.Errorf("invalid keep_alive: %s", valueBytes)
+}
+
+// mergeOptions 以客户端默认参数为基础, 依次用请求中的 Ollama 参数(如 num_ctx、top_k 或 options 对象)与标准字段覆盖
+func mergeOptions(req *v1.ChatCompletionRequest, defaults *Options) (*Options, error) {
+	options := &Options{}
+	if defaults != nil {
+		*options = *defaults
+	}
+	extra := requestExtra(req)
+	if value, ok := extra["options"]; ok {
+		if err := applyOptions(options, value); err != nil {
+			return nil, err
+		}
+		delete(extra, "options")
+	}
+	// 未声明的字段在解析时忽略
+	if err := applyOptions(options, extra); err != nil {
+		return nil, err
+	}
+	if req.Temperature != 0 {
+		temperature := req.Temperature
+		options.Temperature = &temperature
+	}
+	if req.TopP != 0 {
+		options.TopP = req.TopP
+	}
+	if maxTokens := req.MaxOutputTokens(); maxTokens > 0 {
+		options.NumPredict = maxTokens
+	}
+	if stop := req.StopSequences(); len(stop) > 0 {
+		options.Stop = stop
+	}
+	if req.Seed != 0 {
+		options.Seed = req.Seed
+	}
+	if req.PresencePenalty != 0 {
+		options.PresencePenalty = req.PresencePenalty
+	}
+	if req.FrequencyPenalty != 0 {
+		options.FrequencyPenalty = req.FrequencyPenalty
+	}
+	return options, nil
+}
+
+// applyOptions 将 value 中出现的字段覆盖到 options
+func applyOptions(options *Options, value any) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal error: %w", err)
+	}
+	if err = json.Unmarshal(valueBytes, options); err != nil {
+		return fmt.Errorf("invalid ollama options: %w", err)
+	}
+	return nil
+}
+
+// convertContent 拆分消息中的文本与图片, 图片转换为不带 data: 前缀的 base64
+func convertContent(msg *v1.Message) (string, []string, error) {
+	if len(msg.Content) == 0 || string(msg.Content) == "null" {
+		return "", nil, nil
+	}
+	mediaContents, err := msg.ParseContent()
+	if err != nil {
+		return "", nil, err
+	}
+	var texts, images []string
+	for _, mediaContent := range mediaContents {
+		switch mediaContent.Type {
+		case v1.ContentTypeText:
+			texts = append(texts, mediaContent.Text)
+		case v1.ContentTypeImageURL:
+			if mediaContent.ImageUrl == nil || mediaContent.ImageUrl.Url == "" {
+				return "", nil, errors.New("image_url is empty")
+			}
+			image, err := urlToBase64(mediaContent.ImageUrl.Url)
+			if err != nil {
+				return "", nil, err
+			}
+			images = append(images, image)
+		default:
+			return "", nil, fmt.Errorf("unsupported content type: %s", mediaContent.Type)
+		}
+	}
+	return strings.Join(texts, "\n"), images, nil
+}
+
+func urlToBase64(url string) (string, error) {
+	if _, data, ok := tools.ParseDataURL(url); ok {
+		return data, nil
+	}
+	f, err := tools.NewImageFileData(url, true)
+	if err != nil {
+		return "", err
+	}
+	_, data, ok := tools.ParseDataURL(f.URL)
+	if !ok {
+		return "", fmt.Errorf("invalid image url: %s", url)
+	}
+	return data, nil
+}
+
+// ConvertFinishReason 将 done_reason 转换为 OpenAI finish_reason
+func ConvertFinishReason(doneReason string, hasToolCalls bool) string {
+	if hasToolCalls {
+		return "tool_calls"
+	}
+	switch doneReason {
+	case "length":
+		return "length"
+	case "":
+		return ""
+	default:
+		return "stop"
+	}
+}
+
+func convertUsage(resp *ChatResponse) v1.Usage {
+	return v1.Usage{
+		PromptTokens:     resp.PromptEvalCount,
+		CompletionTokens: resp.EvalCount,
+		TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+	}
+}
+
+func convertToolCalls(toolCalls []ToolCall) []v1.ToolCall {
+	if len(toolCalls) == 0 {
+		return nil
+	}
+	result := make([]v1.ToolCall, 0, len(toolCalls))
+	for _, toolCall := range toolCalls {
+		args := string(toolCall.Function.Arguments)
+		if args == "" || args == "null" {
+			args = "{}"
+		}
+		result = append(result, v1.ToolCall{
+			Id:       "call_" + rr.GenString(24),
+			Type:     "function",
+			Function: v1.Function{Name: toolCall.Function.Name, Arguments: args},
+		})
+	}
+	return result
+}
+
+// ConvertChatResponse 将 /api/chat 响应转换为 ChatCompletionResponse
+func ConvertChatResponse(resp *ChatResponse) *v1.ChatCompletionResponse {
+	toolCalls := convertToolCalls(resp.Message.ToolCalls)
+	message := v1.Message{Role: "assistant", ToolCalls: toolCalls}
+	if resp.Message.Content == "" && len(toolCalls) > 0 {
+		message.Content = json.RawMessage("null")
+	} else {
+		message.SetStringContent(resp.Message.Content)
+	}
+	created := resp.CreatedAt.Unix()
+	if resp.CreatedAt.IsZero() {
+		created = time.Now().Unix()
+	}
+	return &v1.ChatCompletionResponse{
+		ID:      "chatcmpl-" + rr.GenString(24),
+		Model:   resp.Model,
+		Object:  "chat.completion",
+		Created: created,
+		Choices: []v1.Choice{{
+			Message:      message,
+			FinishReason: ConvertFinishReason(resp.DoneReason, len(toolCalls) > 0),
+		}},
+		Usage: convertUsage(resp),
+	}
+}
+
+// ConvertStreamResponse 将 /api/chat 的 NDJSON 流转换为 OpenAI chat.completion.chunk 流
+func ConvertStreamResponse(respBody io.ReadCloser, model string, includeUsage bool) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		defer respBody.Close()
+		err := convertStream(respBody, pw, model, includeUsage)
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		_ = base.WriteSSEDone(pw)
+		pw.Close()
+	}()
+	return pr
+}
+
+func convertStream(r io.Reader, w io.Writer, model string, includeUsage bool) error {
+	id := "chatcmpl-" + rr.GenString(24)
+	created := time.Now().Unix()
+	toolCount := 0
+	newChunk := func(choices []v1.ChoiceWithDelta) *v1.ChatCompletionStreamResponse {
+		return &v1.ChatCompletionStreamResponse{
+			ID:      id,
+			Model:   model,
+			Object:  "chat.completion.chunk",
+			Created: created,
+			Choices: choices,
+		}
+	}
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("read stream error: %w", err)
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			var resp ChatResponse
+			if unmarshalErr := sonic.Unmarshal(line, &resp); unmarshalErr != nil {
+				return fmt.Errorf("unmarshal error: %w", unmarshalErr)
+			}
+			if resp.Error != "" {
+				return errors.New(resp.Error)
+			}
+			if resp.Model != "" {
+				model = resp.Model
+			}
+			toolCalls := convertToolCalls(resp.Message.ToolCalls)
+			for i := range toolCalls {
+				index := toolCount
+				toolCount++
+				toolCalls[i].Index = &index
+			}
+			delta := v1.Delta{Role: "assistant", Content: resp.Message.Content, ToolCalls: toolCalls}
+			finishReason := ""
+			if resp.Done {
+				finishReason = ConvertFinishReason(resp.DoneReason, toolCount > 0)
+			}
+			if delta.Content != "" || len(toolCalls) > 0 || finishReason != "" {
+				choices := []v1.ChoiceWithDelta{{Delta: delta, FinishReason: finishReason}}
+				if writeErr := base.WriteSSEData(w, newChunk(choices)); writeErr != nil {
+					return writeErr
+				}
+			}
+			if resp.Done {
+				if includeUsage {
+					usageChunk := newChunk([]v1.ChoiceWithDelta{})
+					usageChunk.Usage = convertUsage(&resp)
+					return base.WriteSSEData(w, usageChunk)
+				}
+				return nil
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package ollama_native
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// https://github.com/ollama/ollama/blob/main/docs/api.md
+
+type (
+	ChatRequest struct {
+		Model     string          `json:"model"`
+		Messages  []Message       `json:"messages"`
+		Tools     []Tool          `json:"tools,omitempty"`
+		Format    json.RawMessage `json:"format,omitempty"` // "json" 或 JSON schema
+		Options   *Options        `json:"options,omitempty"`
+		Stream    bool            `json:"stream"`
+		KeepAlive string          `json:"keep_alive,omitempty"`
+	}
+	Message struct {
+		Role      string     `json:"role"`
+		Content   string     `json:"content"`
+		Thinking  string     `json:"thinking,omitempty"`
+		Images    []string   `json:"images,omitempty"` // base64, 不带 data: 前缀
+		ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+		ToolName  string     `json:"tool_name,omitempty"`
+	}
+	ToolCall struct {
+		Function ToolCallFunction `json:"function"`
+	}
+	ToolCallFunction struct {
+		Index     int             `json:"index,omitempty"`
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"` // 对象而不是字符串
+	}
+	Tool struct {
+		Type     string       `json:"type"`
+		Function ToolFunction `json:"function"`
+	}
+	ToolFunction struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description,omitempty"`
+		Parameters  json.RawMessage `json:"parameters,omitempty"`
+	}
+	// Options 模型运行参数, 其中 num_ctx、top_k 等参数在 /v1 兼容层中无法设置
+	Options struct {
+		NumCtx           int      `json:"num_ctx,omitempty"`
+		NumPredict       int      `json:"num_predict,omitempty"`
+		Temperature      *float64 `json:"temperature,omitempty"`
+		TopK             int      `json:"top_k,omitempty"`
+		TopP             float64  `json:"top_p,omitempty"`
+		MinP             float64  `json:"min_p,omitempty"`
+		Seed             int      `json:"seed,omitempty"`
+		Stop             []string `json:"stop,omitempty"`
+		RepeatPenalty    float64  `json:"repeat_penalty,omitempty"`
+		PresencePenalty  float64  `json:"presence_penalty,omitempty"`
+		FrequencyPenalty float64  `json:"frequency_penalty,omitempty"`
+		NumGpu           int      `json:"num_gpu,omitempty"`
+	}
+	ChatResponse struct {
+		Model           string    `json:"model"`
+		CreatedAt       time.Time `json:"created_at"`
+		Message         Message   `json:"message"`
+		Done            bool      `json:"done"`
+		DoneReason      string    `json:"done_reason,omitempty"`
+		TotalDuration   int64     `json:"total_duration,omitempty"`
+		PromptEvalCount int       `json:"prompt_eval_count,omitempty"`
+		EvalCount       int       `json:"eval_count,omitempty"`
+		Error           string    `json:"error,omitempty"`
+	}
+)
+
+type (
+	EmbedRequest struct {
+		Model      string   `json:"model"`
+		Input      []string `json:"input"`
+		Truncate   *bool    `json:"truncate,omitempty"`
+		Dimensions int      `json:"dimensions,omitempty"`
+		Options    *Options `json:"options,omitempty"`
+		KeepAlive  string   `json:"keep_alive,omitempty"`
+	}
+	EmbedResponse struct {
+		Model           string      `json:"model"`
+		Embeddings      [][]float64 `json:"embeddings"`
+		PromptEvalCount int         `json:"prompt_eval_count,omitempty"`
+	}
+)
+
+type (
+	TagsResponse struct {
+		Models []ModelInfo `json:"models"`
+	}
+	ModelInfo struct {
+		Name       string    `json:"name"`
+		Model      string    `json:"model"`
+		ModifiedAt time.Time `json:"modified_at"`
+		Size       int64     `json:"size"`
+		Digest     string    `json:"digest"`
+	}
+)
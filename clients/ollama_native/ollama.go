@@ -0,0 +1,228 @@
+package ollama_native
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/constant"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type Client struct {
+	*base.Client
+	baseUrl   string
+	Options   *Options // 每个请求的默认运行参数, 如 num_ctx、top_k
+	KeepAlive string   // 模型在内存中的保留时间, 如 "5m"、"-1"
+}
+
+func NewClient(endPoint, apiKey string) *Client {
+	return NewClientWithOptions(endPoint, apiKey, nil, "")
+}
+
+// NewClientWithOptions 创建客户端并指定 /v1 兼容层无法设置的 Ollama 参数
+func NewClientWithOptions(endPoint, apiKey string, options *Options, keepAlive string) *Client {
+	if endPoint == "" {
+		endPoint = constant.OllamaDefaultURL
+	}
+	endPoint = strings.TrimSpace(endPoint)
+	endPoint = strings.TrimRight(endPoint, "/")
+	return &Client{
+		Client:    base.NewClient(endPoint+"/api", apiKey),
+		baseUrl:   endPoint,
+		Options:   options,
+		KeepAlive: keepAlive,
+	}
+}
+
+func (c *Client) generateHeader() http.Header {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	// 本地部署通常不需要鉴权, 反向代理场景下使用 Bearer
+	if c.APIKey != "" {
+		header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	return header
+}
+
+func (c *Client) RelayRequest(ctx context.Context, method, targetPath string, body io.ReadCloser, header http.Header) (io.ReadCloser, http.Header, error) {
+	header.Del("Authorization")
+	if c.APIKey != "" {
+		header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	return base.Relay(ctx, method, c.baseUrl+targetPath, body, header, c.HTTPClient())
+}
+
+func (c *Client) postJson(ctx context.Context, path string, req any) (io.ReadCloser, http.Header, error) {
+	reqBytes, err := sonic.Marshal(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	body := io.NopCloser(bytes.NewReader(reqBytes))
+	return base.RelayWithCheck(ctx, http.MethodPost, c.EndPoint+path, body, c.generateHeader(), c.HTTPClient())
+}
+
+func (c *Client) CreateChatCompletions(ctx context.Context, req *v1.ChatCompletionRequest) (io.ReadCloser, http.Header, error) {
+	newReq, err := ConvertChatRequest(req, c.Options)
+	if err != nil {
+		return nil, nil, err
+	}
+	if newReq.KeepAlive == "" {
+		newReq.KeepAlive = c.KeepAlive
+	}
+	respBody, _, err := c.postJson(ctx, "/chat", newReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.Stream {
+		includeUsage := req.StreamOptions != nil && req.StreamOptions.IncludeUsage
+		return ConvertStreamResponse(respBody, req.Model, includeUsage), base.NewStreamHeader(), nil
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read all error: %w", err)
+	}
+	var resp ChatResponse
+	if err = sonic.Unmarshal(respBytes, &resp); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	newRespBytes, err := sonic.Marshal(ConvertChatResponse(&resp))
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(newRespBytes)), base.NewJsonHeader(), nil
+}
+
+func (c *Client) CreateEmbeddings(ctx context.Context, req *v1.EmbeddingsRequest) (io.ReadCloser, http.Header, error) {
+	inputs, err := req.InputStrings()
+	if err != nil {
+		return nil, nil, err
+	}
+	newReq := &EmbedRequest{
+		Model:      req.Model,
+		Input:      inputs,
+		Dimensions: req.Dimensions,
+		Options:    c.Options,
+		KeepAlive:  c.KeepAlive,
+	}
+	respBody, _, err := c.postJson(ctx, "/embed", newReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read all error: %w", err)
+	}
+	var resp EmbedResponse
+	if err = sonic.Unmarshal(respBytes, &resp); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	newResp := v1.EmbeddingsResponse{
+		Object: "list",
+		Model:  req.Model,
+		Data:   make([]v1.EmbeddingsData, 0, len(resp.Embeddings)),
+		Usage: v1.Usage{
+			PromptTokens: resp.PromptEvalCount,
+			TotalTokens:  resp.PromptEvalCount,
+		},
+	}
+	for i, embedding := range resp.Embeddings {
+		values := make([]any, 0, len(embedding))
+		for _, value := range embedding {
+			values = append(values, value)
+		}
+		newResp.Data = append(newResp.Data, v1.EmbeddingsData{
+			Object:    "embedding",
+			Index:     i,
+			Embedding: values,
+		})
+	}
+	newRespBytes, err := sonic.Marshal(newResp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(newRespBytes)), base.NewJsonHeader(), nil
+}
+
+func (c *Client) Models(ctx context.Context) (*v1.ModelResponse, error) {
+	body, _, err := base.RelayWithCheck(ctx, http.MethodGet, c.EndPoint+"/tags", nil, c.generateHeader(), c.HTTPClient())
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	dataBytes, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("read all error: %w", err)
+	}
+	var tags TagsResponse
+	if err = sonic.Unmarshal(dataBytes, &tags); err != nil {
+		return nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	resp := &v1.ModelResponse{Object: "list", Data: make([]v1.Model, 0, len(tags.Models))}
+	for _, model := range tags.Models {
+		created := model.ModifiedAt.Unix()
+		if model.ModifiedAt.IsZero() {
+			created = time.Now().Unix()
+		}
+		resp.Data = append(resp.Data, v1.Model{
+			ID:      model.Name,
+			Object:  "model",
+			Created: created,
+			OwnedBy: "library",
+		})
+	}
+	return resp, nil
+}
+
+func (c *Client) CreateResponses(ctx context.Context, req *v1.ResponsesRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateCompletions(ctx context.Context, req *v1.CompletionsRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateRerank(ctx context.Context, req *v1.RerankRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImage(ctx context.Context, req *v1.ImageGenerateRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImageEdit(ctx context.Context, req *v1.ImageEditRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImageVariation(ctx context.Context, req *v1.ImageVariationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateSpeech(ctx context.Context, req *v1.AudioSpeechRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateTranslation(ctx context.Context, req *v1.TranslationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateTranscription(ctx context.Context, req *v1.TranscriptionRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateVideoSubmit(ctx context.Context, req *v1.VideoRequest) (*v1.VideoResponse, error) {
+	_, _, err := base.NoImplementMethod(ctx, req)
+	return nil, err
+}
+
+func (c *Client) GetVideoStatus(ctx context.Context, externalID string) (bool, any, error) {
+	_, _, err := base.NoImplementMethod(ctx, externalID)
+	return false, nil, err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	v1 "github.com/jiu-u/oai-adapter/api/v1"
 	base2 "github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/clients/emulate"
 	"github.com/jiu-u/oai-adapter/constant"
 	"io"
 	"net/http"
@@ -12,6 +13,7 @@ import (
 
 type Client struct {
 	*base2.Client
+	responsesModels map[string]bool
 }
 
 func NewClient(endPoint, apiKey string) *Client {
@@ -27,6 +29,21 @@ func NewClient(endPoint, apiKey string) *Client {
 	}
 }
 
+// SetResponsesModels 指定通过 /v1/responses 调用的 chat 模型, "*" 表示全部模型
+func (c *Client) SetResponsesModels(models ...string) {
+	c.responsesModels = make(map[string]bool, len(models))
+	for _, model := range models {
+		c.responsesModels[model] = true
+	}
+}
+
+func (c *Client) CreateChatCompletions(ctx context.Context, req *v1.ChatCompletionRequest) (io.ReadCloser, http.Header, error) {
+	if c.responsesModels[req.Model] || c.responsesModels["*"] {
+		return emulate.ChatCompletions(ctx, c.Client, req)
+	}
+	return c.Client.CreateChatCompletions(ctx, req)
+}
+
 func (c *Client) CreateRerank(ctx context.Context, req *v1.RerankRequest) (io.ReadCloser, http.Header, error) {
 	return base2.NoImplementMethod(ctx, req)
 }
@@ -0,0 +1,80 @@
+package preset
+
+import "github.com/jiu-u/oai-adapter/constant"
+
+// 内置预设, 可被 LoadFile 中的同名预设覆盖
+var builtins = []*Preset{
+	{
+		Name:       "DeepSeek",
+		BaseURL:    constant.DeepSeekDefaultURL,
+		Prefix:     "/v1",
+		Paths:      map[string]string{"completions": "/beta/completions"},
+		Operations: []string{"chat", "completions", "models"},
+	},
+	{
+		Name:       "XAI",
+		BaseURL:    constant.XAIDefaultURL,
+		Prefix:     "/v1",
+		Operations: []string{"chat", "completions", "image", "models"},
+	},
+	{
+		Name:    "SiliconFlow",
+		BaseURL: constant.SiliconFlowDefaultURL,
+		Prefix:  "/v1",
+		Operations: []string{"chat", "embedding", "rerank", "image", "audio", "transcriptions", "models",
+			"videoSubmit", "videoStatus"},
+	},
+	{
+		Name:       "Groq",
+		BaseURL:    "https://api.groq.com",
+		Prefix:     "/openai/v1",
+		Operations: []string{"chat", "models", "audio", "transcriptions", "translate"},
+	},
+	{
+		Name:       "Together",
+		BaseURL:    "https://api.together.xyz",
+		Prefix:     "/v1",
+		Operations: []string{"chat", "completions", "embedding", "rerank", "image", "audio", "models"},
+	},
+	{
+		Name:       "Fireworks",
+		BaseURL:    "https://api.fireworks.ai",
+		Prefix:     "/inference/v1",
+		Operations: []string{"chat", "completions", "embedding", "models"},
+	},
+	{
+		Name:       "OpenRouter",
+		BaseURL:    "https://openrouter.ai",
+		Prefix:     "/api/v1",
+		Operations: []string{"chat", "completions", "models"},
+		Headers: map[string]string{
+			"HTTP-Referer": "https://github.com/jiu-u/oai-adapter",
+			"X-Title":      "oai-adapter",
+		},
+	},
+	{
+		Name:       "Mistral",
+		BaseURL:    "https://api.mistral.ai",
+		Prefix:     "/v1",
+		Operations: []string{"chat", "embedding", "models"},
+	},
+	{
+		Name:       "Moonshot",
+		BaseURL:    "https://api.moonshot.cn",
+		Prefix:     "/v1",
+		Operations: []string{"chat", "models"},
+	},
+	{
+		Name:       "Perplexity",
+		BaseURL:    "https://api.perplexity.ai",
+		Operations: []string{"chat"},
+	},
+}
+
+func init() {
+	for _, p := range builtins {
+		if err := Register(p); err != nil {
+			panic(err)
+		}
+	}
+}
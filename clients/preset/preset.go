@@ -0,0 +1,171 @@
+package preset
+
+import (
+	"fmt"
+	"github.com/bytedance/sonic"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/constant/mode"
+	"gopkg.in/yaml.v3"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Preset 描述一个 OpenAI 兼容服务商, 新增服务商只需增加一份配置
+type Preset struct {
+	Name    string `json:"name" yaml:"name"`
+	BaseURL string `json:"base_url" yaml:"base_url"` // 默认地址, 不含版本前缀, eg: https://api.groq.com
+	Prefix  string `json:"prefix" yaml:"prefix"`     // 版本前缀, eg: /openai/v1
+	// Paths 覆盖单个接口的路径, key 为 mode 名称, 值为拼接在 BaseURL 之后的完整路径(不自动加 Prefix)
+	// eg: DeepSeek {"completions": "/beta/completions"}
+	Paths map[string]string `json:"paths,omitempty" yaml:"paths,omitempty"`
+	// Operations 支持的接口, 为空表示全部支持
+	Operations []string          `json:"operations,omitempty" yaml:"operations,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+}
+
+func (p *Preset) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("preset name is empty")
+	}
+	for _, op := range p.Operations {
+		if !isKnownMode(mode.Mode(op)) {
+			return fmt.Errorf("preset %s: unknown operation %s", p.Name, op)
+		}
+	}
+	for op := range p.Paths {
+		if !isKnownMode(mode.Mode(op)) {
+			return fmt.Errorf("preset %s: unknown path operation %s", p.Name, op)
+		}
+	}
+	return nil
+}
+
+var knownModes = []mode.Mode{
+	mode.Chat, mode.Completions, mode.Embedding, mode.Models, mode.Audio, mode.Image,
+	mode.Translate, mode.Transcriptions, mode.ImageEdit, mode.ImageVariation,
+	mode.Responses, mode.Rerank, mode.VideoSubmit, mode.VideoStatus,
+}
+
+func isKnownMode(m mode.Mode) bool {
+	for _, known := range knownModes {
+		if known == m {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]*Preset)
+)
+
+func key(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Register 注册或覆盖同名预设, 名称不区分大小写
+func Register(p *Preset) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	registry[key(p.Name)] = p
+	return nil
+}
+
+func Get(name string) (*Preset, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := registry[key(name)]
+	return p, ok
+}
+
+// Names 返回已注册的预设名称
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for _, p := range registry {
+		names = append(names, p.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse 解析预设列表, 支持 JSON 与 YAML, 既可以是数组也可以是 {"presets": [...]}
+func Parse(data []byte, isYAML bool) ([]*Preset, error) {
+	unmarshal := sonic.Unmarshal
+	if isYAML {
+		unmarshal = yaml.Unmarshal
+	}
+	var presets []*Preset
+	if err := unmarshal(data, &presets); err == nil {
+		return presets, nil
+	}
+	var file struct {
+		Presets []*Preset `json:"presets" yaml:"presets"`
+	}
+	if err := unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	return file.Presets, nil
+}
+
+// LoadFile 从 .json/.yaml/.yml 文件加载并注册预设
+func LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read file error: %w", err)
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	presets, err := Parse(data, ext == ".yaml" || ext == ".yml")
+	if err != nil {
+		return err
+	}
+	for _, p := range presets {
+		if err = Register(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewClient 根据预设构造 base.Client, endPoint 为空时使用预设的默认地址
+func NewClient(p *Preset, endPoint, apiKey string) *base.Client {
+	if endPoint == "" {
+		endPoint = p.BaseURL
+	}
+	endPoint = strings.TrimSpace(endPoint)
+	endPoint = strings.TrimRight(endPoint, "/")
+	prefix := strings.TrimRight(p.Prefix, "/")
+	client := base.NewClient(endPoint+prefix, apiKey)
+	if len(p.Paths) > 0 {
+		client.SetURLBuilder(func(m mode.Mode, model, path string) string {
+			if override, ok := p.Paths[string(m)]; ok {
+				return endPoint + override
+			}
+			return endPoint + prefix + path
+		})
+	}
+	if len(p.Operations) > 0 {
+		modes := make([]mode.Mode, 0, len(p.Operations))
+		for _, op := range p.Operations {
+			modes = append(modes, mode.Mode(op))
+		}
+		client.SetSupportedModes(modes...)
+	}
+	if len(p.Headers) > 0 {
+		header := http.Header{}
+		for k, v := range p.Headers {
+			header.Set(k, v)
+		}
+		client.SetHeaders(header)
+	}
+	return client
+}
@@ -0,0 +1,42 @@
+package replicate
+
+// https://replicate.com/docs/reference/http
+
+type (
+	PredictionRequest struct {
+		Version string         `json:"version"`
+		Input   map[string]any `json:"input"`
+	}
+	Prediction struct {
+		ID      string `json:"id"`
+		Model   string `json:"model"`
+		Version string `json:"version"`
+		Status  string `json:"status"` // starting, processing, succeeded, failed, canceled
+		Output  any    `json:"output"` // 通常为 url 或 url 数组
+		Error   any    `json:"error"`
+	}
+	ModelInfo struct {
+		Owner         string `json:"owner"`
+		Name          string `json:"name"`
+		LatestVersion *struct {
+			ID string `json:"id"`
+		} `json:"latest_version"`
+	}
+)
+
+// OutputURLs 从 output 中提取结果链接
+func (p *Prediction) OutputURLs() []string {
+	switch output := p.Output.(type) {
+	case string:
+		return []string{output}
+	case []any:
+		urls := make([]string, 0, len(output))
+		for _, item := range output {
+			if s, ok := item.(string); ok {
+				urls = append(urls, s)
+			}
+		}
+		return urls
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package replicate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/constant"
+	"github.com/jiu-u/oai-adapter/pkg/task"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WaitInterval 同步等待图片预测时检查任务结果的间隔
+var WaitInterval = 500 * time.Millisecond
+
+type Client struct {
+	*base.Client
+	versions sync.Map // owner/name -> 最新版本 id
+}
+
+func NewClient(endPoint, apiKey string) *Client {
+	if endPoint == "" {
+		endPoint = constant.ReplicateDefaultURL
+	}
+	endPoint = strings.TrimSpace(endPoint)
+	endPoint = strings.TrimRight(endPoint, "/")
+	return &Client{
+		Client: base.NewClient(endPoint+"/v1", apiKey),
+	}
+}
+
+func (c *Client) generateHeader() http.Header {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("Authorization", "Bearer "+c.APIKey)
+	return header
+}
+
+func (c *Client) doJson(ctx context.Context, method, path string, req, resp any) error {
+	var body io.ReadCloser
+	if req != nil {
+		reqBytes, err := sonic.Marshal(req)
+		if err != nil {
+			return fmt.Errorf("marshal error: %w", err)
+		}
+		body = io.NopCloser(bytes.NewReader(reqBytes))
+	}
+	respBody, _, err := base.RelayWithCheck(ctx, method, c.EndPoint+path, body, c.generateHeader(), c.HTTPClient())
+	if err != nil {
+		return err
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return fmt.Errorf("read all error: %w", err)
+	}
+	if err = sonic.Unmarshal(respBytes, resp); err != nil {
+		return fmt.Errorf("unmarshal error: %w", err)
+	}
+	return nil
+}
+
+// ResolveVersion 模型名为 owner/name:version 时直接使用版本号, 否则查询并缓存最新版本
+func (c *Client) ResolveVersion(ctx context.Context, model string) (string, error) {
+	name, version, ok := strings.Cut(model, ":")
+	if ok && version != "" {
+		return version, nil
+	}
+	if cached, ok := c.versions.Load(name); ok {
+		return cached.(string), nil
+	}
+	owner, modelName, ok := strings.Cut(name, "/")
+	if !ok {
+		return "", fmt.Errorf("invalid replicate model %s, want owner/name[:version]", model)
+	}
+	var info ModelInfo
+	if err := c.doJson(ctx, http.MethodGet, "/models/"+url.PathEscape(owner)+"/"+url.PathEscape(modelName), nil, &info); err != nil {
+		return "", fmt.Errorf("get model error: %w", err)
+	}
+	if info.LatestVersion == nil || info.LatestVersion.ID == "" {
+		return "", fmt.Errorf("model %s has no version", name)
+	}
+	c.versions.Store(name, info.LatestVersion.ID)
+	return info.LatestVersion.ID, nil
+}
+
+func (c *Client) CreatePrediction(ctx context.Context, model string, input map[string]any) (*Prediction, error) {
+	version, err := c.ResolveVersion(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+	var prediction Prediction
+	if err = c.doJson(ctx, http.MethodPost, "/predictions", &PredictionRequest{Version: version, Input: input}, &prediction); err != nil {
+		return nil, fmt.Errorf("create prediction error: %w", err)
+	}
+	return &prediction, nil
+}
+
+func (c *Client) GetPrediction(ctx context.Context, id string) (*Prediction, error) {
+	var prediction Prediction
+	if err := c.doJson(ctx, http.MethodGet, "/predictions/"+url.PathEscape(id), nil, &prediction); err != nil {
+		return nil, fmt.Errorf("get prediction error: %w", err)
+	}
+	return &prediction, nil
+}
+
+// PollPrediction 实现 task.PollFunc, failed/canceled 作为最终失败返回
+func (c *Client) PollPrediction(ctx context.Context, externalID string) (bool, any, error) {
+	prediction, err := c.GetPrediction(ctx, externalID)
+	if err != nil {
+		return false, nil, err
+	}
+	switch prediction.Status {
+	case "succeeded":
+		return true, prediction, nil
+	case "failed", "canceled":
+		return true, prediction, fmt.Errorf("prediction %s %s: %v", prediction.ID, prediction.Status, prediction.Error)
+	default:
+		return false, prediction, nil
+	}
+}
+
+// parseSize 将 1024x1024 拆为宽高
+func parseSize(size string) (width, height int, ok bool) {
+	w, h, found := strings.Cut(size, "x")
+	if !found {
+		return 0, 0, false
+	}
+	width, err1 := strconv.Atoi(w)
+	height, err2 := strconv.Atoi(h)
+	return width, height, err1 == nil && err2 == nil
+}
+
+// CreateImage 创建预测并阻塞等待完成, ctx 结束时取消等待
+func (c *Client) CreateImage(ctx context.Context, req *v1.ImageGenerateRequest) (io.ReadCloser, http.Header, error) {
+	input := map[string]any{"prompt": req.Prompt}
+	if req.N > 0 {
+		input["num_outputs"] = req.N
+	}
+	if width, height, ok := parseSize(req.Size); ok {
+		input["width"] = width
+		input["height"] = height
+	}
+	if req.OutputFormat != "" {
+		input["output_format"] = req.OutputFormat
+	}
+	prediction, err := c.CreatePrediction(ctx, req.Model, input)
+	if err != nil {
+		return nil, nil, err
+	}
+	if prediction.Status != "succeeded" {
+		taskID := c.TaskManager().CreatePollingTask(ctx, prediction.ID, base.NewPoller(c.PollPrediction), nil)
+		result, err := task.Wait(ctx, c.TaskManager(), taskID, WaitInterval)
+		if err != nil {
+			return nil, nil, err
+		}
+		if result.Status == task.StatusFailed {
+			return nil, nil, result.Error
+		}
+		prediction = result.Data.(*Prediction)
+	}
+	urls := prediction.OutputURLs()
+	newResp := v1.ImageGenerateResponse{Created: time.Now().Unix(), Data: make([]v1.ImageGenData, 0, len(urls))}
+	for _, u := range urls {
+		newResp.Data = append(newResp.Data, v1.ImageGenData{URL: u})
+	}
+	respBytes, err := sonic.Marshal(newResp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(respBytes)), base.NewJsonHeader(), nil
+}
+
+func (c *Client) CreateVideoSubmit(ctx context.Context, req *v1.VideoRequest) (*v1.VideoResponse, error) {
+	input := map[string]any{"prompt": req.Prompt}
+	if req.NegativePrompt != "" {
+		input["negative_prompt"] = req.NegativePrompt
+	}
+	if req.Image != "" {
+		input["image"] = req.Image
+	}
+	if req.Seed != 0 {
+		input["seed"] = req.Seed
+	}
+	if width, height, ok := parseSize(req.ImageSize); ok {
+		input["width"] = width
+		input["height"] = height
+	}
+	prediction, err := c.CreatePrediction(ctx, req.Model, input)
+	if err != nil {
+		return nil, err
+	}
+	poller := base.NewPoller(c.GetVideoStatus)
+	taskID := c.TaskManager().CreatePollingTask(context.WithoutCancel(ctx), prediction.ID, poller, nil)
+	return &v1.VideoResponse{RequestId: taskID}, nil
+}
+
+func (c *Client) GetVideoStatus(ctx context.Context, externalID string) (bool, any, error) {
+	completed, data, err := c.PollPrediction(ctx, externalID)
+	prediction, ok := data.(*Prediction)
+	if !ok {
+		return false, nil, err
+	}
+	status := &v1.VideoStatusResponse{}
+	switch {
+	case !completed:
+		status.Status = "InProgress"
+	case err != nil:
+		// 与 base/zhipu 一致, 失败作为已完成的状态返回, 保留上游的错误信息
+		status.Status = "Failed"
+		status.Reason = fmt.Sprint(prediction.Error)
+		return true, status, nil
+	default:
+		status.Status = "Succeed"
+		result := v1.VideoResult{}
+		for _, u := range prediction.OutputURLs() {
+			result.Videos = append(result.Videos, v1.VideoItem{Url: u})
+		}
+		status.Results = []v1.VideoResult{result}
+	}
+	return completed, status, err
+}
+
+func (c *Client) Models(ctx context.Context) (*v1.ModelResponse, error) {
+	return nil, v1.NoImplementError
+}
+
+func (c *Client) CreateResponses(ctx context.Context, req *v1.ResponsesRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateChatCompletions(ctx context.Context, req *v1.ChatCompletionRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateCompletions(ctx context.Context, req *v1.CompletionsRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateEmbeddings(ctx context.Context, req *v1.EmbeddingsRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateRerank(ctx context.Context, req *v1.RerankRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImageEdit(ctx context.Context, req *v1.ImageEditRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImageVariation(ctx context.Context, req *v1.ImageVariationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateSpeech(ctx context.Context, req *v1.AudioSpeechRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateTranslation(ctx context.Context, req *v1.TranslationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateTranscription(ctx context.Context, req *v1.TranscriptionRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
@@ -0,0 +1,68 @@
+package stability
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// AspectRatios Stability 支持的宽高比
+var AspectRatios = []string{"21:9", "16:9", "3:2", "5:4", "1:1", "4:5", "2:3", "9:16", "9:21"}
+
+// ConvertAspectRatio 将 1024x1792 形式的尺寸转换为最接近的 aspect_ratio, 也接受直接传入的宽高比
+func ConvertAspectRatio(size string) string {
+	if size == "" {
+		return ""
+	}
+	for _, ratio := range AspectRatios {
+		if size == ratio {
+			return ratio
+		}
+	}
+	w, h, ok := strings.Cut(size, "x")
+	if !ok {
+		return ""
+	}
+	width, err1 := strconv.ParseFloat(w, 64)
+	height, err2 := strconv.ParseFloat(h, 64)
+	if err1 != nil || err2 != nil || width <= 0 || height <= 0 {
+		return ""
+	}
+	target := math.Log(width / height)
+	best, bestDiff := "", math.MaxFloat64
+	for _, ratio := range AspectRatios {
+		rw, rh, _ := strings.Cut(ratio, ":")
+		a, _ := strconv.ParseFloat(rw, 64)
+		b, _ := strconv.ParseFloat(rh, 64)
+		if diff := math.Abs(math.Log(a/b) - target); diff < bestDiff {
+			best, bestDiff = ratio, diff
+		}
+	}
+	return best
+}
+
+// ConvertOutputFormat Stability 仅支持 png, jpeg 与 webp
+func ConvertOutputFormat(format string) string {
+	switch format {
+	case "jpeg", "jpg":
+		return "jpeg"
+	case "webp":
+		return "webp"
+	default:
+		return "png"
+	}
+}
+
+// GeneratePath 根据模型名选择生成接口, sd3 系列需要额外传 model 字段
+func GeneratePath(model string) (path string, sd3Model string) {
+	switch {
+	case strings.Contains(model, "ultra"):
+		return "/stable-image/generate/ultra", ""
+	case strings.Contains(model, "core"):
+		return "/stable-image/generate/core", ""
+	case strings.HasPrefix(model, "sd3"):
+		return "/stable-image/generate/sd3", model
+	default:
+		return "/stable-image/generate/sd3", ""
+	}
+}
@@ -0,0 +1,259 @@
+package stability
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/constant"
+	"github.com/jiu-u/oai-adapter/tools"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VariationPrompt 图生图要求必填 prompt, 变体请求没有 prompt 时使用
+var VariationPrompt = "a variation of the input image"
+
+// VariationStrength 变体请求的重绘强度, 越大与原图差异越大
+var VariationStrength = 0.6
+
+// Models Stability 没有模型列表接口, 返回支持的模型
+var Models = []string{"stable-image-ultra", "stable-image-core", "sd3.5-large", "sd3.5-large-turbo", "sd3.5-medium"}
+
+type ImageResult struct {
+	Image        string `json:"image"` // base64
+	FinishReason string `json:"finish_reason"`
+	Seed         int64  `json:"seed"`
+}
+
+type Client struct {
+	*base.Client
+}
+
+func NewClient(endPoint, apiKey string) *Client {
+	if endPoint == "" {
+		endPoint = constant.StabilityDefaultURL
+	}
+	endPoint = strings.TrimSpace(endPoint)
+	endPoint = strings.TrimRight(endPoint, "/")
+	return &Client{
+		Client: base.NewClient(endPoint+"/v2beta", apiKey),
+	}
+}
+
+// postForm 以 multipart 提交请求, Accept 为 application/json 时图片以 base64 返回
+func (c *Client) postForm(ctx context.Context, path string, fields map[string]string, files map[string]*multipart.FileHeader) (*ImageResult, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if fields[key] == "" {
+			continue
+		}
+		if err := writer.WriteField(key, fields[key]); err != nil {
+			return nil, fmt.Errorf("write %s field error: %w", key, err)
+		}
+	}
+	for name, fileHeader := range files {
+		if fileHeader == nil {
+			continue
+		}
+		if err := copyFile(writer, name, fileHeader); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("close multipart writer error: %w", err)
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", writer.FormDataContentType())
+	header.Set("Accept", "application/json")
+	header.Set("Authorization", "Bearer "+c.APIKey)
+	respBody, respHeader, err := base.RelayWithCheck(ctx, http.MethodPost, c.EndPoint+path, io.NopCloser(&buf), header, c.HTTPClient())
+	if err != nil {
+		return nil, err
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return nil, fmt.Errorf("read all error: %w", err)
+	}
+	// 部分接口忽略 Accept 直接返回图片二进制
+	if strings.HasPrefix(respHeader.Get("Content-Type"), "image/") {
+		return &ImageResult{Image: base64.StdEncoding.EncodeToString(respBytes), FinishReason: respHeader.Get("Finish-Reason")}, nil
+	}
+	var result ImageResult
+	if err = sonic.Unmarshal(respBytes, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	if result.FinishReason == "CONTENT_FILTERED" {
+		return nil, errors.New("image content filtered")
+	}
+	return &result, nil
+}
+
+func copyFile(writer *multipart.Writer, name string, fileHeader *multipart.FileHeader) error {
+	part, err := writer.CreateFormFile(name, fileHeader.Filename)
+	if err != nil {
+		return fmt.Errorf("create form file for %s error: %w", name, err)
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return fmt.Errorf("open %s file error: %w", name, err)
+	}
+	defer file.Close()
+	if _, err = io.Copy(part, file); err != nil {
+		return fmt.Errorf("copy %s file content error: %w", name, err)
+	}
+	return nil
+}
+
+// buildResponse 按 response_format 返回 b64_json 或 data URL
+func buildResponse(results []*ImageResult, responseFormat, outputFormat string) (io.ReadCloser, http.Header, error) {
+	newResp := v1.ImageGenerateResponse{Created: time.Now().Unix(), Data: make([]v1.ImageGenData, 0, len(results))}
+	for _, result := range results {
+		if responseFormat == "b64_json" {
+			newResp.Data = append(newResp.Data, v1.ImageGenData{B64JSON: result.Image})
+			continue
+		}
+		newResp.Data = append(newResp.Data, v1.ImageGenData{URL: tools.ToDataURL("image/"+outputFormat, result.Image)})
+	}
+	respBytes, err := sonic.Marshal(newResp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(respBytes)), base.NewJsonHeader(), nil
+}
+
+// generate 每次调用只生成一张图片, n 大于 1 时重复请求
+func (c *Client) generate(ctx context.Context, path string, fields map[string]string, files map[string]*multipart.FileHeader, n int) ([]*ImageResult, error) {
+	n = max(n, 1)
+	results := make([]*ImageResult, 0, n)
+	for i := 0; i < n; i++ {
+		result, err := c.postForm(ctx, path, fields, files)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (c *Client) CreateImage(ctx context.Context, req *v1.ImageGenerateRequest) (io.ReadCloser, http.Header, error) {
+	path, sd3Model := GeneratePath(req.Model)
+	outputFormat := ConvertOutputFormat(req.OutputFormat)
+	fields := map[string]string{
+		"prompt":        req.Prompt,
+		"aspect_ratio":  ConvertAspectRatio(req.Size),
+		"output_format": outputFormat,
+		"model":         sd3Model,
+	}
+	results, err := c.generate(ctx, path, fields, nil, req.N)
+	if err != nil {
+		return nil, nil, err
+	}
+	return buildResponse(results, req.ResponseFormat, outputFormat)
+}
+
+// CreateImageEdit 映射为 inpaint, 未提供 mask 时使用图片的透明通道
+func (c *Client) CreateImageEdit(ctx context.Context, req *v1.ImageEditRequest) (io.ReadCloser, http.Header, error) {
+	if len(req.Image) == 0 {
+		return nil, nil, errors.New("image is empty")
+	}
+	fields := map[string]string{
+		"prompt":        req.Prompt,
+		"output_format": "png",
+	}
+	files := map[string]*multipart.FileHeader{"image": req.Image[0], "mask": req.Mask}
+	results, err := c.generate(ctx, "/stable-image/edit/inpaint", fields, files, req.N)
+	if err != nil {
+		return nil, nil, err
+	}
+	return buildResponse(results, req.ResponseFormat, "png")
+}
+
+// CreateImageVariation 映射为 sd3 图生图
+func (c *Client) CreateImageVariation(ctx context.Context, req *v1.ImageVariationRequest) (io.ReadCloser, http.Header, error) {
+	if req.Image == nil {
+		return nil, nil, errors.New("image is empty")
+	}
+	_, sd3Model := GeneratePath(req.Model)
+	fields := map[string]string{
+		"prompt":        VariationPrompt,
+		"mode":          "image-to-image",
+		"strength":      strconv.FormatFloat(VariationStrength, 'f', -1, 64),
+		"output_format": "png",
+		"model":         sd3Model,
+	}
+	files := map[string]*multipart.FileHeader{"image": req.Image}
+	results, err := c.generate(ctx, "/stable-image/generate/sd3", fields, files, req.N)
+	if err != nil {
+		return nil, nil, err
+	}
+	return buildResponse(results, req.ResponseFormat, "png")
+}
+
+func (c *Client) Models(ctx context.Context) (*v1.ModelResponse, error) {
+	resp := &v1.ModelResponse{Object: "list", Data: make([]v1.Model, 0, len(Models))}
+	now := time.Now().Unix()
+	for _, model := range Models {
+		resp.Data = append(resp.Data, v1.Model{ID: model, Object: "model", Created: now, OwnedBy: "stability"})
+	}
+	return resp, nil
+}
+
+func (c *Client) CreateResponses(ctx context.Context, req *v1.ResponsesRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateChatCompletions(ctx context.Context, req *v1.ChatCompletionRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateCompletions(ctx context.Context, req *v1.CompletionsRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateEmbeddings(ctx context.Context, req *v1.EmbeddingsRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateRerank(ctx context.Context, req *v1.RerankRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateSpeech(ctx context.Context, req *v1.AudioSpeechRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateTranslation(ctx context.Context, req *v1.TranslationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateTranscription(ctx context.Context, req *v1.TranscriptionRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateVideoSubmit(ctx context.Context, req *v1.VideoRequest) (*v1.VideoResponse, error) {
+	_, _, err := base.NoImplementMethod(ctx, req)
+	return nil, err
+}
+
+func (c *Client) GetVideoStatus(ctx context.Context, externalID string) (bool, any, error) {
+	_, _, err := base.NoImplementMethod(ctx, externalID)
+	return false, nil, err
+}
@@ -0,0 +1,46 @@
+package tei
+
+import v1 "github.com/jiu-u/oai-adapter/api/v1"
+
+// https://huggingface.github.io/text-embeddings-inference/
+
+type (
+	EmbedRequest struct {
+		Inputs    []string `json:"inputs"`
+		Normalize bool     `json:"normalize"`
+		Truncate  bool     `json:"truncate"`
+	}
+	RerankRequest struct {
+		Query      string   `json:"query"`
+		Texts      []string `json:"texts"`
+		ReturnText bool     `json:"return_text"`
+		Truncate   bool     `json:"truncate"`
+	}
+	RerankResult struct {
+		Index int     `json:"index"`
+		Score float64 `json:"score"`
+		Text  string  `json:"text,omitempty"`
+	}
+	Info struct {
+		ModelId        string         `json:"model_id"`
+		ModelSha       string         `json:"model_sha"`
+		ModelType      map[string]any `json:"model_type"` // {"embedding": {...}} 或 {"reranker": {...}}
+		MaxInputLength int            `json:"max_input_length"`
+		Version        string         `json:"version"`
+	}
+)
+
+type (
+	// embeddingsResponse base64 编码时 embedding 为字符串, 不能使用 v1.EmbeddingsData
+	embeddingsResponse struct {
+		Object string           `json:"object"`
+		Model  string           `json:"model"`
+		Data   []embeddingsData `json:"data"`
+		Usage  v1.Usage         `json:"usage"`
+	}
+	embeddingsData struct {
+		Object    string `json:"object"`
+		Index     int    `json:"index"`
+		Embedding any    `json:"embedding"`
+	}
+)
@@ -0,0 +1,230 @@
+package tei
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/constant"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Client struct {
+	*base.Client
+}
+
+// NewClient apiKey 对应 TEI 的 --api-key, 未启用鉴权时留空
+func NewClient(endPoint, apiKey string) *Client {
+	if endPoint == "" {
+		endPoint = constant.TEIDefaultURL
+	}
+	endPoint = strings.TrimSpace(endPoint)
+	endPoint = strings.TrimRight(endPoint, "/")
+	return &Client{
+		Client: base.NewClient(endPoint, apiKey),
+	}
+}
+
+func (c *Client) generateHeader() http.Header {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	return header
+}
+
+// do 发送请求并解析响应, 返回 TEI 在响应头中给出的 token 数
+func (c *Client) do(ctx context.Context, method, path string, req, resp any) (int, error) {
+	var body io.ReadCloser
+	if req != nil {
+		reqBytes, err := sonic.Marshal(req)
+		if err != nil {
+			return 0, fmt.Errorf("marshal error: %w", err)
+		}
+		body = io.NopCloser(bytes.NewReader(reqBytes))
+	}
+	respBody, respHeader, err := base.RelayWithCheck(ctx, method, c.EndPoint+path, body, c.generateHeader(), c.HTTPClient())
+	if err != nil {
+		return 0, err
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return 0, fmt.Errorf("read all error: %w", err)
+	}
+	if err = sonic.Unmarshal(respBytes, resp); err != nil {
+		return 0, fmt.Errorf("unmarshal error: %w", err)
+	}
+	tokens, _ := strconv.Atoi(respHeader.Get("X-Compute-Tokens"))
+	return tokens, nil
+}
+
+// truncateEmbedding 按 dimensions 截断并重新归一化, 适用于 Matryoshka 训练的模型
+func truncateEmbedding(embedding []float32, dimensions int) []float32 {
+	if dimensions <= 0 || dimensions >= len(embedding) {
+		return embedding
+	}
+	embedding = embedding[:dimensions]
+	var norm float64
+	for _, v := range embedding {
+		norm += float64(v) * float64(v)
+	}
+	if norm = math.Sqrt(norm); norm > 0 {
+		for i := range embedding {
+			embedding[i] = float32(float64(embedding[i]) / norm)
+		}
+	}
+	return embedding
+}
+
+// encodeBase64 与 OpenAI 一致, 使用小端 float32 序列
+func encodeBase64(embedding []float32) string {
+	buf := make([]byte, 4*len(embedding))
+	for i, v := range embedding {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
+
+func (c *Client) CreateEmbeddings(ctx context.Context, req *v1.EmbeddingsRequest) (io.ReadCloser, http.Header, error) {
+	inputs, err := req.InputStrings()
+	if err != nil {
+		return nil, nil, err
+	}
+	var embeddings [][]float32
+	tokens, err := c.do(ctx, http.MethodPost, "/embed", &EmbedRequest{Inputs: inputs, Normalize: true, Truncate: true}, &embeddings)
+	if err != nil {
+		return nil, nil, err
+	}
+	newResp := embeddingsResponse{
+		Object: "list",
+		Model:  req.Model,
+		Data:   make([]embeddingsData, 0, len(embeddings)),
+		Usage:  v1.Usage{PromptTokens: tokens, TotalTokens: tokens},
+	}
+	for i, embedding := range embeddings {
+		embedding = truncateEmbedding(embedding, req.Dimensions)
+		data := embeddingsData{Object: "embedding", Index: i, Embedding: embedding}
+		if req.EncodingFormat == "base64" {
+			data.Embedding = encodeBase64(embedding)
+		}
+		newResp.Data = append(newResp.Data, data)
+	}
+	respBytes, err := sonic.Marshal(newResp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(respBytes)), base.NewJsonHeader(), nil
+}
+
+func (c *Client) CreateRerank(ctx context.Context, req *v1.RerankRequest) (io.ReadCloser, http.Header, error) {
+	newReq := &RerankRequest{
+		Query:      req.Query,
+		Texts:      req.Documents,
+		ReturnText: req.ReturnDocuments,
+		Truncate:   true,
+	}
+	var results []RerankResult
+	tokens, err := c.do(ctx, http.MethodPost, "/rerank", newReq, &results)
+	if err != nil {
+		return nil, nil, err
+	}
+	// TEI 不支持 top_n, 在本地排序截断
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if req.TopN > 0 && req.TopN < len(results) {
+		results = results[:req.TopN]
+	}
+	newResp := v1.RerankResponse{
+		ID:      "rerank-" + strconv.FormatInt(time.Now().UnixNano(), 36),
+		Results: make([]v1.RerankResult, 0, len(results)),
+	}
+	for _, result := range results {
+		newResult := v1.RerankResult{Index: result.Index, RelevanceScore: result.Score}
+		if req.ReturnDocuments {
+			newResult.Document.Text = result.Text
+			if newResult.Document.Text == "" && result.Index >= 0 && result.Index < len(req.Documents) {
+				newResult.Document.Text = req.Documents[result.Index]
+			}
+		}
+		newResp.Results = append(newResp.Results, newResult)
+	}
+	if tokens > 0 {
+		newResp.Usage = &v1.Usage{PromptTokens: tokens, TotalTokens: tokens}
+	}
+	respBytes, err := sonic.Marshal(newResp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(respBytes)), base.NewJsonHeader(), nil
+}
+
+// Models TEI 每个实例只加载一个模型, 由 /info 给出
+func (c *Client) Models(ctx context.Context) (*v1.ModelResponse, error) {
+	var info Info
+	if _, err := c.do(ctx, http.MethodGet, "/info", nil, &info); err != nil {
+		return nil, err
+	}
+	return &v1.ModelResponse{
+		Object: "list",
+		Data:   []v1.Model{{ID: info.ModelId, Object: "model", Created: time.Now().Unix(), OwnedBy: "tei"}},
+	}, nil
+}
+
+func (c *Client) CreateResponses(ctx context.Context, req *v1.ResponsesRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateChatCompletions(ctx context.Context, req *v1.ChatCompletionRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateCompletions(ctx context.Context, req *v1.CompletionsRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImage(ctx context.Context, req *v1.ImageGenerateRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImageEdit(ctx context.Context, req *v1.ImageEditRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImageVariation(ctx context.Context, req *v1.ImageVariationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateSpeech(ctx context.Context, req *v1.AudioSpeechRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateTranslation(ctx context.Context, req *v1.TranslationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateTranscription(ctx context.Context, req *v1.TranscriptionRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateVideoSubmit(ctx context.Context, req *v1.VideoRequest) (*v1.VideoResponse, error) {
+	_, _, err := base.NoImplementMethod(ctx, req)
+	return nil, err
+}
+
+func (c *Client) GetVideoStatus(ctx context.Context, externalID string) (bool, any, error) {
+	_, _, err := base.NoImplementMethod(ctx, externalID)
+	return false, nil, err
+}
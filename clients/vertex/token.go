@@ -0,0 +1,170 @@
+package vertex
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/bytedance/sonic"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTokenURI = "https://oauth2.googleapis.com/token"
+	cloudPlatform   = "https://www.googleapis.com/auth/cloud-platform"
+	// 令牌在过期前提前刷新的时间
+	tokenRefreshAhead = 5 * time.Minute
+)
+
+// ServiceAccount 服务账号密钥文件中使用到的字段
+type ServiceAccount struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+func ParseServiceAccount(data []byte) (*ServiceAccount, error) {
+	var sa ServiceAccount
+	if err := sonic.Unmarshal(data, &sa); err != nil {
+		return nil, fmt.Errorf("unmarshal service account error: %w", err)
+	}
+	if sa.ClientEmail == "" || sa.PrivateKey == "" {
+		return nil, errors.New("service account missing client_email or private_key")
+	}
+	return &sa, nil
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
+
+// TokenSource 使用服务账号私钥签发 JWT 换取 OAuth2 访问令牌, 并缓存至过期前
+type TokenSource struct {
+	sa     *ServiceAccount
+	key    *rsa.PrivateKey
+	client *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func NewTokenSource(sa *ServiceAccount, client *http.Client) (*TokenSource, error) {
+	key, err := parsePrivateKey(sa.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenSource{sa: sa, key: key, client: client}, nil
+}
+
+func parsePrivateKey(pemKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("invalid private key pem")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("private key is not rsa")
+		}
+		return rsaKey, nil
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key error: %w", err)
+	}
+	return key, nil
+}
+
+// Token 返回缓存的访问令牌, 即将过期时重新获取
+func (s *TokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Now().Add(tokenRefreshAhead).Before(s.expires) {
+		return s.token, nil
+	}
+	now := time.Now()
+	assertion, err := s.signJWT(now)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+	header := http.Header{}
+	header.Set("Content-Type", "application/x-www-form-urlencoded")
+	body := io.NopCloser(strings.NewReader(form.Encode()))
+	respBody, _, err := base.RelayWithCheck(ctx, http.MethodPost, s.tokenURI(), body, header, s.client)
+	if err != nil {
+		return "", fmt.Errorf("fetch token error: %w", err)
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return "", fmt.Errorf("read all error: %w", err)
+	}
+	var resp tokenResponse
+	if err = sonic.Unmarshal(respBytes, &resp); err != nil {
+		return "", fmt.Errorf("unmarshal error: %w", err)
+	}
+	if resp.AccessToken == "" {
+		return "", errors.New("empty access token")
+	}
+	s.token = resp.AccessToken
+	s.expires = now.Add(time.Duration(resp.ExpiresIn) * time.Second)
+	return s.token, nil
+}
+
+func (s *TokenSource) tokenURI() string {
+	if s.sa.TokenURI != "" {
+		return s.sa.TokenURI
+	}
+	return defaultTokenURI
+}
+
+// signJWT 生成 RS256 签名的 JWT 断言
+func (s *TokenSource) signJWT(now time.Time) (string, error) {
+	header := map[string]string{"alg": "RS256", "typ": "JWT"}
+	if s.sa.PrivateKeyID != "" {
+		header["kid"] = s.sa.PrivateKeyID
+	}
+	claims := map[string]any{
+		"iss":   s.sa.ClientEmail,
+		"scope": cloudPlatform,
+		"aud":   s.tokenURI(),
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}
+	headerBytes, err := sonic.Marshal(header)
+	if err != nil {
+		return "", fmt.Errorf("marshal error: %w", err)
+	}
+	claimsBytes, err := sonic.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("marshal error: %w", err)
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(headerBytes) + "." + base64.RawURLEncoding.EncodeToString(claimsBytes)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("sign jwt error: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
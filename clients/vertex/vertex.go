@@ -0,0 +1,317 @@
+package vertex
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/clients/gemini_native"
+	"github.com/jiu-u/oai-adapter/constant/mode"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+var DefaultLocation = "us-central1"
+
+type Client struct {
+	*base.Client
+	host      string
+	ProjectID string
+	Location  string
+	tokens    *TokenSource
+	initErr   error // 服务账号解析失败时在请求时返回
+}
+
+// NewClient endPoint 为区域名(如 us-central1)或自定义地址, apiKey 为服务账号 JSON 内容或其文件路径
+func NewClient(endPoint, apiKey string) *Client {
+	data := []byte(strings.TrimSpace(apiKey))
+	if !bytes.HasPrefix(data, []byte("{")) {
+		fileData, err := os.ReadFile(apiKey)
+		if err != nil {
+			return newErrClient(fmt.Errorf("read service account error: %w", err))
+		}
+		data = fileData
+	}
+	sa, err := ParseServiceAccount(data)
+	if err != nil {
+		return newErrClient(err)
+	}
+	endPoint = strings.TrimSpace(endPoint)
+	if endPoint != "" && !strings.Contains(endPoint, "://") {
+		return NewClientWithServiceAccount("", endPoint, sa)
+	}
+	return NewClientWithServiceAccount(endPoint, "", sa)
+}
+
+func newErrClient(err error) *Client {
+	c := &Client{Client: base.NewClient("", ""), initErr: err}
+	c.SetAuthFunc(c.auth)
+	return c
+}
+
+// NewClientWithServiceAccount host 为空时根据 location 使用官方地址
+func NewClientWithServiceAccount(host, location string, sa *ServiceAccount) *Client {
+	if location == "" {
+		location = DefaultLocation
+	}
+	host = strings.TrimRight(strings.TrimSpace(host), "/")
+	if host == "" {
+		host = "https://" + location + "-aiplatform.googleapis.com"
+		if location == "global" {
+			host = "https://aiplatform.googleapis.com"
+		}
+	}
+	c := &Client{
+		Client:    base.NewClient(host+"/v1/projects/"+sa.ProjectID+"/locations/"+location, ""),
+		host:      host,
+		ProjectID: sa.ProjectID,
+		Location:  location,
+	}
+	c.tokens, c.initErr = NewTokenSource(sa, c.HTTPClient())
+	c.SetAuthFunc(c.auth)
+	// 非原生接口走 Vertex 的 OpenAI 兼容端点
+	c.SetURLBuilder(func(m mode.Mode, model, path string) string {
+		return c.EndPoint + "/endpoints/openapi" + path
+	})
+	return c
+}
+
+func (c *Client) auth(ctx context.Context, header http.Header) error {
+	if c.initErr != nil {
+		return c.initErr
+	}
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return err
+	}
+	header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+func (c *Client) RelayRequest(ctx context.Context, method, targetPath string, body io.ReadCloser, header http.Header) (io.ReadCloser, http.Header, error) {
+	header.Del("Authorization")
+	if err := c.auth(ctx, header); err != nil {
+		return nil, nil, err
+	}
+	return base.Relay(ctx, method, c.host+targetPath, body, header, c.HTTPClient())
+}
+
+func (c *Client) postJson(ctx context.Context, targetUrl string, req any) (io.ReadCloser, http.Header, error) {
+	reqBytes, err := sonic.Marshal(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	if err = c.auth(ctx, header); err != nil {
+		return nil, nil, err
+	}
+	body := io.NopCloser(bytes.NewReader(reqBytes))
+	return base.RelayWithCheck(ctx, http.MethodPost, targetUrl, body, header, c.HTTPClient())
+}
+
+// ModelURL publishers/google/models/{model} 的完整地址
+func (c *Client) ModelURL(model string) string {
+	return c.EndPoint + "/publishers/google/models/" + strings.TrimPrefix(model, "models/")
+}
+
+// CreateChatCompletions Gemini 模型走原生 generateContent,
+// 带发布方前缀的模型(如 google/gemini-2.0-flash、meta/llama-3.1-405b-instruct-maas)走 OpenAI 兼容端点
+func (c *Client) CreateChatCompletions(ctx context.Context, req *v1.ChatCompletionRequest) (io.ReadCloser, http.Header, error) {
+	if strings.Contains(req.Model, "/") {
+		return c.Client.CreateChatCompletions(ctx, req)
+	}
+	newReq, err := gemini_native.ConvertChatRequest(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	targetUrl := c.ModelURL(req.Model) + ":generateContent"
+	if req.Stream {
+		targetUrl = c.ModelURL(req.Model) + ":streamGenerateContent?alt=sse"
+	}
+	respBody, _, err := c.postJson(ctx, targetUrl, newReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.Stream {
+		includeUsage := req.StreamOptions != nil && req.StreamOptions.IncludeUsage
+		return gemini_native.ConvertStreamResponse(respBody, req.Model, includeUsage), base.NewStreamHeader(), nil
+	}
+	return gemini_native.ConvertNoStreamResponse(respBody, req.Model)
+}
+
+type (
+	predictRequest struct {
+		Instances  []predictInstance  `json:"instances"`
+		Parameters *predictParameters `json:"parameters,omitempty"`
+	}
+	predictInstance struct {
+		Content string `json:"content"`
+	}
+	predictParameters struct {
+		OutputDimensionality int `json:"outputDimensionality,omitempty"`
+	}
+	predictResponse struct {
+		Predictions []struct {
+			Embeddings struct {
+				Values     []float64 `json:"values"`
+				Statistics struct {
+					TokenCount int `json:"token_count"`
+				} `json:"statistics"`
+			} `json:"embeddings"`
+		} `json:"predictions"`
+	}
+)
+
+// CreateEmbeddings 通过 :predict 调用文本向量模型
+func (c *Client) CreateEmbeddings(ctx context.Context, req *v1.EmbeddingsRequest) (io.ReadCloser, http.Header, error) {
+	inputs, err := req.InputStrings()
+	if err != nil {
+		return nil, nil, err
+	}
+	newReq := &predictRequest{Instances: make([]predictInstance, 0, len(inputs))}
+	for _, input := range inputs {
+		newReq.Instances = append(newReq.Instances, predictInstance{Content: input})
+	}
+	if req.Dimensions > 0 {
+		newReq.Parameters = &predictParameters{OutputDimensionality: req.Dimensions}
+	}
+	respBody, _, err := c.postJson(ctx, c.ModelURL(req.Model)+":predict", newReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read all error: %w", err)
+	}
+	var resp predictResponse
+	if err = sonic.Unmarshal(respBytes, &resp); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	newResp := v1.EmbeddingsResponse{
+		Object: "list",
+		Model:  req.Model,
+		Data:   make([]v1.EmbeddingsData, 0, len(resp.Predictions)),
+	}
+	for i, prediction := range resp.Predictions {
+		values := make([]any, 0, len(prediction.Embeddings.Values))
+		for _, value := range prediction.Embeddings.Values {
+			values = append(values, value)
+		}
+		newResp.Data = append(newResp.Data, v1.EmbeddingsData{
+			Object:    "embedding",
+			Index:     i,
+			Embedding: values,
+		})
+		newResp.Usage.PromptTokens += prediction.Embeddings.Statistics.TokenCount
+	}
+	newResp.Usage.TotalTokens = newResp.Usage.PromptTokens
+	newRespBytes, err := sonic.Marshal(newResp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(newRespBytes)), base.NewJsonHeader(), nil
+}
+
+type publisherModelList struct {
+	PublisherModels []struct {
+		Name string `json:"name"`
+	} `json:"publisherModels"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// Models 列出 Google 发布的模型, 该接口仅在 v1beta1 中提供
+func (c *Client) Models(ctx context.Context) (*v1.ModelResponse, error) {
+	resp := &v1.ModelResponse{Object: "list", Data: make([]v1.Model, 0)}
+	now := time.Now().Unix()
+	pageToken := ""
+	for {
+		targetUrl := c.host + "/v1beta1/publishers/google/models?pageSize=100"
+		if pageToken != "" {
+			targetUrl += "&pageToken=" + url.QueryEscape(pageToken)
+		}
+		header := http.Header{}
+		if err := c.auth(ctx, header); err != nil {
+			return nil, err
+		}
+		body, _, err := base.RelayWithCheck(ctx, http.MethodGet, targetUrl, nil, header, c.HTTPClient())
+		if err != nil {
+			return nil, err
+		}
+		dataBytes, err := io.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read all error: %w", err)
+		}
+		var list publisherModelList
+		if err = sonic.Unmarshal(dataBytes, &list); err != nil {
+			return nil, fmt.Errorf("unmarshal error: %w", err)
+		}
+		for _, model := range list.PublisherModels {
+			resp.Data = append(resp.Data, v1.Model{
+				ID:      strings.TrimPrefix(model.Name, "publishers/google/models/"),
+				Object:  "model",
+				Created: now,
+				OwnedBy: "google",
+			})
+		}
+		if list.NextPageToken == "" {
+			break
+		}
+		pageToken = list.NextPageToken
+	}
+	return resp, nil
+}
+
+func (c *Client) CreateResponses(ctx context.Context, req *v1.ResponsesRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateCompletions(ctx context.Context, req *v1.CompletionsRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateRerank(ctx context.Context, req *v1.RerankRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImage(ctx context.Context, req *v1.ImageGenerateRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImageEdit(ctx context.Context, req *v1.ImageEditRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImageVariation(ctx context.Context, req *v1.ImageVariationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateSpeech(ctx context.Context, req *v1.AudioSpeechRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateTranslation(ctx context.Context, req *v1.TranslationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateTranscription(ctx context.Context, req *v1.TranscriptionRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateVideoSubmit(ctx context.Context, req *v1.VideoRequest) (*v1.VideoResponse, error) {
+	_, _, err := base.NoImplementMethod(ctx, req)
+	return nil, err
+}
+
+func (c *Client) GetVideoStatus(ctx context.Context, externalID string) (bool, any, error) {
+	_, _, err := base.NoImplementMethod(ctx, externalID)
+	return false, nil, err
+}
@@ -0,0 +1,130 @@
+package vllm
+
+import (
+	"context"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/constant"
+	"github.com/jiu-u/oai-adapter/constant/mode"
+	"io"
+	"maps"
+	"net/http"
+	"strings"
+)
+
+type Backend string
+
+const (
+	BackendVLLM     Backend = "vllm"
+	BackendLlamaCpp Backend = "llama.cpp"
+)
+
+// Client vLLM 与 llama.cpp server 均提供 OpenAI 兼容接口, 区别在于约束解码的参数名
+type Client struct {
+	*base.Client
+	backend Backend
+}
+
+func NewClient(endPoint, apiKey string) *Client {
+	if endPoint == "" {
+		endPoint = constant.VLLMDefaultURL
+	}
+	return newClient(BackendVLLM, endPoint, apiKey)
+}
+
+func NewLlamaCppClient(endPoint, apiKey string) *Client {
+	if endPoint == "" {
+		endPoint = constant.LlamaCppDefaultURL
+	}
+	return newClient(BackendLlamaCpp, endPoint, apiKey)
+}
+
+func newClient(backend Backend, endPoint, apiKey string) *Client {
+	endPoint = strings.TrimSpace(endPoint)
+	endPoint = strings.TrimRight(endPoint, "/")
+	c := &Client{
+		Client:  base.NewClient(endPoint+"/v1", apiKey),
+		backend: backend,
+	}
+	modes := []mode.Mode{mode.Chat, mode.ChatStream, mode.Completions, mode.Embedding, mode.Models}
+	if backend == BackendVLLM {
+		modes = append(modes, mode.Rerank, mode.Transcriptions, mode.Translate)
+	}
+	c.SetSupportedModes(modes...)
+	return c
+}
+
+func (c *Client) Backend() Backend {
+	return c.backend
+}
+
+func (c *Client) CreateChatCompletions(ctx context.Context, req *v1.ChatCompletionRequest) (io.ReadCloser, http.Header, error) {
+	newReq := *req
+	newReq.ExtraBody = ConvertExtraBody(c.backend, withUnknownFields(req.ExtraBody, req.UnknownFields))
+	if ConvertResponseFormat(c.backend, req.ResponseFormat, newReq.ExtraBody) {
+		newReq.ResponseFormat = nil
+	}
+	return c.Client.CreateChatCompletions(ctx, &newReq)
+}
+
+func (c *Client) CreateCompletions(ctx context.Context, req *v1.CompletionsRequest) (io.ReadCloser, http.Header, error) {
+	newReq := *req
+	newReq.ExtraBody = ConvertExtraBody(c.backend, withUnknownFields(req.ExtraBody, req.UnknownFields))
+	return c.Client.CreateCompletions(ctx, &newReq)
+}
+
+// withUnknownFields vLLM 与 llama.cpp 的采样参数常直接写在请求体顶层, 一并透传, extra_body 中的同名字段优先
+func withUnknownFields(extra, unknown map[string]any) map[string]any {
+	if len(unknown) == 0 {
+		return extra
+	}
+	merged := maps.Clone(unknown)
+	maps.Copy(merged, extra)
+	return merged
+}
+
+// guidedAliases vLLM 与 llama.cpp 的同义参数
+var guidedAliases = map[string]string{
+	"guided_json":    "json_schema",
+	"guided_grammar": "grammar",
+}
+
+// ConvertExtraBody 将另一后端的参数名转换为当前后端的参数名, 返回新的 map
+func ConvertExtraBody(backend Backend, extra map[string]any) map[string]any {
+	if len(extra) == 0 {
+		return make(map[string]any)
+	}
+	extra = maps.Clone(extra)
+	for vllmKey, llamaKey := range guidedAliases {
+		from, to := llamaKey, vllmKey
+		if backend == BackendLlamaCpp {
+			from, to = vllmKey, llamaKey
+		}
+		if v, ok := extra[from]; ok {
+			if _, exists := extra[to]; !exists {
+				extra[to] = v
+			}
+			delete(extra, from)
+		}
+	}
+	return extra
+}
+
+// ConvertResponseFormat 将 json_schema 格式转换为约束解码参数写入 extra, 返回是否已转换
+// 已显式指定约束解码参数时不覆盖
+func ConvertResponseFormat(backend Backend, format *v1.ResponseFormat, extra map[string]any) bool {
+	if format == nil || format.Type != "json_schema" || format.JsonSchema == nil || format.JsonSchema.Schema == nil {
+		return false
+	}
+	key := "guided_json"
+	if backend == BackendLlamaCpp {
+		key = "json_schema"
+	}
+	for _, guided := range []string{"guided_json", "guided_regex", "guided_choice", "guided_grammar", "json_schema", "grammar"} {
+		if _, ok := extra[guided]; ok {
+			return true
+		}
+	}
+	extra[key] = format.JsonSchema.Schema
+	return true
+}
@@ -0,0 +1,55 @@
+package zhipu
+
+import (
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+)
+
+// ConvertChatRequest 将 ChatCompletionRequest 转换为 GLM 请求
+// do_sample 与 request_id 可通过 meta_data 传入, web_search_options 转为 web_search 工具
+func ConvertChatRequest(req *v1.ChatCompletionRequest) *ChatRequest {
+	newReq := &ChatRequest{
+		Model:          req.Model,
+		Messages:       req.Messages,
+		Stream:         req.Stream,
+		MaxTokens:      req.MaxOutputTokens(),
+		Stop:           req.StopSequences(),
+		ResponseFormat: req.ResponseFormat,
+		UserId:         req.User,
+	}
+	// GLM 的 temperature 范围为 [0, 1], top_p 范围为 (0, 1)
+	if req.Temperature != 0 {
+		temperature := min(req.Temperature, 1)
+		newReq.Temperature = &temperature
+	}
+	if req.TopP > 0 && req.TopP < 1 {
+		newReq.TopP = req.TopP
+	}
+	if metaData, ok := req.MetaData.(map[string]any); ok {
+		if doSample, ok := metaData["do_sample"].(bool); ok {
+			newReq.DoSample = &doSample
+		}
+		if requestId, ok := metaData["request_id"].(string); ok {
+			newReq.RequestId = requestId
+		}
+	}
+	toolChoice, _ := req.ParseToolChoice()
+	if toolChoice != v1.ToolChoiceNone {
+		for i := range req.Tools {
+			tool := &req.Tools[i]
+			switch tool.Type {
+			case "", "function":
+				newReq.Tools = append(newReq.Tools, Tool{Type: "function", Function: &tool.Function})
+			case "web_search":
+				newReq.Tools = append(newReq.Tools, Tool{Type: "web_search", WebSearch: &WebSearch{Enable: true}})
+			}
+		}
+	}
+	if req.WebSearchOptions != nil {
+		newReq.Tools = append(newReq.Tools, Tool{Type: "web_search", WebSearch: &WebSearch{Enable: true, SearchResult: true}})
+	}
+	// GLM 只支持 auto
+	if len(newReq.Tools) > 0 {
+		newReq.ToolChoice = "auto"
+	}
+	return newReq
+}
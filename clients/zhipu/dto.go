@@ -0,0 +1,77 @@
+package zhipu
+
+import v1 "github.com/jiu-u/oai-adapter/api/v1"
+
+// https://open.bigmodel.cn/dev/api/normal-model/glm-4
+
+type (
+	ChatRequest struct {
+		Model          string             `json:"model"`
+		Messages       []v1.Message       `json:"messages"`
+		Stream         bool               `json:"stream"`
+		DoSample       *bool              `json:"do_sample,omitempty"`
+		Temperature    *float64           `json:"temperature,omitempty"` // [0, 1]
+		TopP           float64            `json:"top_p,omitempty"`       // (0, 1)
+		MaxTokens      int                `json:"max_tokens,omitempty"`
+		Stop           []string           `json:"stop,omitempty"`
+		Tools          []Tool             `json:"tools,omitempty"`
+		ToolChoice     string             `json:"tool_choice,omitempty"` // 仅支持 auto
+		ResponseFormat *v1.ResponseFormat `json:"response_format,omitempty"`
+		RequestId      string             `json:"request_id,omitempty"`
+		UserId         string             `json:"user_id,omitempty"`
+	}
+	Tool struct {
+		Type      string       `json:"type"` // function, web_search, retrieval
+		Function  *v1.Function `json:"function,omitempty"`
+		WebSearch *WebSearch   `json:"web_search,omitempty"`
+	}
+	WebSearch struct {
+		Enable       bool   `json:"enable"`
+		SearchQuery  string `json:"search_query,omitempty"`
+		SearchResult bool   `json:"search_result,omitempty"` // 是否在响应中返回搜索结果
+	}
+)
+
+// https://open.bigmodel.cn/dev/api/image-model/cogview
+
+type (
+	ImageRequest struct {
+		Model     string `json:"model"`
+		Prompt    string `json:"prompt"`
+		Size      string `json:"size,omitempty"`
+		Quality   string `json:"quality,omitempty"` // hd, standard
+		RequestId string `json:"request_id,omitempty"`
+		UserId    string `json:"user_id,omitempty"`
+	}
+	ImageResponse struct {
+		Created int64 `json:"created"`
+		Data    []struct {
+			Url string `json:"url"`
+		} `json:"data"`
+	}
+)
+
+// https://open.bigmodel.cn/dev/api/videomodel/cogvideox
+
+type (
+	VideoRequest struct {
+		Model     string `json:"model"`
+		Prompt    string `json:"prompt,omitempty"`
+		ImageUrl  string `json:"image_url,omitempty"` // 图生视频, url 或 base64
+		Size      string `json:"size,omitempty"`
+		Seed      int    `json:"seed,omitempty"`
+		RequestId string `json:"request_id,omitempty"`
+		UserId    string `json:"user_id,omitempty"`
+	}
+	// AsyncResponse 异步任务提交与查询的响应
+	AsyncResponse struct {
+		ID          string `json:"id"`
+		Model       string `json:"model"`
+		RequestId   string `json:"request_id"`
+		TaskStatus  string `json:"task_status"` // PROCESSING, SUCCESS, FAIL
+		VideoResult []struct {
+			Url           string `json:"url"`
+			CoverImageUrl string `json:"cover_image_url"`
+		} `json:"video_result"`
+	}
+)
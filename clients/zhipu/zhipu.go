@@ -0,0 +1,240 @@
+package zhipu
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/bytedance/sonic"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/constant"
+	"github.com/jiu-u/oai-adapter/tools"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Models 智谱没有模型列表接口, 返回常用模型
+var Models = []string{
+	"glm-4-plus", "glm-4-air", "glm-4-airx", "glm-4-long", "glm-4-flash", "glm-4-flashx",
+	"glm-4v-plus", "glm-4v-flash", "glm-z1-air", "glm-z1-flash",
+	"cogview-3-flash", "cogview-4", "cogvideox-2", "cogvideox-flash", "embedding-3",
+}
+
+type Client struct {
+	*base.Client
+	authFunc base.AuthFunc
+}
+
+// NewClient apiKey 为 id.secret 形式时在本地签发 JWT, 否则直接作为 Bearer 令牌使用
+func NewClient(endPoint, apiKey string) *Client {
+	if endPoint == "" {
+		endPoint = constant.ZhipuDefaultURL
+	}
+	endPoint = strings.TrimSpace(endPoint)
+	endPoint = strings.TrimRight(endPoint, "/")
+	c := &Client{
+		Client: base.NewClient(endPoint+"/api/paas/v4", apiKey),
+	}
+	if tokenSource, err := NewTokenSource(apiKey); err == nil {
+		c.authFunc = func(ctx context.Context, header http.Header) error {
+			token, err := tokenSource.Token()
+			if err != nil {
+				return err
+			}
+			header.Set("Authorization", "Bearer "+token)
+			return nil
+		}
+	} else {
+		c.authFunc = func(ctx context.Context, header http.Header) error {
+			header.Set("Authorization", "Bearer "+apiKey)
+			return nil
+		}
+	}
+	c.SetAuthFunc(c.authFunc)
+	return c
+}
+
+func (c *Client) generateHeader(ctx context.Context) (http.Header, error) {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	if err := c.authFunc(ctx, header); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+func (c *Client) CreateChatCompletions(ctx context.Context, req *v1.ChatCompletionRequest) (io.ReadCloser, http.Header, error) {
+	newReq := ConvertChatRequest(req)
+	// 响应格式与 OpenAI 一致, 包括流式与 web_search 结果, 直接透传
+	return c.SamePostJob(ctx, c.EndPoint+"/chat/completions", newReq, "application/json")
+}
+
+func (c *Client) CreateImage(ctx context.Context, req *v1.ImageGenerateRequest) (io.ReadCloser, http.Header, error) {
+	newReq := &ImageRequest{
+		Model:   req.Model,
+		Prompt:  req.Prompt,
+		Size:    req.Size,
+		Quality: req.Quality,
+		UserId:  req.User,
+	}
+	if newReq.Quality != "" && newReq.Quality != "hd" {
+		newReq.Quality = "standard"
+	}
+	respBody, _, err := c.postJson(ctx, "/images/generations", newReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read all error: %w", err)
+	}
+	var resp ImageResponse
+	if err = sonic.Unmarshal(respBytes, &resp); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	newResp := v1.ImageGenerateResponse{Created: resp.Created, Data: make([]v1.ImageGenData, 0, len(resp.Data))}
+	if newResp.Created == 0 {
+		newResp.Created = time.Now().Unix()
+	}
+	for _, item := range resp.Data {
+		// CogView 只返回临时链接, 需要 b64_json 时下载转换
+		if req.ResponseFormat == "b64_json" {
+			dataUrl, _, err := tools.GetNetImageB64(item.Url)
+			if err != nil {
+				return nil, nil, fmt.Errorf("download image error: %w", err)
+			}
+			_, data, _ := tools.ParseDataURL(dataUrl)
+			newResp.Data = append(newResp.Data, v1.ImageGenData{B64JSON: data})
+			continue
+		}
+		newResp.Data = append(newResp.Data, v1.ImageGenData{URL: item.Url})
+	}
+	respBytes, err = sonic.Marshal(newResp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(respBytes)), base.NewJsonHeader(), nil
+}
+
+func (c *Client) postJson(ctx context.Context, path string, req any) (io.ReadCloser, http.Header, error) {
+	reqBytes, err := sonic.Marshal(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error: %w", err)
+	}
+	header, err := c.generateHeader(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	body := io.NopCloser(bytes.NewReader(reqBytes))
+	return base.RelayWithCheck(ctx, http.MethodPost, c.EndPoint+path, body, header, c.HTTPClient())
+}
+
+func (c *Client) CreateVideoSubmit(ctx context.Context, req *v1.VideoRequest) (*v1.VideoResponse, error) {
+	newReq := &VideoRequest{
+		Model:    req.Model,
+		Prompt:   req.Prompt,
+		ImageUrl: req.Image,
+		Size:     req.ImageSize,
+		Seed:     req.Seed,
+	}
+	respBody, _, err := c.postJson(ctx, "/videos/generations", newReq)
+	if err != nil {
+		return nil, fmt.Errorf("relay error: %w", err)
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return nil, fmt.Errorf("read all error: %w", err)
+	}
+	var resp AsyncResponse
+	if err = sonic.Unmarshal(respBytes, &resp); err != nil {
+		return nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	if resp.ID == "" {
+		return nil, fmt.Errorf("empty task id, status: %s", resp.TaskStatus)
+	}
+	// 由任务管理器轮询 /async-result, 对外返回本地任务 ID; 轮询不随当前请求结束而取消
+	poller := base.NewPoller(c.GetVideoStatus)
+	taskID := c.TaskManager().CreatePollingTask(context.WithoutCancel(ctx), resp.ID, poller, nil)
+	return &v1.VideoResponse{RequestId: taskID}, nil
+}
+
+func (c *Client) GetVideoStatus(ctx context.Context, externalID string) (bool, any, error) {
+	header, err := c.generateHeader(ctx)
+	if err != nil {
+		return false, nil, err
+	}
+	targetUrl := c.EndPoint + "/async-result/" + url.PathEscape(externalID)
+	respBody, _, err := base.RelayWithCheck(ctx, http.MethodGet, targetUrl, nil, header, c.HTTPClient())
+	if err != nil {
+		return false, nil, fmt.Errorf("relay error: %w", err)
+	}
+	defer respBody.Close()
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return false, nil, fmt.Errorf("read all error: %w", err)
+	}
+	var resp AsyncResponse
+	if err = sonic.Unmarshal(respBytes, &resp); err != nil {
+		return false, nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	status := &v1.VideoStatusResponse{}
+	switch resp.TaskStatus {
+	case "SUCCESS":
+		status.Status = "Succeed"
+		result := v1.VideoResult{}
+		for _, video := range resp.VideoResult {
+			result.Videos = append(result.Videos, v1.VideoItem{Url: video.Url})
+		}
+		status.Results = []v1.VideoResult{result}
+		return true, status, nil
+	case "FAIL":
+		status.Status = "Failed"
+		status.Reason = "task failed"
+		return true, status, nil
+	default:
+		status.Status = "InProgress"
+		return false, status, nil
+	}
+}
+
+func (c *Client) Models(ctx context.Context) (*v1.ModelResponse, error) {
+	resp := &v1.ModelResponse{Object: "list", Data: make([]v1.Model, 0, len(Models))}
+	now := time.Now().Unix()
+	for _, model := range Models {
+		resp.Data = append(resp.Data, v1.Model{ID: model, Object: "model", Created: now, OwnedBy: "zhipu"})
+	}
+	return resp, nil
+}
+
+func (c *Client) CreateResponses(ctx context.Context, req *v1.ResponsesRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateCompletions(ctx context.Context, req *v1.CompletionsRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImageEdit(ctx context.Context, req *v1.ImageEditRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateImageVariation(ctx context.Context, req *v1.ImageVariationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateSpeech(ctx context.Context, req *v1.AudioSpeechRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateTranslation(ctx context.Context, req *v1.TranslationRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
+
+func (c *Client) CreateTranscription(ctx context.Context, req *v1.TranscriptionRequest) (io.ReadCloser, http.Header, error) {
+	return base.NoImplementMethod(ctx, req)
+}
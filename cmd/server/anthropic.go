@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	oaiadapter "github.com/jiu-u/oai-adapter"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/anthropic"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// HandleAnthropicMessages 以 Anthropic Messages 协议对外提供服务, 实际请求交给任意 Adapter 的 CreateChatCompletions
+func HandleAnthropicMessages(cl oaiadapter.Adapter) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeAnthropicError(w, http.StatusMethodNotAllowed, "invalid_request_error", "method not allowed")
+			return
+		}
+		var req v1.AnthropicMessageRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeAnthropicError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+			return
+		}
+		chatReq, err := anthropic.ConvertMessagesRequest(&req)
+		if err != nil {
+			writeAnthropicError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+			return
+		}
+		respBody, header, err := cl.CreateChatCompletions(r.Context(), chatReq)
+		if err != nil {
+			status := http.StatusBadGateway
+			var statusErr *base.StatusError
+			if errors.As(err, &statusErr) {
+				status = statusErr.StatusCode
+			}
+			writeAnthropicError(w, status, anthropic.ConvertErrorType(status), err.Error())
+			return
+		}
+		// 上游出错时可能返回 JSON 错误而非事件流
+		if req.Stream && !strings.HasPrefix(header.Get("Content-Type"), "text/event-stream") {
+			defer respBody.Close()
+			errBody, _ := io.ReadAll(respBody)
+			writeAnthropicError(w, http.StatusBadGateway, "api_error", string(errBody))
+			return
+		}
+		if req.Stream {
+			stream := anthropic.ConvertMessagesStream(respBody, req.Model)
+			defer stream.Close()
+			for k, v := range base.NewStreamHeader() {
+				w.Header().Set(k, v[0])
+			}
+			w.WriteHeader(http.StatusOK)
+			if err := copyAndFlush(w, stream); err != nil {
+				fmt.Println("error writing response:", err)
+			}
+			return
+		}
+		defer respBody.Close()
+		data, err := io.ReadAll(respBody)
+		if err != nil {
+			writeAnthropicError(w, http.StatusBadGateway, "api_error", err.Error())
+			return
+		}
+		chatResp, err := base.ParseChatResponse(data)
+		if err != nil {
+			writeAnthropicError(w, http.StatusBadGateway, "api_error", err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(anthropic.ConvertMessagesResponse(chatResp)); err != nil {
+			fmt.Println("error writing response:", err)
+		}
+	}
+}
+
+func writeAnthropicError(w http.ResponseWriter, status int, errType, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v1.AnthropicErrorResponse{
+		Type:  "error",
+		Error: v1.AnthropicError{Type: errType, Message: message},
+	})
+}
+
+// copyAndFlush 边读边写, 每次写入后立即 flush, 保证流式事件及时送达客户端
+func copyAndFlush(w http.ResponseWriter, r io.Reader) error {
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 4096)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, writeErr := w.Write(buf[:n]); writeErr != nil {
+				return writeErr
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
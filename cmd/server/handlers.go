@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	oaiadapter "github.com/jiu-u/oai-adapter"
 	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
 	"github.com/jiu-u/oai-adapter/common"
+	"github.com/jiu-u/oai-adapter/pkg/store"
 	"io"
 	"net/http"
 	"time"
@@ -56,6 +59,16 @@ func RelayHandler(cl oaiadapter.Adapter, action RelayAction) func(w http.Respons
 
 		elapsed := time.Since(startTime)
 		fmt.Printf("函数执行耗时: %s\n", elapsed)
+		// 上游返回错误状态时原样转发状态码与响应体
+		var statusErr *base.StatusError
+		if errors.As(err, &statusErr) {
+			for k, v := range respHeader {
+				w.Header().Set(k, v[0])
+			}
+			w.WriteHeader(statusErr.StatusCode)
+			_, _ = w.Write(statusErr.Body)
+			return
+		}
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
@@ -241,3 +254,40 @@ func HandleVideoStatus(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(http.StatusOK)
 }
+
+func HandleGetResponse(s store.ResponseStore) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		record, err := s.Get(r.Context(), r.PathValue("id"))
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(record.Response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+func HandleDeleteResponse(s store.ResponseStore) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		err := s.Delete(r.Context(), id)
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]any{"id": id, "object": "response", "deleted": true}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
@@ -4,16 +4,49 @@ import (
 	"flag"
 	"fmt"
 	oaiadapter "github.com/jiu-u/oai-adapter"
+	"github.com/jiu-u/oai-adapter/clients/preset"
+	"github.com/jiu-u/oai-adapter/pkg/store"
 	"github.com/joho/godotenv"
 	"net/http"
 	"os"
+	"slices"
+	"strings"
 )
 
-func GetClient() (oaiadapter.Adapter, error) {
+// GetResponseStore OAI_RESPONSE_STORE 为 memory 时使用内存存储, 否则为文件存储目录, 默认 data/responses
+func GetResponseStore() (store.ResponseStore, error) {
+	storeConfig := os.Getenv("OAI_RESPONSE_STORE")
+	switch storeConfig {
+	case "memory":
+		return store.NewMemoryStore(), nil
+	case "":
+		storeConfig = "data/responses"
+	}
+	return store.NewFileStore(storeConfig)
+}
+
+// GetEmulations 格式: embedding_rerank,responses,...
+func GetEmulations() []oaiadapter.Emulation {
+	var emulations []oaiadapter.Emulation
+	if value := os.Getenv("OAI_EMULATIONS"); value != "" {
+		for _, e := range strings.Split(value, ",") {
+			emulations = append(emulations, oaiadapter.Emulation(strings.TrimSpace(e)))
+		}
+	}
+	return emulations
+}
+
+func GetClient(emulations []oaiadapter.Emulation, responseStore store.ResponseStore) (oaiadapter.Adapter, error) {
 
 	clientType := os.Getenv("OAI_TYPE")
 	clientURL := os.Getenv("OAI_URL")
 	clientKey := os.Getenv("OAI_KEY")
+	// 可选的服务商预设文件, 支持 JSON 与 YAML
+	if presetFile := os.Getenv("OAI_PRESET_FILE"); presetFile != "" {
+		if err := preset.LoadFile(presetFile); err != nil {
+			return nil, err
+		}
+	}
 
 	// 公共配置
 	config := &oaiadapter.AdapterConfig{
@@ -21,6 +54,25 @@ func GetClient() (oaiadapter.Adapter, error) {
 		ApiKey:      clientKey,
 		EndPoint:    clientURL,
 	}
+	config.ApiVersion = os.Getenv("OAI_API_VERSION")
+	// 格式: model1=deployment1,model2=deployment2
+	if mapping := os.Getenv("OAI_MODEL_MAPPING"); mapping != "" {
+		config.ModelMapping = make(map[string]string)
+		for _, pair := range strings.Split(mapping, ",") {
+			if model, target, ok := strings.Cut(strings.TrimSpace(pair), "="); ok {
+				config.ModelMapping[model] = target
+			}
+		}
+	}
+	config.Emulations = emulations
+	config.RerankChatModel = os.Getenv("OAI_RERANK_CHAT_MODEL")
+	// 格式: model1,model2 或 *
+	if models := os.Getenv("OAI_RESPONSES_MODELS"); models != "" {
+		for _, model := range strings.Split(models, ",") {
+			config.ResponsesModels = append(config.ResponsesModels, strings.TrimSpace(model))
+		}
+	}
+	config.ResponseStore = responseStore
 	fmt.Println(config)
 
 	client := oaiadapter.NewAdapter(config)
@@ -37,7 +89,16 @@ func main() {
 			panic(err)
 		}
 	}
-	cl, err := GetClient()
+	emulations := GetEmulations()
+	// 仅在模拟 responses 时由本地存储保存对话, 否则 responses 请求直接交给上游
+	var responseStore store.ResponseStore
+	if slices.Contains(emulations, oaiadapter.EmulateResponses) {
+		var err error
+		if responseStore, err = GetResponseStore(); err != nil {
+			panic(err)
+		}
+	}
+	cl, err := GetClient(emulations, responseStore)
 	if err != nil {
 		panic(err)
 	}
@@ -46,9 +107,15 @@ func main() {
 	mux.HandleFunc("/v1/models", HandleModels(cl))
 	// responses
 	mux.HandleFunc("/v1/responses", RelayHandler(cl, Responses))
+	if responseStore != nil {
+		mux.HandleFunc("GET /v1/responses/{id}", HandleGetResponse(responseStore))
+		mux.HandleFunc("DELETE /v1/responses/{id}", HandleDeleteResponse(responseStore))
+	}
 	// completions
 	mux.HandleFunc("/v1/chat/completions", RelayHandler(cl, ChatCompletions))
 	mux.HandleFunc("/v1/completions", RelayHandler(cl, Completions))
+	// anthropic messages
+	mux.HandleFunc("/v1/messages", HandleAnthropicMessages(cl))
 	// embeddings
 	mux.HandleFunc("/v1/embeddings", RelayHandler(cl, Embeddings))
 	// rerank
@@ -6,4 +6,22 @@ const (
 	SiliconFlowDefaultURL = "https://api.siliconflow.ai"
 	XAIDefaultURL         = "https://api.x.ai"
 	GeminiDefaultURL      = "https://generativelanguage.googleapis.com"
+	AnthropicDefaultURL   = "https://api.anthropic.com"
+	OllamaDefaultURL      = "http://localhost:11434"
+	CohereDefaultURL      = "https://api.cohere.com"
+	ZhipuDefaultURL       = "https://open.bigmodel.cn"
+	DashScopeDefaultURL   = "https://dashscope.aliyuncs.com"
+	ReplicateDefaultURL   = "https://api.replicate.com"
+	StabilityDefaultURL   = "https://api.stability.ai"
+	ElevenLabsDefaultURL  = "https://api.elevenlabs.io"
+	TEIDefaultURL         = "http://localhost:8080"
+	VLLMDefaultURL        = "http://localhost:8000"
+	LlamaCppDefaultURL    = "http://localhost:8080"
+)
+
+const (
+	AnthropicVersion = "2023-06-01"
+	AzureAPIVersion  = "2024-10-21"
+	// AzureResponsesAPIVersion /openai/responses 仅在 2025-03-01-preview 及之后的 preview 版本提供
+	AzureResponsesAPIVersion = "2025-03-01-preview"
 )
@@ -14,4 +14,8 @@ const (
 	Transcriptions Mode = "transcriptions"
 	ImageEdit      Mode = "imageEdit"
 	ImageVariation Mode = "imageVariation"
+	Responses      Mode = "responses"
+	Rerank         Mode = "rerank"
+	VideoSubmit    Mode = "videoSubmit"
+	VideoStatus    Mode = "videoStatus"
 )
@@ -1,57 +1,143 @@
 package oai_adapter
 
 import (
+	"github.com/jiu-u/oai-adapter/clients/anthropic"
+	"github.com/jiu-u/oai-adapter/clients/azure"
 	"github.com/jiu-u/oai-adapter/clients/base"
-	"github.com/jiu-u/oai-adapter/clients/deepseek"
+	"github.com/jiu-u/oai-adapter/clients/bedrock"
+	"github.com/jiu-u/oai-adapter/clients/cohere"
+	"github.com/jiu-u/oai-adapter/clients/dashscope"
+	"github.com/jiu-u/oai-adapter/clients/elevenlabs"
+	"github.com/jiu-u/oai-adapter/clients/gemini_native"
 	"github.com/jiu-u/oai-adapter/clients/gemini_oai"
+	"github.com/jiu-u/oai-adapter/clients/ollama_native"
 	"github.com/jiu-u/oai-adapter/clients/ollama_oai"
 	"github.com/jiu-u/oai-adapter/clients/openai"
-	"github.com/jiu-u/oai-adapter/clients/siliconflow"
-	"github.com/jiu-u/oai-adapter/clients/xai"
+	"github.com/jiu-u/oai-adapter/clients/preset"
+	"github.com/jiu-u/oai-adapter/clients/replicate"
+	"github.com/jiu-u/oai-adapter/clients/stability"
+	"github.com/jiu-u/oai-adapter/clients/tei"
+	"github.com/jiu-u/oai-adapter/clients/vertex"
+	"github.com/jiu-u/oai-adapter/clients/vllm"
+	"github.com/jiu-u/oai-adapter/clients/zhipu"
+	"github.com/jiu-u/oai-adapter/pkg/store"
 )
 
 type AdapterConfig struct {
 	AdapterType AdapterType
 	ApiKey      string
 	EndPoint    string
+	// 以下为部分服务商的可选配置
+	ApiVersion   string            // Azure OpenAI 的 api-version
+	ModelMapping map[string]string // 模型名 -> 部署名
+	Emulations   []Emulation       // 需要模拟的接口, 见 WithEmulations
+	// chat_rerank 使用的聊天模型, 为空时使用 rerank 请求中的 model
+	RerankChatModel string
+	// OpenAI 中通过 /v1/responses 调用的 chat 模型, "*" 表示全部
+	ResponsesModels []string
+	// responses 模拟使用的对话存储, 为空时不支持 previous_response_id
+	ResponseStore store.ResponseStore
+	// Gemini 单个内联媒体超过该字节数时通过 Files API 上传, 0 使用默认值, 小于 0 关闭
+	GeminiInlineLimit int
 }
 
 type AdapterType string
 
 const (
-	OpenAI      AdapterType = "OpenAI"
+	OpenAI AdapterType = "OpenAI"
+
+	Gemini       AdapterType = "Gemini"
+	Gemini2OAI   AdapterType = "Gemini2OAI"
+	GeminiNative AdapterType = "GeminiNative"
+
+	Ollama       AdapterType = "Ollama"
+	Ollama2OAI   AdapterType = "Ollama2OAI"
+	OllamaNative AdapterType = "OllamaNative"
+
+	Anthropic AdapterType = "Anthropic"
+
+	AzureOpenAI AdapterType = "AzureOpenAI"
+	Bedrock     AdapterType = "Bedrock"
+	VertexAI    AdapterType = "VertexAI"
+	Cohere      AdapterType = "Cohere"
+	Zhipu       AdapterType = "Zhipu"
+	DashScope   AdapterType = "DashScope"
+	Replicate   AdapterType = "Replicate"
+	Stability   AdapterType = "Stability"
+	ElevenLabs  AdapterType = "ElevenLabs"
+	TEI         AdapterType = "TEI"
+	VLLM        AdapterType = "VLLM"
+	LlamaCpp    AdapterType = "LlamaCpp"
+
+	// 以下服务商由 clients/preset 中的预设构造, 也可通过 preset.LoadFile 注册任意名称
 	DeepSeek    AdapterType = "DeepSeek"
 	XAI         AdapterType = "XAI"
 	SiliconFlow AdapterType = "SiliconFlow"
-
-	Gemini     AdapterType = "Gemini"
-	Gemini2OAI AdapterType = "Gemini2OAI"
-	//GeminiNative AdapterType = "GeminiNative"
-
-	Ollama     AdapterType = "Ollama"
-	Ollama2OAI AdapterType = "Ollama2OAI"
-	//OllamaNative AdapterType = "OllamaNative"
+	Groq        AdapterType = "Groq"
+	Together    AdapterType = "Together"
+	Fireworks   AdapterType = "Fireworks"
+	OpenRouter  AdapterType = "OpenRouter"
+	Mistral     AdapterType = "Mistral"
+	Moonshot    AdapterType = "Moonshot"
+	Perplexity  AdapterType = "Perplexity"
 )
 
 func NewAdapter(config *AdapterConfig) Adapter {
+	return WithEmulations(newAdapter(config), config)
+}
+
+func newAdapter(config *AdapterConfig) Adapter {
 	switch config.AdapterType {
 	case OpenAI:
-		return openai.NewClient(config.EndPoint, config.ApiKey)
-	case DeepSeek:
-		return deepseek.NewClient(config.EndPoint, config.ApiKey)
-	case SiliconFlow:
-		return siliconflow.NewClient(config.EndPoint, config.ApiKey)
+		client := openai.NewClient(config.EndPoint, config.ApiKey)
+		client.SetResponsesModels(config.ResponsesModels...)
+		return client
 	case Gemini, Gemini2OAI:
-		return gemini_oai.NewClient(config.EndPoint, config.ApiKey)
-	//case GeminiNative:
-	//	return gemini_native.NewClient(config.EndPoint, config.ApiKey)
+		client := gemini_oai.NewClient(config.EndPoint, config.ApiKey)
+		if config.GeminiInlineLimit != 0 {
+			client.SetInlineLimit(config.GeminiInlineLimit)
+		}
+		return client
+	case GeminiNative:
+		client := gemini_native.NewClient(config.EndPoint, config.ApiKey)
+		if config.GeminiInlineLimit != 0 {
+			client.SetInlineLimit(config.GeminiInlineLimit)
+		}
+		return client
 	case Ollama, Ollama2OAI:
 		return ollama_oai.NewClient(config.EndPoint, config.ApiKey)
-	//case OllamaNative:
-	//	return ollama_native.NewClient(config.EndPoint, config.ApiKey)
-	case XAI:
-		return xai.NewClient(config.EndPoint, config.ApiKey)
+	case OllamaNative:
+		return ollama_native.NewClient(config.EndPoint, config.ApiKey)
+	case Anthropic:
+		return anthropic.NewClient(config.EndPoint, config.ApiKey)
+	case AzureOpenAI:
+		return azure.NewClient(config.EndPoint, config.ApiKey, config.ApiVersion, config.ModelMapping)
+	case Bedrock:
+		return bedrock.NewClient(config.EndPoint, config.ApiKey)
+	case VertexAI:
+		return vertex.NewClient(config.EndPoint, config.ApiKey)
+	case Cohere:
+		return cohere.NewClient(config.EndPoint, config.ApiKey)
+	case Zhipu:
+		return zhipu.NewClient(config.EndPoint, config.ApiKey)
+	case DashScope:
+		return dashscope.NewClient(config.EndPoint, config.ApiKey)
+	case Replicate:
+		return replicate.NewClient(config.EndPoint, config.ApiKey)
+	case Stability:
+		return stability.NewClient(config.EndPoint, config.ApiKey)
+	case ElevenLabs:
+		return elevenlabs.NewClient(config.EndPoint, config.ApiKey)
+	case TEI:
+		return tei.NewClient(config.EndPoint, config.ApiKey)
+	case VLLM:
+		return vllm.NewClient(config.EndPoint, config.ApiKey)
+	case LlamaCpp:
+		return vllm.NewLlamaCppClient(config.EndPoint, config.ApiKey)
 	default:
+		if p, ok := preset.Get(string(config.AdapterType)); ok {
+			return preset.NewClient(p, config.EndPoint, config.ApiKey)
+		}
 		return base.NewClient(config.EndPoint, config.ApiKey)
 	}
 }
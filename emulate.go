@@ -0,0 +1,72 @@
+package oai_adapter
+
+import (
+	"context"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/emulate"
+	"github.com/jiu-u/oai-adapter/pkg/store"
+	"io"
+	"net/http"
+)
+
+// Emulation 使用服务商已有的接口模拟其不支持的接口
+type Emulation string
+
+const (
+	// EmulateEmbeddingRerank 以 embeddings 余弦相似度实现 rerank
+	EmulateEmbeddingRerank Emulation = "embedding_rerank"
+	// EmulateChatRerank 以聊天模型 listwise 排序实现 rerank, 模型由 AdapterConfig.RerankChatModel 指定
+	EmulateChatRerank Emulation = "chat_rerank"
+	// EmulateCompletions 以 chat completions 实现 legacy completions
+	EmulateCompletions Emulation = "completions"
+	// EmulateResponses 以 chat completions 实现 Responses API, 配置 AdapterConfig.ResponseStore 后支持 previous_response_id
+	EmulateResponses Emulation = "responses"
+)
+
+type emulatedAdapter struct {
+	Adapter
+	emulations      map[Emulation]bool
+	rerankChatModel string
+	responseStore   store.ResponseStore
+}
+
+// WithEmulations 按 config.Emulations 为 adapter 开启模拟接口, 未开启的接口直接调用 adapter
+func WithEmulations(adapter Adapter, config *AdapterConfig) Adapter {
+	if len(config.Emulations) == 0 {
+		return adapter
+	}
+	a := &emulatedAdapter{
+		Adapter:         adapter,
+		emulations:      make(map[Emulation]bool, len(config.Emulations)),
+		rerankChatModel: config.RerankChatModel,
+		responseStore:   config.ResponseStore,
+	}
+	for _, e := range config.Emulations {
+		a.emulations[e] = true
+	}
+	return a
+}
+
+func (a *emulatedAdapter) CreateRerank(ctx context.Context, req *v1.RerankRequest) (io.ReadCloser, http.Header, error) {
+	switch {
+	case a.emulations[EmulateEmbeddingRerank]:
+		return emulate.EmbeddingRerank(ctx, a.Adapter, req)
+	case a.emulations[EmulateChatRerank]:
+		return emulate.ChatRerank(ctx, a.Adapter, a.rerankChatModel, req)
+	}
+	return a.Adapter.CreateRerank(ctx, req)
+}
+
+func (a *emulatedAdapter) CreateCompletions(ctx context.Context, req *v1.CompletionsRequest) (io.ReadCloser, http.Header, error) {
+	if a.emulations[EmulateCompletions] {
+		return emulate.Completions(ctx, a.Adapter, req)
+	}
+	return a.Adapter.CreateCompletions(ctx, req)
+}
+
+func (a *emulatedAdapter) CreateResponses(ctx context.Context, req *v1.ResponsesRequest) (io.ReadCloser, http.Header, error) {
+	if a.emulations[EmulateResponses] {
+		return emulate.Responses(ctx, a.Adapter, a.responseStore, req)
+	}
+	return a.Adapter.CreateResponses(ctx, req)
+}
@@ -6,6 +6,7 @@ require (
 	github.com/bytedance/sonic v1.13.2
 	github.com/joho/godotenv v1.5.1
 	github.com/oklog/ulid/v2 v2.1.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
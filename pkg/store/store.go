@@ -0,0 +1,196 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrNotFound = errors.New("response not found")
+
+// Record 保存一次 response 的本轮输入与输出, 通过 PreviousResponseID 串联成完整对话
+type Record struct {
+	ID                 string                `json:"id"`
+	PreviousResponseID string                `json:"previous_response_id,omitempty"`
+	Input              []v1.ResponsesInput   `json:"input"`
+	Response           *v1.ResponsesResponse `json:"response"`
+	CreatedAt          time.Time             `json:"created_at"`
+}
+
+// ResponseStore 按 response ID 存取记录, 记录不存在时返回 ErrNotFound
+type ResponseStore interface {
+	Get(ctx context.Context, id string) (*Record, error)
+	Put(ctx context.Context, record *Record) error
+	Delete(ctx context.Context, id string) error
+}
+
+// History 从 previousID 开始回溯, 按时间顺序返回此前各轮的输入与输出项
+func History(ctx context.Context, s ResponseStore, previousID string) ([]v1.ResponsesInput, error) {
+	var records []*Record
+	seen := make(map[string]bool)
+	for id := previousID; id != "" && !seen[id]; {
+		seen[id] = true
+		record, err := s.Get(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("get response %s error: %w", id, err)
+		}
+		records = append(records, record)
+		id = record.PreviousResponseID
+	}
+	slices.Reverse(records)
+	var inputs []v1.ResponsesInput
+	for _, record := range records {
+		inputs = append(inputs, record.Input...)
+		if record.Response == nil {
+			continue
+		}
+		for _, output := range record.Response.Output {
+			inputs = append(inputs, OutputToInput(&output))
+		}
+	}
+	return inputs, nil
+}
+
+// OutputToInput 将输出项转为下一轮的输入项
+func OutputToInput(output *v1.ResponsesOutput) v1.ResponsesInput {
+	return v1.ResponsesInput{
+		ID:               output.ID,
+		Type:             output.Type,
+		Content:          output.Content,
+		Role:             output.Role,
+		Status:           output.Status,
+		CallId:           output.CallId,
+		Arguments:        output.Arguments,
+		Name:             output.Name,
+		Output:           output.Output,
+		Summary:          output.Summary,
+		EncryptedContent: output.EncryptedContent,
+	}
+}
+
+// MemoryStore 进程内存储, 重启后丢失
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[string]*Record
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]*Record)}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.records[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return record, nil
+}
+
+func (s *MemoryStore) Put(ctx context.Context, record *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[record.ID] = record
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.records[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.records, id)
+	return nil
+}
+
+// FileStore 每条记录保存为 {dir}/{id}.json
+type FileStore struct {
+	dir string
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("mkdir error: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// path id 来自请求, 拒绝包含路径分隔符的值
+func (s *FileStore) path(id string) (string, error) {
+	if id == "" || strings.HasPrefix(id, ".") || strings.ContainsAny(id, `/\`) {
+		return "", ErrNotFound
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+func (s *FileStore) Get(ctx context.Context, id string) (*Record, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read file error: %w", err)
+	}
+	var record Record
+	if err = json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	return &record, nil
+}
+
+// Put 先写临时文件再重命名, 避免读到写了一半的记录
+func (s *FileStore) Put(ctx context.Context, record *Record) error {
+	path, err := s.path(record.ID)
+	if err != nil {
+		return fmt.Errorf("invalid response id %q", record.ID)
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal error: %w", err)
+	}
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp error: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write file error: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("write file error: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("rename error: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStore) Delete(ctx context.Context, id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("remove error: %w", err)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package task
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"sync"
 	"time"
@@ -32,6 +33,7 @@ type Result struct {
 	Metadata    map[string]interface{} // 额外元数据
 }
 
+// PollFunc 查询任务状态; completed 为 true 且 err 不为空时表示任务最终失败, 仅 err 不为空时视为临时错误继续轮询
 type PollFunc func(ctx context.Context, externalID string) (completed bool, result interface{}, err error)
 
 // Poller 定义用于轮询任务状态的接口
@@ -43,6 +45,7 @@ type Poller interface {
 // Manager 管理异步任务和轮询
 type Manager struct {
 	tasks       sync.Map      // 存储任务ID到任务结果的映射
+	mu          sync.RWMutex  // 保护 Result 字段, 轮询协程原地修改结果
 	ttl         time.Duration // 任务结果保留时间
 	pollInitial time.Duration // 初始轮询间隔
 	pollMax     time.Duration // 最大轮询间隔
@@ -118,7 +121,7 @@ func (tm *Manager) startPolling(ctx context.Context, taskID string, poller Polle
 		return
 	}
 	result := resultVal.(*Result)
-	externalID := result.ExternalID
+	externalID := result.ExternalID // 创建后不再修改
 
 	// 设置初始轮询间隔
 	interval := tm.pollInitial
@@ -126,8 +129,7 @@ func (tm *Manager) startPolling(ctx context.Context, taskID string, poller Polle
 	// 开始轮询循环
 	for {
 		// 检查是否应该停止轮询
-		resultVal, exists := tm.tasks.Load(taskID)
-		if !exists || resultVal.(*Result).StopPolling {
+		if _, exists := tm.tasks.Load(taskID); !exists || tm.stopped(result) {
 			return
 		}
 
@@ -141,32 +143,42 @@ func (tm *Manager) startPolling(ctx context.Context, taskID string, poller Polle
 		}
 
 		// 更新任务状态为处理中
-		result := resultVal.(*Result)
+		tm.mu.Lock()
 		if result.Status == StatusPending {
 			result.Status = StatusProcessing
 			result.UpdatedAt = time.Now()
-			tm.tasks.Store(taskID, result)
 		}
+		tm.mu.Unlock()
 
 		// 调用轮询器查询任务状态
 		completed, data, err := poller.PollTask(pollCtx, externalID)
 
+		tm.mu.Lock()
 		// 更新最后查询时间
 		result.UpdatedAt = time.Now()
-
-		if err != nil {
-			// 轮询出错，但不一定表示任务失败，可能只是临时网络问题
-			// 记录错误但继续轮询
-			log.Printf("Error polling task %s: %v", taskID, err)
+		if err != nil && completed {
+			// 轮询器确认任务已失败，停止轮询
+			result.CompletedAt = time.Now()
+			result.Data = data
+			result.Error = err
+			result.Status = StatusFailed
+			result.StopPolling = true
 		} else if completed {
 			// 任务已完成，更新状态并停止轮询
 			result.CompletedAt = time.Now()
 			result.Data = data
 			result.Status = StatusCompleted
 			result.StopPolling = true
-			tm.tasks.Store(taskID, result)
+		}
+		tm.mu.Unlock()
+		if completed {
 			return
 		}
+		if err != nil {
+			// 轮询出错，但不一定表示任务失败，可能只是临时网络问题
+			// 记录错误但继续轮询
+			log.Printf("Error polling task %s: %v", taskID, err)
+		}
 
 		// 使用指数退避增加轮询间隔
 		interval = time.Duration(float64(interval) * 1.5)
@@ -176,14 +188,23 @@ func (tm *Manager) startPolling(ctx context.Context, taskID string, poller Polle
 	}
 }
 
-// GetTaskResult 获取任务结果
+func (tm *Manager) stopped(result *Result) bool {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	return result.StopPolling
+}
+
+// GetTaskResult 获取任务结果的快照, 轮询协程之后的更新不会反映到返回值上
 func (tm *Manager) GetTaskResult(taskID string) (*Result, bool) {
 	resultVal, exists := tm.tasks.Load(taskID)
 	if !exists {
 		return nil, false
 	}
 
-	return resultVal.(*Result), true
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	snapshot := *resultVal.(*Result)
+	return &snapshot, true
 }
 
 // CancelTask 取消任务轮询
@@ -193,9 +214,9 @@ func (tm *Manager) CancelTask(taskID string) bool {
 		return false
 	}
 
-	result := resultVal.(*Result)
-	result.StopPolling = true
-	tm.tasks.Store(taskID, result)
+	tm.mu.Lock()
+	resultVal.(*Result).StopPolling = true
+	tm.mu.Unlock()
 	return true
 }
 
@@ -208,6 +229,7 @@ func (tm *Manager) CleanExpiredTasks() {
 		select {
 		case <-ticker.C:
 			now := time.Now()
+			tm.mu.RLock()
 			tm.tasks.Range(func(key, value interface{}) bool {
 				result := value.(*Result)
 				// 如果任务已完成或失败，且超过TTL时间，则删除
@@ -217,8 +239,31 @@ func (tm *Manager) CleanExpiredTasks() {
 				}
 				return true
 			})
+			tm.mu.RUnlock()
 		case <-tm.ctx.Done():
 			return
 		}
 	}
 }
+
+// Wait 阻塞等待任务结束, 用于需要同步返回结果的异步接口, ctx 结束时取消轮询
+// 只读取 GetTaskResult 返回的快照, 不访问轮询协程正在修改的结果
+func Wait(ctx context.Context, tm TaskManager, taskID string, interval time.Duration) (*Result, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		result, exists := tm.GetTaskResult(taskID)
+		if !exists {
+			return nil, fmt.Errorf("task %s not found", taskID)
+		}
+		if result.Status == StatusCompleted || result.Status == StatusFailed {
+			return result, nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			tm.CancelTask(taskID)
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/anthropic"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConvertChatRequest(t *testing.T) {
+	req := &v1.ChatCompletionRequest{
+		Model: "claude-sonnet-4-0",
+		Messages: []v1.Message{
+			{Role: "system", Content: json.RawMessage(`"be brief"`)},
+			{Role: "user", Content: json.RawMessage(`[{"type":"text","text":"what is this?"},{"type":"image_url","image_url":{"url":"data:image/png;base64,aGVsbG8="}}]`)},
+			{Role: "assistant", Content: json.RawMessage(`null`), ToolCalls: []v1.ToolCall{
+				{Id: "toolu_1", Type: "function", Function: v1.Function{Name: "lookup", Arguments: `{"q":"x"}`}},
+			}},
+			{Role: "tool", ToolCallId: "toolu_1", Content: json.RawMessage(`"42"`)},
+		},
+		Stop:       []any{"END"},
+		ToolChoice: "required",
+		Tools: []v1.Tool{
+			{Type: "function", Function: v1.Function{Name: "lookup", Parameters: json.RawMessage(`{"type":"object"}`)}},
+		},
+	}
+	newReq, err := anthropic.ConvertChatRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newReq.MaxTokens != anthropic.DefaultMaxTokens {
+		t.Errorf("max_tokens = %d", newReq.MaxTokens)
+	}
+	if string(newReq.System) != `[{"type":"text","text":"be brief"}]` {
+		t.Errorf("system = %s", newReq.System)
+	}
+	if len(newReq.Messages) != 3 {
+		t.Fatalf("messages = %d", len(newReq.Messages))
+	}
+	userContents, _ := newReq.Messages[0].ParseContent()
+	if len(userContents) != 2 || userContents[1].Source == nil || userContents[1].Source.MediaType != "image/png" {
+		t.Errorf("user contents = %+v", userContents)
+	}
+	assistantContents, _ := newReq.Messages[1].ParseContent()
+	if len(assistantContents) != 1 || assistantContents[0].Type != v1.AnthropicContentTypeToolUse {
+		t.Errorf("assistant contents = %+v", assistantContents)
+	}
+	toolContents, _ := newReq.Messages[2].ParseContent()
+	if newReq.Messages[2].Role != "user" || toolContents[0].ToolUseID != "toolu_1" {
+		t.Errorf("tool result = %+v", toolContents)
+	}
+	if newReq.ToolChoice == nil || newReq.ToolChoice.Type != "any" {
+		t.Errorf("tool_choice = %+v", newReq.ToolChoice)
+	}
+	if len(newReq.StopSequences) != 1 || newReq.StopSequences[0] != "END" {
+		t.Errorf("stop_sequences = %v", newReq.StopSequences)
+	}
+}
+
+func TestCreateChatCompletions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" || r.Header.Get("x-api-key") != "sk-test" || r.Header.Get("anthropic-version") == "" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude","content":[{"type":"text","text":"hi"},{"type":"tool_use","id":"toolu_1","name":"lookup","input":{"q":"x"}}],"stop_reason":"tool_use","usage":{"input_tokens":10,"output_tokens":5}}`)
+	}))
+	defer server.Close()
+
+	client := anthropic.NewClient(server.URL, "sk-test")
+	body, _, err := client.CreateChatCompletions(context.Background(), &v1.ChatCompletionRequest{
+		Model:    "claude",
+		Messages: []v1.Message{{Role: "user", Content: json.RawMessage(`"hello"`)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	var resp v1.ChatCompletionResponse
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	choice := resp.Choices[0]
+	if choice.FinishReason != "tool_calls" || choice.Message.StringContent() != "hi" {
+		t.Errorf("choice = %+v", choice)
+	}
+	if len(choice.Message.ToolCalls) != 1 || choice.Message.ToolCalls[0].Function.Arguments != `{"q":"x"}` {
+		t.Errorf("tool_calls = %+v", choice.Message.ToolCalls)
+	}
+	if resp.Usage.TotalTokens != 15 {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+}
+
+func TestCreateChatCompletionsStream(t *testing.T) {
+	events := []string{
+		`event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude","content":[],"usage":{"input_tokens":10,"output_tokens":1}}}`,
+		`event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hel"}}`,
+		`event: ping
+data: {"type": "ping"}`,
+		`event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"lo"}}`,
+		`event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"lookup","input":{}}}`,
+		`event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"q\":"}}`,
+		`event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"x\"}"}}`,
+		`event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":7}}`,
+		`event: message_stop
+data: {"type":"message_stop"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, strings.Join(events, "\n\n")+"\n\n")
+	}))
+	defer server.Close()
+
+	client := anthropic.NewClient(server.URL, "sk-test")
+	body, _, err := client.CreateChatCompletions(context.Background(), &v1.ChatCompletionRequest{
+		Model:         "claude",
+		Stream:        true,
+		StreamOptions: &v1.StreamOptions{IncludeUsage: true},
+		Messages:      []v1.Message{{Role: "user", Content: json.RawMessage(`"hello"`)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content, arguments, finishReason string
+	var usage v1.Usage
+	lines := strings.Split(string(data), "\n\n")
+	if strings.TrimSpace(lines[len(lines)-2]) != "data: [DONE]" {
+		t.Errorf("missing [DONE]: %s", data)
+	}
+	for _, line := range lines {
+		payload, ok := strings.CutPrefix(strings.TrimSpace(line), "data: ")
+		if !ok || payload == "[DONE]" {
+			continue
+		}
+		var chunk v1.ChatCompletionStreamResponse
+		if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+			t.Fatal(err)
+		}
+		if chunk.Usage.TotalTokens > 0 {
+			usage = chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			content += choice.Delta.Content
+			for _, toolCall := range choice.Delta.ToolCalls {
+				if toolCall.Index == nil || *toolCall.Index != 0 {
+					t.Errorf("tool call index = %v", toolCall.Index)
+				}
+				arguments += toolCall.Function.Arguments
+			}
+			if choice.FinishReason != "" {
+				finishReason = choice.FinishReason
+			}
+		}
+	}
+	if content != "Hello" || arguments != `{"q":"x"}` || finishReason != "tool_calls" {
+		t.Errorf("content=%q arguments=%q finish=%q", content, arguments, finishReason)
+	}
+	if usage.PromptTokens != 10 || usage.CompletionTokens != 7 {
+		t.Errorf("usage = %+v", usage)
+	}
+}
+
+func TestConvertStreamResponseTruncated(t *testing.T) {
+	body := "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"type\":\"message\",\"role\":\"assistant\",\"model\":\"claude\",\"content\":[]}}\n\n" +
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hel\"}}\n\n"
+	data, err := io.ReadAll(anthropic.ConvertStreamResponse(io.NopCloser(strings.NewReader(body)), false))
+	if err != io.ErrUnexpectedEOF || strings.Contains(string(data), "[DONE]") {
+		t.Errorf("data = %s, err = %v", data, err)
+	}
+}
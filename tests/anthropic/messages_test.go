@@ -0,0 +1,141 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	oaiadapter "github.com/jiu-u/oai-adapter"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/anthropic"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConvertMessagesRequest(t *testing.T) {
+	var req v1.AnthropicMessageRequest
+	err := json.Unmarshal([]byte(`{
+		"model": "deepseek-chat",
+		"max_tokens": 1024,
+		"system": "be brief",
+		"stream": true,
+		"messages": [
+			{"role": "user", "content": "weather?"},
+			{"role": "assistant", "content": [{"type": "text", "text": "checking"}, {"type": "tool_use", "id": "toolu_1", "name": "weather", "input": {"city": "Paris"}}]},
+			{"role": "user", "content": [{"type": "tool_result", "tool_use_id": "toolu_1", "content": [{"type": "text", "text": "sunny"}]}, {"type": "text", "text": "thanks"}]}
+		],
+		"tools": [{"name": "weather", "input_schema": {"type": "object"}}],
+		"tool_choice": {"type": "tool", "name": "weather"}
+	}`), &req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chatReq, err := anthropic.ConvertMessagesRequest(&req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roles := make([]string, 0, len(chatReq.Messages))
+	for _, msg := range chatReq.Messages {
+		roles = append(roles, msg.Role)
+	}
+	if strings.Join(roles, ",") != "system,user,assistant,tool,user" {
+		t.Fatalf("roles = %v", roles)
+	}
+	if chatReq.Messages[2].ToolCalls[0].Function.Arguments != `{"city": "Paris"}` {
+		t.Errorf("tool call = %+v", chatReq.Messages[2].ToolCalls[0])
+	}
+	if chatReq.Messages[3].StringContent() != "sunny" || chatReq.Messages[3].ToolCallId != "toolu_1" {
+		t.Errorf("tool message = %+v", chatReq.Messages[3])
+	}
+	if choiceType, name := chatReq.ParseToolChoice(); choiceType != v1.ToolChoiceFunction || name != "weather" {
+		t.Errorf("tool_choice = %v", chatReq.ToolChoice)
+	}
+	if chatReq.StreamOptions == nil || !chatReq.StreamOptions.IncludeUsage {
+		t.Errorf("stream_options = %+v", chatReq.StreamOptions)
+	}
+}
+
+func TestConvertMessagesStream(t *testing.T) {
+	chunks := []string{
+		`{"id":"chatcmpl-1","object":"chat.completion.chunk","model":"m","choices":[{"index":0,"delta":{"role":"assistant","content":"Hi"},"finish_reason":null}]}`,
+		`{"id":"chatcmpl-1","object":"chat.completion.chunk","model":"m","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"weather","arguments":""}}]},"finish_reason":null}]}`,
+		`{"id":"chatcmpl-1","object":"chat.completion.chunk","model":"m","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":\"Paris\"}"}}]},"finish_reason":null}]}`,
+		`{"id":"chatcmpl-1","object":"chat.completion.chunk","model":"m","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+		`{"id":"chatcmpl-1","object":"chat.completion.chunk","model":"m","choices":[],"usage":{"prompt_tokens":12,"completion_tokens":8,"total_tokens":20}}`,
+	}
+	body := "data: " + strings.Join(chunks, "\n\ndata: ") + "\n\ndata: [DONE]\n\n"
+	stream := anthropic.ConvertMessagesStream(io.NopCloser(strings.NewReader(body)), "m")
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []string
+	var stopReason, partialJson string
+	var outputTokens int
+	for _, block := range strings.Split(strings.TrimSpace(string(data)), "\n\n") {
+		lines := strings.SplitN(block, "\n", 2)
+		event := strings.TrimPrefix(lines[0], "event: ")
+		events = append(events, event)
+		var ev v1.AnthropicStreamEvent
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &ev); err != nil {
+			t.Fatal(err)
+		}
+		if ev.Type != event {
+			t.Errorf("event %s has type %s", event, ev.Type)
+		}
+		if ev.Delta != nil {
+			partialJson += ev.Delta.PartialJson
+			if ev.Delta.StopReason != "" {
+				stopReason = ev.Delta.StopReason
+				outputTokens = ev.Usage.OutputTokens
+			}
+		}
+	}
+	expected := "message_start,ping,content_block_start,content_block_delta,content_block_stop,content_block_start,content_block_delta,content_block_stop,message_delta,message_stop"
+	if strings.Join(events, ",") != expected {
+		t.Errorf("events = %v", events)
+	}
+	if stopReason != "tool_use" || outputTokens != 8 || partialJson != `{"city":"Paris"}` {
+		t.Errorf("stop=%s output=%d json=%s", stopReason, outputTokens, partialJson)
+	}
+}
+
+func TestConvertMessagesStreamUpstreamError(t *testing.T) {
+	body := `{"error":{"message":"invalid api key","type":"invalid_request_error"}}`
+	stream := anthropic.ConvertMessagesStream(io.NopCloser(strings.NewReader(body)), "m")
+	data, err := io.ReadAll(stream)
+	if err == nil {
+		t.Error("expected stream error")
+	}
+	if !strings.HasPrefix(string(data), "event: error\n") || strings.Contains(string(data), "message_stop") {
+		t.Errorf("data = %s", data)
+	}
+}
+
+func TestMessagesUpstreamStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		io.WriteString(w, `{"error":{"message":"slow down","type":"rate_limit_exceeded"}}`)
+	}))
+	defer server.Close()
+	adapter := oaiadapter.NewAdapter(&oaiadapter.AdapterConfig{AdapterType: oaiadapter.OpenAI, EndPoint: server.URL})
+	chatReq, err := anthropic.ConvertMessagesRequest(&v1.AnthropicMessageRequest{
+		Model: "m", MaxTokens: 16, Stream: true,
+		Messages: []v1.AnthropicMessage{{Role: "user", Content: json.RawMessage(`"hi"`)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = adapter.CreateChatCompletions(context.Background(), chatReq)
+	var statusErr *base.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("err = %v", err)
+	}
+	if errType := anthropic.ConvertErrorType(statusErr.StatusCode); errType != "rate_limit_error" {
+		t.Errorf("error type = %s", errType)
+	}
+}
@@ -0,0 +1,91 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/azure"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDeploymentRouting(t *testing.T) {
+	var gotPath, gotVersion, gotKey, gotAuth, gotModel string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotVersion = r.URL.Query().Get("api-version")
+		gotKey = r.Header.Get("api-key")
+		gotAuth = r.Header.Get("Authorization")
+		var body struct {
+			Model string `json:"model"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		gotModel = body.Model
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{}`)
+	}))
+	defer server.Close()
+
+	client := azure.NewClient(server.URL, "secret", "2024-10-21", map[string]string{"gpt-4o": "prod-gpt4o"})
+	_, _, err := client.CreateChatCompletions(context.Background(), &v1.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []v1.Message{{Role: "user", Content: json.RawMessage(`"hi"`)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/openai/deployments/prod-gpt4o/chat/completions" || gotVersion != "2024-10-21" {
+		t.Errorf("path=%s api-version=%s", gotPath, gotVersion)
+	}
+	if gotKey != "secret" || gotAuth != "" {
+		t.Errorf("api-key=%q authorization=%q", gotKey, gotAuth)
+	}
+
+	_, _, err = client.CreateEmbeddings(context.Background(), &v1.EmbeddingsRequest{Model: "text-embedding-3-small", Input: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/openai/deployments/text-embedding-3-small/embeddings" {
+		t.Errorf("unmapped model path=%s", gotPath)
+	}
+
+	_, _, err = client.CreateResponses(context.Background(), &v1.ResponsesRequest{Model: "gpt-4o"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 默认 api-version 不支持 responses, 改用 preview 版本
+	if gotPath != "/openai/responses" || gotModel != "prod-gpt4o" || gotVersion != "2025-03-01-preview" {
+		t.Errorf("responses path=%s model=%s api-version=%s", gotPath, gotModel, gotVersion)
+	}
+
+	client.APIVersion = "2025-04-01-preview"
+	if _, _, err = client.CreateResponses(context.Background(), &v1.ResponsesRequest{Model: "gpt-4o"}); err != nil {
+		t.Fatal(err)
+	}
+	if gotVersion != "2025-04-01-preview" {
+		t.Errorf("responses api-version=%s", gotVersion)
+	}
+}
+
+func TestEntraToken(t *testing.T) {
+	var gotKey, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("api-key")
+		gotAuth = r.Header.Get("Authorization")
+		io.WriteString(w, `{}`)
+	}))
+	defer server.Close()
+
+	client := azure.NewClientWithTokenProvider(server.URL, func(ctx context.Context) (string, error) {
+		return "entra-token", nil
+	}, "", nil)
+	_, _, err := client.CreateImage(context.Background(), &v1.ImageGenerateRequest{Model: "dall-e-3", Prompt: "cat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotAuth != "Bearer entra-token" || gotKey != "" {
+		t.Errorf("api-key=%q authorization=%q", gotKey, gotAuth)
+	}
+}
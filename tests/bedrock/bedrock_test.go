@@ -0,0 +1,175 @@
+package bedrock
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/bedrock"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// AWS SigV4 测试套件 get-vanilla 与 get-vanilla-query-order-key-case
+func TestSigV4(t *testing.T) {
+	signer := &bedrock.Signer{
+		Credentials: bedrock.Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"},
+		Region:      "us-east-1",
+		Service:     "service",
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	cases := map[string]string{
+		"https://example.amazonaws.com/":                             "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		"https://example.amazonaws.com/?Param2=value2&Param1=value1": "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+	}
+	for targetUrl, signature := range cases {
+		header := http.Header{}
+		if err := signer.Sign(http.MethodGet, targetUrl, header, nil, now); err != nil {
+			t.Fatal(err)
+		}
+		want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=" + signature
+		if got := header.Get("Authorization"); got != want {
+			t.Errorf("%s\n got %s\nwant %s", targetUrl, got, want)
+		}
+	}
+}
+
+func encodeEvent(headers map[string]string, payload string) []byte {
+	var headerBuf bytes.Buffer
+	for name, value := range headers {
+		headerBuf.WriteByte(byte(len(name)))
+		headerBuf.WriteString(name)
+		headerBuf.WriteByte(7)
+		_ = binary.Write(&headerBuf, binary.BigEndian, uint16(len(value)))
+		headerBuf.WriteString(value)
+	}
+	totalLength := 12 + headerBuf.Len() + len(payload) + 4
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.BigEndian, uint32(totalLength))
+	_ = binary.Write(&buf, binary.BigEndian, uint32(headerBuf.Len()))
+	_ = binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(buf.Bytes()))
+	buf.Write(headerBuf.Bytes())
+	buf.WriteString(payload)
+	_ = binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(buf.Bytes()))
+	return buf.Bytes()
+}
+
+func event(eventType, payload string) []byte {
+	return encodeEvent(map[string]string{":event-type": eventType, ":message-type": "event", ":content-type": "application/json"}, payload)
+}
+
+func TestCreateChatCompletionsStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/model/anthropic.claude-3-haiku-20240307-v1%3A0/converse-stream" ||
+			!strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AK/") ||
+			r.Header.Get("X-Amz-Security-Token") != "token" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		var req bedrock.ConverseRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.System) != 1 || req.Messages[0].Role != "user" {
+			http.Error(w, "bad body", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+		w.Write(event("messageStart", `{"role":"assistant"}`))
+		w.Write(event("contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"Hel"}}`))
+		w.Write(event("contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"lo"}}`))
+		w.Write(event("contentBlockStart", `{"contentBlockIndex":1,"start":{"toolUse":{"toolUseId":"tu_1","name":"weather"}}}`))
+		w.Write(event("contentBlockDelta", `{"contentBlockIndex":1,"delta":{"toolUse":{"input":"{\"city\":"}}}`))
+		w.Write(event("contentBlockDelta", `{"contentBlockIndex":1,"delta":{"toolUse":{"input":"\"Paris\"}"}}}`))
+		w.Write(event("messageStop", `{"stopReason":"tool_use"}`))
+		w.Write(event("metadata", `{"usage":{"inputTokens":5,"outputTokens":3,"totalTokens":8}}`))
+	}))
+	defer server.Close()
+
+	client := bedrock.NewClientWithCredentials(server.URL, "us-west-2", bedrock.Credentials{AccessKeyID: "AK", SecretAccessKey: "SK", SessionToken: "token"})
+	body, _, err := client.CreateChatCompletions(context.Background(), &v1.ChatCompletionRequest{
+		Model:         "anthropic.claude-3-haiku-20240307-v1:0",
+		Stream:        true,
+		StreamOptions: &v1.StreamOptions{IncludeUsage: true},
+		Messages: []v1.Message{
+			{Role: "system", Content: json.RawMessage(`"be brief"`)},
+			{Role: "user", Content: json.RawMessage(`"hello"`)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content, arguments, finishReason string
+	var usage v1.Usage
+	for _, line := range strings.Split(string(data), "\n\n") {
+		payload, ok := strings.CutPrefix(line, "data: ")
+		if !ok || payload == "[DONE]" {
+			continue
+		}
+		var chunk v1.ChatCompletionStreamResponse
+		if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+			t.Fatal(err)
+		}
+		if chunk.Usage.TotalTokens > 0 {
+			usage = chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			content += choice.Delta.Content
+			for _, toolCall := range choice.Delta.ToolCalls {
+				arguments += toolCall.Function.Arguments
+			}
+			if choice.FinishReason != "" {
+				finishReason = choice.FinishReason
+			}
+		}
+	}
+	if content != "Hello" || arguments != `{"city":"Paris"}` || finishReason != "tool_calls" {
+		t.Errorf("content=%q arguments=%q finish=%q", content, arguments, finishReason)
+	}
+	if usage.TotalTokens != 8 {
+		t.Errorf("usage = %+v", usage)
+	}
+}
+
+func TestEventStreamCRC(t *testing.T) {
+	frame := event("messageStart", `{"role":"assistant"}`)
+	frame[len(frame)-1] ^= 0xff
+	if _, err := bedrock.NewEventStreamDecoder(bytes.NewReader(frame)).Decode(); err == nil {
+		t.Error("expected crc error")
+	}
+}
+
+func TestConvertChatRequestToolChoiceNone(t *testing.T) {
+	var req v1.ChatCompletionRequest
+	err := json.Unmarshal([]byte(`{
+		"model": "anthropic.claude-3-5-sonnet",
+		"messages": [
+			{"role": "user", "content": "weather?"},
+			{"role": "assistant", "content": null, "tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "sunny"}
+		],
+		"tools": [{"type": "function", "function": {"name": "get_weather", "parameters": {"type": "object"}}}],
+		"tool_choice": "none"
+	}`), &req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newReq, err := bedrock.ConvertChatRequest(&req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newReq.Messages[1].Content[0].ToolUse == nil || newReq.Messages[2].Content[0].ToolResult == nil {
+		t.Fatalf("messages = %+v", newReq.Messages)
+	}
+	// 历史中有 toolUse/toolResult 时 Converse 要求携带 toolConfig
+	if newReq.ToolConfig == nil || len(newReq.ToolConfig.Tools) != 1 || newReq.ToolConfig.ToolChoice != nil {
+		t.Errorf("tool_config = %+v", newReq.ToolConfig)
+	}
+}
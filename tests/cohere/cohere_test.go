@@ -0,0 +1,144 @@
+package cohere
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/cohere"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCreateRerank(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req cohere.RerankRequest
+		if r.URL.Path != "/v2/rerank" || json.NewDecoder(r.Body).Decode(&req) != nil || req.TopN != 1 || len(req.Documents) != 2 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, `{"id":"r1","results":[{"index":1,"relevance_score":0.9}],"meta":{"billed_units":{"search_units":1}}}`)
+	}))
+	defer server.Close()
+
+	client := cohere.NewClient(server.URL, "key")
+	body, _, err := client.CreateRerank(context.Background(), &v1.RerankRequest{
+		Model:           "rerank-v3.5",
+		Query:           "capital of France",
+		Documents:       []string{"Berlin", "Paris"},
+		TopN:            1,
+		ReturnDocuments: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp v1.RerankResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].Index != 1 || resp.Results[0].Document.Text != "Paris" {
+		t.Errorf("results = %+v", resp.Results)
+	}
+}
+
+func TestCreateEmbeddings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req cohere.EmbedRequest
+		if r.URL.Path != "/v2/embed" || json.NewDecoder(r.Body).Decode(&req) != nil || req.InputType != "search_query" || req.EmbeddingTypes[0] != "float" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, `{"id":"e1","embeddings":{"float":[[0.1,0.2]]},"meta":{"billed_units":{"input_tokens":3}}}`)
+	}))
+	defer server.Close()
+
+	client := cohere.NewClient(server.URL, "key")
+	body, _, err := client.CreateEmbeddings(context.Background(), &v1.EmbeddingsRequest{Model: "embed-v4.0", Input: "hi", InputType: "search_query"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp v1.EmbeddingsResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 1 || len(resp.Data[0].Embedding) != 2 || resp.Usage.PromptTokens != 3 {
+		t.Errorf("response = %+v", resp)
+	}
+
+	body, _, err = client.CreateEmbeddings(context.Background(), &v1.EmbeddingsRequest{Model: "embed-v4.0", Input: "hi", InputType: "search_query", EncodingFormat: "base64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b64Resp struct {
+		Data []struct {
+			Embedding string `json:"embedding"`
+		} `json:"data"`
+	}
+	if err = json.NewDecoder(body).Decode(&b64Resp); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(b64Resp.Data[0].Embedding)
+	if err != nil || len(raw) != 8 || math.Float32frombits(binary.LittleEndian.Uint32(raw[4:])) != float32(0.2) {
+		t.Errorf("embedding = %s, err = %v", b64Resp.Data[0].Embedding, err)
+	}
+}
+
+func TestCreateChatCompletionsStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/chat" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "event: message-start\ndata: {\"id\":\"c1\",\"type\":\"message-start\",\"delta\":{\"message\":{\"role\":\"assistant\"}}}\n\n")
+		io.WriteString(w, "event: content-delta\ndata: {\"type\":\"content-delta\",\"index\":0,\"delta\":{\"message\":{\"content\":{\"text\":\"Hi\"}}}}\n\n")
+		io.WriteString(w, "event: tool-call-start\ndata: {\"type\":\"tool-call-start\",\"index\":0,\"delta\":{\"message\":{\"tool_calls\":{\"id\":\"t1\",\"type\":\"function\",\"function\":{\"name\":\"weather\",\"arguments\":\"\"}}}}}\n\n")
+		io.WriteString(w, "event: tool-call-delta\ndata: {\"type\":\"tool-call-delta\",\"index\":0,\"delta\":{\"message\":{\"tool_calls\":{\"function\":{\"arguments\":\"{}\"}}}}}\n\n")
+		io.WriteString(w, "event: message-end\ndata: {\"type\":\"message-end\",\"delta\":{\"finish_reason\":\"TOOL_CALL\",\"usage\":{\"tokens\":{\"input_tokens\":4,\"output_tokens\":2}}}}\n\n")
+	}))
+	defer server.Close()
+
+	client := cohere.NewClient(server.URL, "key")
+	body, _, err := client.CreateChatCompletions(context.Background(), &v1.ChatCompletionRequest{
+		Model:         "command-r-plus",
+		Stream:        true,
+		StreamOptions: &v1.StreamOptions{IncludeUsage: true},
+		Messages:      []v1.Message{{Role: "user", Content: json.RawMessage(`"hello"`)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(body)
+	var content, arguments, finishReason string
+	var usage v1.Usage
+	for _, line := range strings.Split(string(data), "\n\n") {
+		payload, ok := strings.CutPrefix(line, "data: ")
+		if !ok || payload == "[DONE]" {
+			continue
+		}
+		var chunk v1.ChatCompletionStreamResponse
+		if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+			t.Fatal(err)
+		}
+		if chunk.Usage.TotalTokens > 0 {
+			usage = chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			content += choice.Delta.Content
+			for _, toolCall := range choice.Delta.ToolCalls {
+				arguments += toolCall.Function.Arguments
+			}
+			if choice.FinishReason != "" {
+				finishReason = choice.FinishReason
+			}
+		}
+	}
+	if content != "Hi" || arguments != "{}" || finishReason != "tool_calls" || usage.TotalTokens != 6 {
+		t.Errorf("content=%q arguments=%q finish=%q usage=%+v", content, arguments, finishReason, usage)
+	}
+}
@@ -0,0 +1,79 @@
+package dashscope
+
+import (
+	"context"
+	"encoding/json"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/dashscope"
+	"github.com/jiu-u/oai-adapter/pkg/task"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreateImageAsync(t *testing.T) {
+	polls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/services/aigc/text2image/image-synthesis", func(w http.ResponseWriter, r *http.Request) {
+		var req dashscope.ImageSynthesisRequest
+		if r.Header.Get("X-DashScope-Async") != "enable" || json.NewDecoder(r.Body).Decode(&req) != nil || req.Parameters.Size != "1024*1024" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, `{"request_id":"r1","output":{"task_id":"t1","task_status":"PENDING"}}`)
+	})
+	mux.HandleFunc("/api/v1/tasks/t1", func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		if polls < 2 {
+			io.WriteString(w, `{"output":{"task_id":"t1","task_status":"RUNNING"}}`)
+			return
+		}
+		io.WriteString(w, `{"output":{"task_id":"t1","task_status":"SUCCEEDED","results":[{"url":"https://example.com/1.png"},{"code":"DataInspectionFailed"}]}}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tm := task.NewTaskManager(time.Minute, 10*time.Millisecond, 20*time.Millisecond)
+	defer tm.Close()
+	client := dashscope.NewClient(server.URL, "key")
+	client.SetTaskManager(tm)
+	dashscope.WaitInterval = 10 * time.Millisecond
+
+	body, _, err := client.CreateImage(context.Background(), &v1.ImageGenerateRequest{Model: "wanx2.1-t2i-turbo", Prompt: "a cat", Size: "1024x1024", N: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp v1.ImageGenerateResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].URL != "https://example.com/1.png" {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestChatCompatibleMode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/compatible-mode/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, `{"id":"1","object":"chat.completion","choices":[]}`)
+	}))
+	defer server.Close()
+
+	client := dashscope.NewClient(server.URL, "key")
+	body, _, err := client.CreateChatCompletions(context.Background(), &v1.ChatCompletionRequest{Model: "qwen-plus"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp v1.ChatCompletionResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != "1" {
+		t.Errorf("response = %+v", resp)
+	}
+}
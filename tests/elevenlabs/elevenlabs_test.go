@@ -0,0 +1,95 @@
+package elevenlabs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/elevenlabs"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCreateSpeech(t *testing.T) {
+	voiceCalls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/voices", func(w http.ResponseWriter, r *http.Request) {
+		voiceCalls++
+		io.WriteString(w, `{"voices":[{"voice_id":"21m00Tcm4TlvDq8ikWAM","name":"Rachel"}]}`)
+	})
+	mux.HandleFunc("/v1/text-to-speech/21m00Tcm4TlvDq8ikWAM", func(w http.ResponseWriter, r *http.Request) {
+		var req elevenlabs.SpeechRequest
+		if r.Header.Get("xi-api-key") != "key" || r.Header.Get("Authorization") != "" || r.URL.Query().Get("output_format") != "opus_48000_128" || json.NewDecoder(r.Body).Decode(&req) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if req.VoiceSettings == nil || req.VoiceSettings.Speed != 1.2 || req.ModelId != elevenlabs.DefaultSpeechModel || req.PreviousText != "cheerful" {
+			http.Error(w, "bad fields", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("audio"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := elevenlabs.NewClient(server.URL, "key")
+	for i := 0; i < 2; i++ {
+		body, header, err := client.CreateSpeech(context.Background(), &v1.AudioSpeechRequest{
+			Model: "tts-1", Voice: "rachel", Input: "hello", Speed: 2, Instructions: "cheerful", ResponseFormat: "opus",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(body)
+		if string(data) != "audio" || header.Get("Content-Type") != "audio/opus" {
+			t.Errorf("data = %s, header = %v", data, header)
+		}
+	}
+	if voiceCalls != 1 {
+		t.Errorf("voices should be cached, calls = %d", voiceCalls)
+	}
+}
+
+func TestCreateTranscriptionVerbose(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/speech-to-text" || r.ParseMultipartForm(1<<20) != nil || r.FormValue("model_id") != "scribe_v1" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, `{"language_code":"en","text":"Hi there. Bye.","words":[
+			{"text":"Hi","start":0,"end":0.3,"type":"word"},
+			{"text":" ","start":0.3,"end":0.35,"type":"spacing"},
+			{"text":"there.","start":0.35,"end":0.8,"type":"word"},
+			{"text":" ","start":0.8,"end":1.0,"type":"spacing"},
+			{"text":"Bye.","start":1.0,"end":1.4,"type":"word"}]}`)
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, _ := writer.CreateFormFile("file", "a.mp3")
+	part.Write([]byte("audio"))
+	writer.Close()
+	form, err := multipart.NewReader(&buf, writer.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := elevenlabs.NewClient(server.URL, "key")
+	body, _, err := client.CreateTranscription(context.Background(), &v1.TranscriptionRequest{
+		File: form.File["file"][0], Model: "whisper-1", ResponseFormat: "verbose_json",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp v1.TranscriptionVerboseResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Words) != 3 || len(resp.Segments) != 2 || resp.Segments[0].Text != "Hi there." || resp.Segments[1].Start != 1.0 || resp.Duration != 1.4 {
+		t.Errorf("response = %+v", resp)
+	}
+}
@@ -0,0 +1,98 @@
+package emulate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	oaiadapter "github.com/jiu-u/oai-adapter"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/emulate"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var passageRe = regexp.MustCompile(`\[(\d+)\] (.*)`)
+
+// newChatServer 将包含 "cat" 的段落排在最前, 记录每次请求的模型与段落数
+func newChatServer(models *[]string, sizes *[]int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req v1.ChatCompletionRequest
+		if r.URL.Path != "/v1/chat/completions" || json.NewDecoder(r.Body).Decode(&req) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		*models = append(*models, req.Model)
+		var first, rest []string
+		matches := passageRe.FindAllStringSubmatch(req.Messages[0].StringContent(), -1)
+		*sizes = append(*sizes, len(matches))
+		for _, m := range matches {
+			if strings.Contains(m[2], "cat") {
+				first = append(first, m[1])
+			} else {
+				rest = append(rest, m[1])
+			}
+		}
+		reply := fmt.Sprintf(`{"ranking": [%s]}`, strings.Join(append(first, rest...), ","))
+		resp := v1.ChatCompletionResponse{
+			Object:  "chat.completion",
+			Choices: []v1.Choice{{Message: v1.Message{Role: "assistant", Content: json.RawMessage(fmt.Sprintf("%q", reply))}}},
+			Usage:   v1.Usage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12},
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestChatRerankSlidingWindow(t *testing.T) {
+	emulate.ChatRerankWindowSize, emulate.ChatRerankStep = 3, 2
+	defer func() { emulate.ChatRerankWindowSize, emulate.ChatRerankStep = 20, 10 }()
+	var models []string
+	var sizes []int
+	server := newChatServer(&models, &sizes)
+	defer server.Close()
+
+	adapter := oaiadapter.NewAdapter(&oaiadapter.AdapterConfig{
+		AdapterType:     oaiadapter.OpenAI,
+		EndPoint:        server.URL,
+		Emulations:      []oaiadapter.Emulation{oaiadapter.EmulateChatRerank},
+		RerankChatModel: "gpt-4o-mini",
+	})
+	body, _, err := adapter.CreateRerank(context.Background(), &v1.RerankRequest{
+		Model: "rerank", Query: "cat", Documents: []string{"a", "b", "c", "d", "the cat"}, TopN: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp v1.RerankResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	// 最后一个文档经过两个窗口上浮到第一位
+	if len(resp.Results) != 2 || resp.Results[0].Index != 4 || resp.Results[0].RelevanceScore != 1 {
+		t.Errorf("results = %+v", resp.Results)
+	}
+	if !slices.Equal(sizes, []int{3, 3}) || models[0] != "gpt-4o-mini" || resp.Usage.TotalTokens != 24 {
+		t.Errorf("sizes = %v, models = %v, usage = %+v", sizes, models, resp.Usage)
+	}
+}
+
+func TestParseRanking(t *testing.T) {
+	tests := []struct {
+		reply string
+		want  []int
+	}{
+		{"```json\n{\"ranking\": [3, 1, 2]}\n```", []int{2, 0, 1}},
+		{"[2] > [3] > [1]", []int{1, 2, 0}},
+		{`[{"index":1,"score":0.2},{"index":3,"score":0.9}]`, []int{2, 0, 1}},
+		{"[3, 3, 9]", []int{2, 0, 1}},
+		{"I cannot decide.", []int{0, 1, 2}},
+	}
+	for _, tt := range tests {
+		if got := emulate.ParseRanking(tt.reply, 3); !slices.Equal(got, tt.want) {
+			t.Errorf("ParseRanking(%q) = %v, want %v", tt.reply, got, tt.want)
+		}
+	}
+}
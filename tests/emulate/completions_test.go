@@ -0,0 +1,100 @@
+package emulate
+
+import (
+	"context"
+	"encoding/json"
+	oaiadapter "github.com/jiu-u/oai-adapter"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newCompletionsAdapter(t *testing.T) (oaiadapter.Adapter, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req v1.ChatCompletionRequest
+		if r.URL.Path != "/v1/chat/completions" || json.NewDecoder(r.Body).Decode(&req) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if len(req.Messages) != 2 || req.Messages[0].Role != "system" || req.MaxTokens != 16 || req.N != 1 || req.UnknownFields["top_k"] == nil {
+			http.Error(w, "bad fields", http.StatusBadRequest)
+			return
+		}
+		if prompt := req.Messages[1].StringContent(); !strings.HasPrefix(prompt, "Once") && !strings.HasSuffix(prompt, "SUFFIX:\n}") {
+			http.Error(w, "bad prompt", http.StatusBadRequest)
+			return
+		}
+		if req.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			io.WriteString(w, `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","model":"m","choices":[{"index":0,"delta":{"role":"assistant","content":" upon"}}]}`+"\n\n")
+			io.WriteString(w, `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","model":"m","choices":[{"index":0,"delta":{"content":" a time"},"finish_reason":"stop"}]}`+"\n\n")
+			io.WriteString(w, "data: [DONE]\n\n")
+			return
+		}
+		io.WriteString(w, `{"id":"chatcmpl-1","object":"chat.completion","model":"m","choices":[{"index":0,"message":{"role":"assistant","content":" upon a time"},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":3,"total_tokens":8}}`)
+	}))
+	adapter := oaiadapter.NewAdapter(&oaiadapter.AdapterConfig{
+		AdapterType: oaiadapter.SiliconFlow,
+		EndPoint:    server.URL,
+		Emulations:  []oaiadapter.Emulation{oaiadapter.EmulateCompletions},
+	})
+	return adapter, server.Close
+}
+
+func TestCompletions(t *testing.T) {
+	adapter, closeFn := newCompletionsAdapter(t)
+	defer closeFn()
+
+	body, _, err := adapter.CreateCompletions(context.Background(), &v1.CompletionsRequest{
+		Model: "m", Prompt: "Once", Echo: true, MaxTokens: 16, N: float64(1), ExtraBody: map[string]any{"top_k": 5},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp v1.CompletionsResp
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Object != "text_completion" || resp.ID != "cmpl-1" || resp.Choices[0].Text != "Once upon a time" || resp.Usage.TotalTokens != 8 {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestCompletionsStream(t *testing.T) {
+	adapter, closeFn := newCompletionsAdapter(t)
+	defer closeFn()
+
+	body, header, err := adapter.CreateCompletions(context.Background(), &v1.CompletionsRequest{
+		Model: "m", Prompt: "func f() {", Suffix: "}", MaxTokens: 16, N: 1, Stream: true, ExtraBody: map[string]any{"top_k": 5},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("Content-Type") != "text/event-stream" {
+		t.Errorf("header = %v", header)
+	}
+	var text string
+	var done bool
+	err = base.ReadSSE(body, func(ev *base.SSEEvent) error {
+		if string(ev.Data) == "[DONE]" {
+			done = true
+			return nil
+		}
+		var chunk v1.CompletionsResp
+		if err := json.Unmarshal(ev.Data, &chunk); err != nil {
+			return err
+		}
+		if chunk.Object != "text_completion" {
+			t.Errorf("chunk = %s", ev.Data)
+		}
+		text += chunk.Choices[0].Text
+		return nil
+	})
+	if err != nil || !done || text != " upon a time" {
+		t.Errorf("text = %q, done = %v, err = %v", text, done, err)
+	}
+}
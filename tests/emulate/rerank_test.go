@@ -0,0 +1,88 @@
+package emulate
+
+import (
+	"context"
+	"encoding/json"
+	oaiadapter "github.com/jiu-u/oai-adapter"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/emulate"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// vectorOf 以是否包含关键词构造向量, 便于断言排序
+func vectorOf(text string) []any {
+	return []any{
+		boolFloat(strings.Contains(text, "cat")),
+		boolFloat(strings.Contains(text, "dog")),
+		0.1,
+	}
+}
+
+func boolFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func newEmbeddingServer(batches *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req v1.EmbeddingsRequest
+		if r.URL.Path != "/v1/embeddings" || json.NewDecoder(r.Body).Decode(&req) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		*batches++
+		inputs, _ := req.InputStrings()
+		resp := v1.EmbeddingsResponse{Object: "list", Model: req.Model, Usage: v1.Usage{PromptTokens: len(inputs), TotalTokens: len(inputs)}}
+		for i, input := range inputs {
+			resp.Data = append(resp.Data, v1.EmbeddingsData{Object: "embedding", Index: i, Embedding: vectorOf(input)})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestEmbeddingRerank(t *testing.T) {
+	emulate.EmbeddingBatchSize = 2
+	defer func() { emulate.EmbeddingBatchSize = 64 }()
+	var batches int
+	server := newEmbeddingServer(&batches)
+	defer server.Close()
+
+	adapter := oaiadapter.NewAdapter(&oaiadapter.AdapterConfig{
+		AdapterType: oaiadapter.OpenAI,
+		EndPoint:    server.URL,
+		Emulations:  []oaiadapter.Emulation{oaiadapter.EmulateEmbeddingRerank},
+	})
+	body, _, err := adapter.CreateRerank(context.Background(), &v1.RerankRequest{
+		Model: "text-embedding-3-small", Query: "cat", Documents: []string{"a dog", "the cat", "fish"}, TopN: 2, ReturnDocuments: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp v1.RerankResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != 2 || resp.Results[0].Index != 1 || resp.Results[0].Document.Text != "the cat" {
+		t.Errorf("results = %+v", resp.Results)
+	}
+	if batches != 2 || resp.Usage == nil || resp.Usage.TotalTokens != 4 {
+		t.Errorf("batches = %d, usage = %+v", batches, resp.Usage)
+	}
+}
+
+func TestChunkDocument(t *testing.T) {
+	emulate.RerankChunkSize = 4
+	defer func() { emulate.RerankChunkSize = 1024 }()
+	chunks := emulate.ChunkDocument("abcdefghij", 3, 1)
+	if len(chunks) != 3 || chunks[0] != "abcd" || chunks[1] != "defg" || chunks[2] != "ghij" {
+		t.Errorf("chunks = %v", chunks)
+	}
+	if chunks = emulate.ChunkDocument("abcdefghij", 0, 0); len(chunks) != 1 {
+		t.Errorf("chunks = %v", chunks)
+	}
+}
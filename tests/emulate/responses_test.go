@@ -0,0 +1,315 @@
+package emulate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	oaiadapter "github.com/jiu-u/oai-adapter"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"github.com/jiu-u/oai-adapter/pkg/store"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newResponsesAdapter(t *testing.T) (oaiadapter.Adapter, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req v1.ChatCompletionRequest
+		if r.URL.Path != "/v1/chat/completions" || json.NewDecoder(r.Body).Decode(&req) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if len(req.Messages) != 4 || req.Messages[0].Role != "system" || req.Messages[2].ToolCalls[0].Id != "call_1" ||
+			req.Messages[3].Role != "tool" || req.Messages[3].ToolCallId != "call_1" ||
+			len(req.Tools) != 1 || req.ResponseFormat == nil || req.ResponseFormat.JsonSchema.Name != "answer" ||
+			string(req.UnknownFields["reasoning_effort"].(json.RawMessage)) != `"high"` || req.MaxTokens != 64 {
+			http.Error(w, "bad fields", http.StatusBadRequest)
+			return
+		}
+		if req.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			io.WriteString(w, `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"role":"assistant","content":"It is"}}]}`+"\n\n")
+			io.WriteString(w, `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"content":" sunny"}}]}`+"\n\n")
+			io.WriteString(w, `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_2","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`+"\n\n")
+			io.WriteString(w, `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":\"Paris\"}"}}]},"finish_reason":"tool_calls"}]}`+"\n\n")
+			io.WriteString(w, `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"m","choices":[],"usage":{"prompt_tokens":20,"completion_tokens":6,"total_tokens":26}}`+"\n\n")
+			io.WriteString(w, "data: [DONE]\n\n")
+			return
+		}
+		io.WriteString(w, `{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"m","choices":[{"index":0,"message":{"role":"assistant","content":"It is sunny","tool_calls":[{"id":"call_2","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":20,"completion_tokens":6,"total_tokens":26}}`)
+	}))
+	adapter := oaiadapter.NewAdapter(&oaiadapter.AdapterConfig{
+		AdapterType: oaiadapter.DeepSeek,
+		EndPoint:    server.URL,
+		Emulations:  []oaiadapter.Emulation{oaiadapter.EmulateResponses},
+	})
+	return adapter, server.Close
+}
+
+func newResponsesRequest(stream bool) *v1.ResponsesRequest {
+	return &v1.ResponsesRequest{
+		Model:        "m",
+		Instructions: "be brief",
+		Input: json.RawMessage(`[
+			{"role":"user","content":[{"type":"input_text","text":"weather?"}]},
+			{"type":"function_call","call_id":"call_1","name":"get_weather","arguments":"{\"city\":\"Rome\"}"},
+			{"type":"function_call_output","call_id":"call_1","output":"rain"}
+		]`),
+		Tools:           []v1.ResponsesTool{{Type: "function", Name: "get_weather", Parameters: map[string]any{"type": "object"}}},
+		Text:            &v1.ResponsesText{Format: &v1.ResponsesText{Type: "json_schema", Name: "answer", Schema: map[string]any{"type": "object"}}},
+		Reasoning:       &v1.Reasoning{Effort: "high"},
+		MaxOutputTokens: 64,
+		Stream:          stream,
+	}
+}
+
+func TestResponses(t *testing.T) {
+	adapter, closeFn := newResponsesAdapter(t)
+	defer closeFn()
+
+	body, _, err := adapter.CreateResponses(context.Background(), newResponsesRequest(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp v1.ResponsesResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(resp.ID, "resp_") || resp.Object != "response" || resp.Status != "completed" || resp.OutputText != "It is sunny" || resp.Usage.OutputTokens != 6 {
+		t.Errorf("response = %+v", resp)
+	}
+	if len(resp.Output) != 2 || resp.Output[0].Type != "message" || resp.Output[1].Type != "function_call" ||
+		resp.Output[1].CallId != "call_2" || resp.Output[1].Arguments != `{"city":"Paris"}` {
+		t.Errorf("output = %+v", resp.Output)
+	}
+}
+
+func TestResponsesStream(t *testing.T) {
+	adapter, closeFn := newResponsesAdapter(t)
+	defer closeFn()
+
+	body, header, err := adapter.CreateResponses(context.Background(), newResponsesRequest(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("Content-Type") != "text/event-stream" {
+		t.Errorf("header = %v", header)
+	}
+	var events []string
+	var text string
+	var completed *v1.ResponsesResponse
+	err = base.ReadSSE(body, func(ev *base.SSEEvent) error {
+		var event v1.ResponsesStreamEvent
+		if err := json.Unmarshal(ev.Data, &event); err != nil {
+			return err
+		}
+		if event.Type != ev.Event || event.SequenceNumber != len(events) {
+			t.Errorf("event = %s", ev.Data)
+		}
+		events = append(events, event.Type)
+		switch event.Type {
+		case v1.ResponseOutputTextDeltaEvent:
+			text += event.Delta
+		case v1.ResponseCompletedEvent:
+			completed = event.Response
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"response.created", "response.in_progress",
+		"response.output_item.added", "response.content_part.added",
+		"response.output_text.delta", "response.output_text.delta",
+		"response.output_text.done", "response.content_part.done", "response.output_item.done",
+		"response.output_item.added", "response.function_call_arguments.delta",
+		"response.function_call_arguments.done", "response.output_item.done",
+		"response.completed",
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("events = %v", events)
+	}
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Fatalf("events = %v", events)
+		}
+	}
+	if text != "It is sunny" || completed == nil || completed.OutputText != text || completed.Usage.TotalTokens != 26 ||
+		len(completed.Output) != 2 || completed.Output[1].Arguments != `{"city":"Paris"}` {
+		t.Errorf("completed = %+v", completed)
+	}
+}
+
+func TestResponsesPreviousResponseID(t *testing.T) {
+	var lastMessages []v1.Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req v1.ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		lastMessages = req.Messages
+		io.WriteString(w, `{"id":"chatcmpl-1","object":"chat.completion","model":"m","choices":[{"index":0,"message":{"role":"assistant","content":"reply"},"finish_reason":"stop"}]}`)
+	}))
+	defer server.Close()
+	responseStore, err := store.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	adapter := oaiadapter.NewAdapter(&oaiadapter.AdapterConfig{
+		AdapterType:   oaiadapter.XAI,
+		EndPoint:      server.URL,
+		Emulations:    []oaiadapter.Emulation{oaiadapter.EmulateResponses},
+		ResponseStore: responseStore,
+	})
+
+	var previousID string
+	for _, input := range []string{`"first"`, `"second"`, `"third"`} {
+		body, _, err := adapter.CreateResponses(context.Background(), &v1.ResponsesRequest{
+			Model: "m", Input: json.RawMessage(input), PreviousResponseId: previousID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var resp v1.ResponsesResponse
+		if err = json.NewDecoder(body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.PreviousResponseID != previousID {
+			t.Errorf("previous_response_id = %s", resp.PreviousResponseID)
+		}
+		previousID = resp.ID
+	}
+	// first, reply, second, reply, third
+	if len(lastMessages) != 5 || lastMessages[2].StringContent() != "second" || lastMessages[3].Role != "assistant" || lastMessages[3].StringContent() != "reply" {
+		t.Errorf("messages = %+v", lastMessages)
+	}
+	record, err := responseStore.Get(context.Background(), previousID)
+	if err != nil || record.Response.OutputText != "reply" || record.Input[0].StringContent() != "third" {
+		t.Errorf("record = %+v, err = %v", record, err)
+	}
+	noStore := false
+	body, _, err := adapter.CreateResponses(context.Background(), &v1.ResponsesRequest{Model: "m", Input: json.RawMessage(`"ephemeral"`), Store: &noStore})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ephemeral v1.ResponsesResponse
+	if err = json.NewDecoder(body).Decode(&ephemeral); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = responseStore.Get(context.Background(), ephemeral.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("store:false response was saved, err = %v", err)
+	}
+	if err = responseStore.Delete(context.Background(), previousID); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = adapter.CreateResponses(context.Background(), &v1.ResponsesRequest{Model: "m", Input: json.RawMessage(`"fourth"`), PreviousResponseId: previousID})
+	if !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestResponsesIncomplete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"id":"chatcmpl-1","object":"chat.completion","model":"m","choices":[{"index":0,"message":{"role":"assistant","content":"Once upon"},"finish_reason":"length"}]}`)
+	}))
+	defer server.Close()
+	adapter := oaiadapter.NewAdapter(&oaiadapter.AdapterConfig{
+		AdapterType: oaiadapter.DeepSeek,
+		EndPoint:    server.URL,
+		Emulations:  []oaiadapter.Emulation{oaiadapter.EmulateResponses},
+	})
+
+	body, _, err := adapter.CreateResponses(context.Background(), &v1.ResponsesRequest{Model: "m", Input: json.RawMessage(`"tell a story"`), MaxOutputTokens: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	respBytes, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(respBytes), `"incomplete_details":{"reason":"max_output_tokens"}`) || !strings.Contains(string(respBytes), `"status":"incomplete"`) {
+		t.Errorf("response = %s", respBytes)
+	}
+}
+
+func TestResponsesStreamInterleavedToolCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","model":"m","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":"}}]}}]}`+"\n\n")
+		io.WriteString(w, `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","model":"m","choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_2","type":"function","function":{"name":"get_time","arguments":"{}"}}]}}]}`+"\n\n")
+		io.WriteString(w, `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","model":"m","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]},"finish_reason":"tool_calls"}]}`+"\n\n")
+		io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+	adapter := oaiadapter.NewAdapter(&oaiadapter.AdapterConfig{
+		AdapterType: oaiadapter.DeepSeek,
+		EndPoint:    server.URL,
+		Emulations:  []oaiadapter.Emulation{oaiadapter.EmulateResponses},
+	})
+
+	body, _, err := adapter.CreateResponses(context.Background(), &v1.ResponsesRequest{Model: "m", Input: json.RawMessage(`"weather and time?"`), Stream: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []string
+	doneArguments := make(map[int]string)
+	err = base.ReadSSE(body, func(ev *base.SSEEvent) error {
+		var event v1.ResponsesStreamEvent
+		if err := json.Unmarshal(ev.Data, &event); err != nil {
+			return err
+		}
+		events = append(events, event.Type)
+		switch event.Type {
+		case v1.ResponseFunctionCallArgumentsDoneEvent:
+			doneArguments[*event.OutputIndex] = event.Arguments
+		case v1.ResponseOutputItemDoneEvent:
+			if event.Item.Arguments != doneArguments[*event.OutputIndex] {
+				t.Errorf("item = %+v", event.Item)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "response.created,response.in_progress," +
+		"response.output_item.added,response.function_call_arguments.delta," +
+		"response.output_item.added,response.function_call_arguments.delta," +
+		"response.function_call_arguments.delta," +
+		"response.function_call_arguments.done,response.output_item.done," +
+		"response.function_call_arguments.done,response.output_item.done," +
+		"response.completed"
+	if strings.Join(events, ",") != expected {
+		t.Errorf("events = %v", events)
+	}
+	if doneArguments[0] != `{"city":"Paris"}` || doneArguments[1] != `{}` {
+		t.Errorf("arguments = %v", doneArguments)
+	}
+}
+
+func TestResponsesStreamTruncated(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","model":"m","choices":[{"index":0,"delta":{"role":"assistant","content":"It is"}}]}`+"\n\n")
+	}))
+	defer server.Close()
+	adapter := oaiadapter.NewAdapter(&oaiadapter.AdapterConfig{
+		AdapterType: oaiadapter.DeepSeek,
+		EndPoint:    server.URL,
+		Emulations:  []oaiadapter.Emulation{oaiadapter.EmulateResponses},
+	})
+
+	body, _, err := adapter.CreateResponses(context.Background(), &v1.ResponsesRequest{Model: "m", Input: json.RawMessage(`"weather?"`), Stream: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(body)
+	if err != io.ErrUnexpectedEOF || strings.Contains(string(data), v1.ResponseCompletedEvent) {
+		t.Errorf("data = %s, err = %v", data, err)
+	}
+}
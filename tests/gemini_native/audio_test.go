@@ -0,0 +1,109 @@
+package gemini_native
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/gemini_native"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCreateSpeech(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req gemini_native.GenerateContentRequest
+		if r.URL.Path != "/v1beta/models/"+gemini_native.DefaultSpeechModel+":generateContent" || json.NewDecoder(r.Body).Decode(&req) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		config := req.GenerationConfig
+		if config.ResponseModalities[0] != "AUDIO" || config.SpeechConfig.VoiceConfig.PrebuiltVoiceConfig.VoiceName != "Puck" || req.Contents[0].Parts[0].Text != "Say cheerfully: hi" {
+			http.Error(w, "bad config", http.StatusBadRequest)
+			return
+		}
+		// 分为两个 part 的 6 字节 PCM
+		io.WriteString(w, `{"candidates":[{"content":{"parts":[{"inlineData":{"mimeType":"audio/L16;codec=pcm;rate=16000","data":"AQIDBA=="}},{"inlineData":{"mimeType":"audio/L16;codec=pcm;rate=16000","data":"BQY="}}]}}]}`)
+	}))
+	defer server.Close()
+
+	client := gemini_native.NewClient(server.URL, "key")
+	body, header, err := client.CreateSpeech(context.Background(), &v1.AudioSpeechRequest{
+		Model: "tts-1", Voice: "fable", Input: "hi", Instructions: "Say cheerfully", ResponseFormat: "wav",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(body)
+	if header.Get("Content-Type") != "audio/wav" || len(data) != 50 || string(data[:4]) != "RIFF" || data[24] != 0x80 || data[25] != 0x3e ||
+		!bytes.Equal(data[44:], []byte{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("header = %v, data = %v", header, data[:28])
+	}
+
+	if _, _, err = client.CreateSpeech(context.Background(), &v1.AudioSpeechRequest{Model: "tts-1", Input: "hi", ResponseFormat: "mp3"}); err == nil || !strings.Contains(err.Error(), "mp3") {
+		t.Errorf("mp3 err = %v", err)
+	}
+}
+
+func newAudioFile(t *testing.T) *multipart.FileHeader {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, _ := writer.CreateFormFile("file", "a.wav")
+	part.Write([]byte("audio"))
+	writer.Close()
+	form, err := multipart.NewReader(&buf, writer.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return form.File["file"][0]
+}
+
+func TestCreateTranscription(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req gemini_native.GenerateContentRequest
+		if json.NewDecoder(r.Body).Decode(&req) != nil || len(req.Contents[0].Parts) != 2 || req.Contents[0].Parts[1].InlineData == nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if req.GenerationConfig.ResponseSchema == nil {
+			io.WriteString(w, `{"candidates":[{"content":{"parts":[{"text":"Hello there. Bye."}]}}]}`)
+			return
+		}
+		io.WriteString(w, `{"candidates":[{"content":{"parts":[{"text":"{\"language\":\"en\",\"segments\":[{\"start\":0,\"end\":1.5,\"text\":\"Hello there.\"},{\"start\":61.25,\"end\":62,\"text\":\"Bye.\"}]}"}]}}]}`)
+	}))
+	defer server.Close()
+	client := gemini_native.NewClient(server.URL, "key")
+
+	body, _, err := client.CreateTranscription(context.Background(), &v1.TranscriptionRequest{File: newAudioFile(t), Model: "whisper-1", ResponseFormat: "text"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(body); string(data) != "Hello there. Bye." {
+		t.Errorf("text = %s", data)
+	}
+
+	body, _, err = client.CreateTranscription(context.Background(), &v1.TranscriptionRequest{File: newAudioFile(t), Model: "whisper-1", ResponseFormat: "verbose_json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp v1.TranscriptionVerboseResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Segments) != 2 || resp.Duration != 62 || resp.Language != "en" || resp.Text != "Hello there. Bye." {
+		t.Errorf("response = %+v", resp)
+	}
+
+	body, _, err = client.CreateTranslation(context.Background(), &v1.TranslationRequest{File: newAudioFile(t), Model: "whisper-1", ResponseFormat: "srt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(body)
+	if !strings.Contains(string(data), "2\n00:01:01,250 --> 00:01:02,000\nBye.") {
+		t.Errorf("srt = %s", data)
+	}
+}
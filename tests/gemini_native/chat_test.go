@@ -0,0 +1,129 @@
+package gemini_native
+
+import (
+	"context"
+	"encoding/json"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/gemini_native"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConvertChatRequest(t *testing.T) {
+	req := &v1.ChatCompletionRequest{
+		Model: "gemini-2.5-flash",
+		Messages: []v1.Message{
+			{Role: "system", Content: json.RawMessage(`"be brief"`)},
+			{Role: "user", Content: json.RawMessage(`[{"type":"text","text":"look"},{"type":"image_url","image_url":{"url":"data:image/png;base64,aGVsbG8="}}]`)},
+			{Role: "assistant", Content: json.RawMessage(`null`), ToolCalls: []v1.ToolCall{
+				{Id: "call_1", Type: "function", Function: v1.Function{Name: "weather", Arguments: `{"city":"Paris"}`}},
+				{Id: "call_2", Type: "function", Function: v1.Function{Name: "time", Arguments: `{}`}},
+			}},
+			{Role: "tool", ToolCallId: "call_1", Content: json.RawMessage(`"sunny"`)},
+			{Role: "tool", ToolCallId: "call_2", Content: json.RawMessage(`"{\"hour\":12}"`)},
+		},
+		ToolChoice: map[string]any{"type": "function", "function": map[string]any{"name": "weather"}},
+		Tools: []v1.Tool{
+			{Type: "function", Function: v1.Function{Name: "weather", Parameters: json.RawMessage(`{"type":"object","additionalProperties":false,"properties":{"city":{"type":["string","null"]}}}`)}},
+		},
+		ResponseFormat: &v1.ResponseFormat{Type: "json_schema", JsonSchema: &v1.FormatJsonSchema{Name: "x", Schema: map[string]any{"$schema": "x", "type": "object"}}},
+	}
+	newReq, err := gemini_native.ConvertChatRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newReq.SystemInstruction == nil || newReq.SystemInstruction.Parts[0].Text != "be brief" {
+		t.Errorf("systemInstruction = %+v", newReq.SystemInstruction)
+	}
+	if len(newReq.Contents) != 3 {
+		t.Fatalf("contents = %d", len(newReq.Contents))
+	}
+	if newReq.Contents[0].Parts[1].InlineData == nil || newReq.Contents[0].Parts[1].InlineData.MimeType != "image/png" {
+		t.Errorf("image part = %+v", newReq.Contents[0].Parts[1])
+	}
+	results := newReq.Contents[2].Parts
+	if len(results) != 2 || results[0].FunctionResponse.Name != "weather" || string(results[0].FunctionResponse.Response) != `{"content":"sunny"}` {
+		t.Errorf("function responses = %+v", results)
+	}
+	if string(results[1].FunctionResponse.Response) != `{"hour":12}` {
+		t.Errorf("object response = %s", results[1].FunctionResponse.Response)
+	}
+	config := newReq.ToolConfig.FunctionCallingConfig
+	if config.Mode != "ANY" || config.AllowedFunctionNames[0] != "weather" {
+		t.Errorf("toolConfig = %+v", config)
+	}
+	params, _ := json.Marshal(newReq.Tools[0].FunctionDeclarations[0].Parameters)
+	if string(params) != `{"properties":{"city":{"nullable":true,"type":"string"}},"type":"object"}` {
+		t.Errorf("parameters = %s", params)
+	}
+	schema, _ := json.Marshal(newReq.GenerationConfig.ResponseSchema)
+	if newReq.GenerationConfig.ResponseMimeType != "application/json" || string(schema) != `{"type":"object"}` {
+		t.Errorf("responseSchema = %s", schema)
+	}
+}
+
+func TestCreateChatCompletionsStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1beta/models/gemini-2.5-flash:streamGenerateContent" || r.URL.Query().Get("alt") != "sse" || r.Header.Get("x-goog-api-key") != "key" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, `data: {"candidates":[{"content":{"role":"model","parts":[{"text":"Hel"}]},"index":0}],"modelVersion":"gemini-2.5-flash","responseId":"r1"}`+"\r\n\r\n")
+		io.WriteString(w, `data: {"candidates":[{"content":{"role":"model","parts":[{"text":"lo"},{"functionCall":{"name":"weather","args":{"city":"Paris"}}}]},"finishReason":"STOP","index":0}],"usageMetadata":{"promptTokenCount":5,"candidatesTokenCount":3,"thoughtsTokenCount":2,"totalTokenCount":10},"modelVersion":"gemini-2.5-flash","responseId":"r1"}`+"\r\n\r\n")
+	}))
+	defer server.Close()
+
+	client := gemini_native.NewClient(server.URL, "key")
+	body, _, err := client.CreateChatCompletions(context.Background(), &v1.ChatCompletionRequest{
+		Model:         "gemini-2.5-flash",
+		Stream:        true,
+		StreamOptions: &v1.StreamOptions{IncludeUsage: true},
+		Messages:      []v1.Message{{Role: "user", Content: json.RawMessage(`"hello"`)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content, finishReason string
+	var toolCalls []v1.ToolCall
+	var usage v1.Usage
+	for _, line := range strings.Split(string(data), "\n\n") {
+		payload, ok := strings.CutPrefix(line, "data: ")
+		if !ok || payload == "[DONE]" {
+			continue
+		}
+		var chunk v1.ChatCompletionStreamResponse
+		if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+			t.Fatal(err)
+		}
+		if chunk.ID != "chatcmpl-r1" {
+			t.Errorf("id = %s", chunk.ID)
+		}
+		if chunk.Usage.TotalTokens > 0 {
+			usage = chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			content += choice.Delta.Content
+			toolCalls = append(toolCalls, choice.Delta.ToolCalls...)
+			if choice.FinishReason != "" {
+				finishReason = choice.FinishReason
+			}
+		}
+	}
+	if content != "Hello" || finishReason != "tool_calls" {
+		t.Errorf("content=%q finish=%q", content, finishReason)
+	}
+	if len(toolCalls) != 1 || toolCalls[0].Function.Arguments != `{"city":"Paris"}` || toolCalls[0].Index == nil || toolCalls[0].Id == "" {
+		t.Errorf("tool_calls = %+v", toolCalls)
+	}
+	if usage.CompletionTokens != 5 || usage.TotalTokens != 10 || usage.CompletionTokensDetails.ReasoningTokens != 2 {
+		t.Errorf("usage = %+v", usage)
+	}
+}
@@ -0,0 +1,129 @@
+package gemini_native
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	oaiadapter "github.com/jiu-u/oai-adapter"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/gemini_native"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUploadLargeInlineData(t *testing.T) {
+	gemini_native.FilePollInterval = 10 * time.Millisecond
+	var uploads, polls int
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/upload/v1beta/files", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Goog-Upload-Command") != "start" || r.Header.Get("X-Goog-Upload-Header-Content-Type") != "image/png" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("X-Goog-Upload-URL", server.URL+"/upload-session")
+	})
+	mux.HandleFunc("/upload-session", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		if r.ContentLength != 2048 || len(data) != 2048 || r.Header.Get("X-Goog-Upload-Command") != "upload, finalize" {
+			http.Error(w, "bad upload", http.StatusBadRequest)
+			return
+		}
+		uploads++
+		io.WriteString(w, `{"file":{"name":"files/abc","mimeType":"image/png","uri":"https://files/abc","state":"PROCESSING"}}`)
+	})
+	mux.HandleFunc("/v1beta/files/abc", func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		io.WriteString(w, `{"name":"files/abc","mimeType":"image/png","uri":"https://files/abc","state":"ACTIVE"}`)
+	})
+	mux.HandleFunc("/v1beta/models/gemini-2.5-flash:generateContent", func(w http.ResponseWriter, r *http.Request) {
+		var req gemini_native.GenerateContentRequest
+		if json.NewDecoder(r.Body).Decode(&req) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		parts := req.Contents[0].Parts
+		if len(parts) != 3 || parts[1].FileData == nil || parts[1].FileData.FileUri != "https://files/abc" || parts[1].InlineData != nil || parts[2].InlineData == nil {
+			http.Error(w, "bad parts", http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]},"finishReason":"STOP"}]}`)
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	client := gemini_native.NewClient(server.URL, "key")
+	client.SetInlineLimit(1024)
+	large := base64.StdEncoding.EncodeToString(make([]byte, 2048))
+	small := base64.StdEncoding.EncodeToString(make([]byte, 16))
+	content := `[{"type":"text","text":"describe"},` +
+		`{"type":"image_url","image_url":{"url":"data:image/png;base64,` + large + `"}},` +
+		`{"type":"image_url","image_url":{"url":"data:image/png;base64,` + small + `"}}]`
+	for i := 0; i < 2; i++ {
+		body, _, err := client.CreateChatCompletions(context.Background(), &v1.ChatCompletionRequest{
+			Model:    "gemini-2.5-flash",
+			Messages: []v1.Message{{Role: "user", Content: json.RawMessage(content)}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, _ := io.ReadAll(body); !strings.Contains(string(data), `"ok"`) {
+			t.Errorf("response = %s", data)
+		}
+	}
+	if uploads != 1 || polls != 1 {
+		t.Errorf("uploads = %d, polls = %d", uploads, polls)
+	}
+}
+
+func TestGeminiOAILargeInlineData(t *testing.T) {
+	var paths []string
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/upload/v1beta/files", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Goog-Upload-URL", server.URL+"/upload-session")
+	})
+	mux.HandleFunc("/upload-session", func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		io.WriteString(w, `{"file":{"name":"files/abc","mimeType":"audio/wav","uri":"https://files/abc","state":"ACTIVE"}}`)
+	})
+	mux.HandleFunc("/v1beta/models/gemini-2.5-flash:generateContent", func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		io.WriteString(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]},"finishReason":"STOP"}]}`)
+	})
+	mux.HandleFunc("/v1beta/openai/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		io.WriteString(w, `{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	client := oaiadapter.NewAdapter(&oaiadapter.AdapterConfig{
+		AdapterType:       oaiadapter.Gemini,
+		EndPoint:          server.URL,
+		ApiKey:            "key",
+		GeminiInlineLimit: 1024,
+	})
+	for _, size := range []int{16, 2048} {
+		audio := base64.StdEncoding.EncodeToString(make([]byte, size))
+		content := `[{"type":"input_audio","input_audio":{"data":"` + audio + `","format":"wav"}}]`
+		body, _, err := client.CreateChatCompletions(context.Background(), &v1.ChatCompletionRequest{
+			Model:    "gemini-2.5-flash",
+			Messages: []v1.Message{{Role: "user", Content: json.RawMessage(content)}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, _ := io.ReadAll(body); !strings.Contains(string(data), `"ok"`) {
+			t.Errorf("response = %s", data)
+		}
+	}
+	want := []string{"/v1beta/openai/chat/completions", "/upload-session", "/v1beta/models/gemini-2.5-flash:generateContent"}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+}
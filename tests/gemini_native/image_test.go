@@ -0,0 +1,82 @@
+package gemini_native
+
+import (
+	"context"
+	"encoding/json"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/gemini_native"
+	"github.com/jiu-u/oai-adapter/clients/gemini_oai"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCreateImagen(t *testing.T) {
+	var counts []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req gemini_native.PredictRequest
+		if r.URL.Path != "/v1beta/models/imagen-4.0-generate-001:predict" || r.Header.Get("x-goog-api-key") != "key" || json.NewDecoder(r.Body).Decode(&req) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if req.Parameters.AspectRatio != "16:9" || req.Parameters.OutputOptions == nil || req.Parameters.OutputOptions.MimeType != "image/jpeg" {
+			http.Error(w, "bad parameters", http.StatusBadRequest)
+			return
+		}
+		counts = append(counts, req.Parameters.SampleCount)
+		predictions := make([]gemini_native.ImagenPrediction, req.Parameters.SampleCount)
+		for i := range predictions {
+			predictions[i] = gemini_native.ImagenPrediction{BytesBase64Encoded: "aW1n", MimeType: "image/jpeg"}
+		}
+		json.NewEncoder(w).Encode(gemini_native.PredictResponse{Predictions: predictions})
+	}))
+	defer server.Close()
+
+	// OpenAI 兼容客户端同样支持
+	client := gemini_oai.NewClient(server.URL, "key")
+	body, _, err := client.CreateImage(context.Background(), &v1.ImageGenerateRequest{
+		Model: "imagen-4.0-generate-001", Prompt: "cat", N: 6, Size: "1792x1024", OutputFormat: "jpeg",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp v1.ImageGenerateResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 6 || resp.Data[0].B64JSON != "aW1n" || len(counts) != 2 || counts[0] != 4 || counts[1] != 2 {
+		t.Errorf("response = %+v, counts = %v", resp, counts)
+	}
+}
+
+func TestCreateGeminiImage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req gemini_native.GenerateContentRequest
+		if r.URL.Path != "/v1beta/models/gemini-2.5-flash-image:generateContent" || json.NewDecoder(r.Body).Decode(&req) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if req.GenerationConfig.ImageConfig == nil || req.GenerationConfig.ImageConfig.AspectRatio != "2:3" {
+			http.Error(w, "bad config", http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":"A cat"},{"inlineData":{"mimeType":"image/png","data":"cG5n"}}]}}]}`)
+	}))
+	defer server.Close()
+
+	client := gemini_native.NewClient(server.URL, "key")
+	body, _, err := client.CreateImage(context.Background(), &v1.ImageGenerateRequest{
+		Model: "gemini-2.5-flash-image", Prompt: "cat", Size: "1024x1536",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp v1.ImageGenerateResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].B64JSON != "cG5n" || resp.Data[0].RevisedPrompt != "A cat" {
+		t.Errorf("response = %+v", resp)
+	}
+}
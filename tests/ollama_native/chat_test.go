@@ -0,0 +1,137 @@
+package ollama_native
+
+import (
+	"context"
+	"encoding/json"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/ollama_native"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConvertChatRequest(t *testing.T) {
+	req := &v1.ChatCompletionRequest{
+		Model:       "llama3.2",
+		Temperature: 0.5,
+		Messages: []v1.Message{
+			{Role: "user", Content: json.RawMessage(`[{"type":"text","text":"look"},{"type":"image_url","image_url":{"url":"data:image/png;base64,aGVsbG8="}}]`)},
+			{Role: "assistant", Content: json.RawMessage(`null`), ToolCalls: []v1.ToolCall{
+				{Id: "call_1", Type: "function", Function: v1.Function{Name: "weather", Arguments: `{"city":"Paris"}`}},
+			}},
+			{Role: "tool", ToolCallId: "call_1", Content: json.RawMessage(`"sunny"`)},
+		},
+		Tools:          []v1.Tool{{Type: "function", Function: v1.Function{Name: "weather", Parameters: json.RawMessage(`{"type":"object"}`)}}},
+		ResponseFormat: &v1.ResponseFormat{Type: "json_schema", JsonSchema: &v1.FormatJsonSchema{Name: "x", Schema: map[string]any{"type": "object"}}},
+	}
+	newReq, err := ollama_native.ConvertChatRequest(req, &ollama_native.Options{NumCtx: 8192, TopK: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(newReq.Messages) != 3 || newReq.Messages[0].Content != "look" || newReq.Messages[0].Images[0] != "aGVsbG8=" {
+		t.Fatalf("messages = %+v", newReq.Messages)
+	}
+	if string(newReq.Messages[1].ToolCalls[0].Function.Arguments) != `{"city":"Paris"}` {
+		t.Errorf("tool_calls = %+v", newReq.Messages[1].ToolCalls)
+	}
+	if newReq.Messages[2].ToolName != "weather" || newReq.Messages[2].Content != "sunny" {
+		t.Errorf("tool message = %+v", newReq.Messages[2])
+	}
+	if string(newReq.Format) != `{"type":"object"}` || len(newReq.Tools) != 1 {
+		t.Errorf("format = %s tools = %d", newReq.Format, len(newReq.Tools))
+	}
+	if newReq.Options.NumCtx != 8192 || newReq.Options.TopK != 20 || *newReq.Options.Temperature != 0.5 {
+		t.Errorf("options = %+v", newReq.Options)
+	}
+}
+
+func TestCreateChatCompletionsStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollama_native.ChatRequest
+		if r.URL.Path != "/api/chat" || json.NewDecoder(r.Body).Decode(&req) != nil || req.KeepAlive != "10m" || !req.Stream {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		io.WriteString(w, `{"model":"llama3.2","created_at":"2024-01-01T00:00:00Z","message":{"role":"assistant","content":"Hel"},"done":false}`+"\n")
+		io.WriteString(w, `{"model":"llama3.2","created_at":"2024-01-01T00:00:00Z","message":{"role":"assistant","content":"lo","tool_calls":[{"function":{"name":"weather","arguments":{"city":"Paris"}}}]},"done":false}`+"\n")
+		io.WriteString(w, `{"model":"llama3.2","created_at":"2024-01-01T00:00:00Z","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":5,"eval_count":3}`+"\n")
+	}))
+	defer server.Close()
+
+	client := ollama_native.NewClientWithOptions(server.URL, "", &ollama_native.Options{NumCtx: 4096}, "10m")
+	body, _, err := client.CreateChatCompletions(context.Background(), &v1.ChatCompletionRequest{
+		Model:         "llama3.2",
+		Stream:        true,
+		StreamOptions: &v1.StreamOptions{IncludeUsage: true},
+		Messages:      []v1.Message{{Role: "user", Content: json.RawMessage(`"hello"`)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content, finishReason string
+	var toolCalls []v1.ToolCall
+	var usage v1.Usage
+	for _, line := range strings.Split(string(data), "\n\n") {
+		payload, ok := strings.CutPrefix(line, "data: ")
+		if !ok || payload == "[DONE]" {
+			continue
+		}
+		var chunk v1.ChatCompletionStreamResponse
+		if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+			t.Fatal(err)
+		}
+		if chunk.Usage.TotalTokens > 0 {
+			usage = chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			content += choice.Delta.Content
+			toolCalls = append(toolCalls, choice.Delta.ToolCalls...)
+			if choice.FinishReason != "" {
+				finishReason = choice.FinishReason
+			}
+		}
+	}
+	if content != "Hello" || finishReason != "tool_calls" {
+		t.Errorf("content=%q finish=%q", content, finishReason)
+	}
+	if len(toolCalls) != 1 || toolCalls[0].Function.Arguments != `{"city":"Paris"}` || toolCalls[0].Index == nil {
+		t.Errorf("tool_calls = %+v", toolCalls)
+	}
+	if usage.PromptTokens != 5 || usage.TotalTokens != 8 {
+		t.Errorf("usage = %+v", usage)
+	}
+	if !strings.HasSuffix(string(data), "data: [DONE]\n\n") {
+		t.Errorf("missing [DONE]: %q", data)
+	}
+}
+
+func TestConvertChatRequestOllamaOptions(t *testing.T) {
+	var req v1.ChatCompletionRequest
+	data := `{"model":"llama3.2","messages":[],"temperature":0.2,"num_ctx":16384,"keep_alive":-1,"extra_body":{"top_k":40,"options":{"min_p":0.05}}}`
+	if err := json.Unmarshal([]byte(data), &req); err != nil {
+		t.Fatal(err)
+	}
+	newReq, err := ollama_native.ConvertChatRequest(&req, &ollama_native.Options{NumCtx: 8192, NumGpu: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := newReq.Options
+	if options.NumCtx != 16384 || options.TopK != 40 || options.MinP != 0.05 || options.NumGpu != 1 || *options.Temperature != 0.2 {
+		t.Errorf("options = %+v", options)
+	}
+	if newReq.KeepAlive != "-1s" {
+		t.Errorf("keep_alive = %s", newReq.KeepAlive)
+	}
+
+	req.ExtraBody = map[string]any{"keep_alive": "10m"}
+	if newReq, err = ollama_native.ConvertChatRequest(&req, nil); err != nil || newReq.KeepAlive != "10m" {
+		t.Errorf("keep_alive = %s, err = %v", newReq.KeepAlive, err)
+	}
+}
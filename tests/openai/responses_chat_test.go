@@ -0,0 +1,232 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	oaiadapter "github.com/jiu-u/oai-adapter"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/base"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newResponsesChatAdapter(t *testing.T) (oaiadapter.Adapter, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req v1.ResponsesRequest
+		reqBytes, _ := io.ReadAll(r.Body)
+		if r.URL.Path != "/v1/responses" || json.Unmarshal(reqBytes, &req) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		// chat 请求不应在上游保存对话
+		if !bytes.Contains(reqBytes, []byte(`"store":false`)) {
+			http.Error(w, "store not disabled", http.StatusBadRequest)
+			return
+		}
+		inputs, err := req.ParseInput()
+		if err != nil || len(inputs) != 4 || inputs[0].Role != "system" ||
+			inputs[2].Type != "function_call" || inputs[2].CallId != "call_1" || inputs[2].ID != "" ||
+			inputs[3].Type != "function_call_output" || inputs[3].Output != "rain" ||
+			len(req.Tools) != 1 || req.Tools[0].Name != "get_weather" || string(req.ToolChoice) != `{"name":"get_weather","type":"function"}` ||
+			req.Text == nil || req.Text.Format.Name != "answer" || req.MaxOutputTokens != 64 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"error":{"message":"bad fields"}}`)
+			return
+		}
+		if req.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			io.WriteString(w, "event: response.created\ndata: {\"type\":\"response.created\",\"sequence_number\":0,\"response\":{\"id\":\"resp_1\",\"object\":\"response\",\"created_at\":1,\"model\":\"o3-pro\",\"status\":\"in_progress\"}}\n\n")
+			io.WriteString(w, "event: response.output_text.delta\ndata: {\"type\":\"response.output_text.delta\",\"sequence_number\":1,\"item_id\":\"msg_1\",\"output_index\":0,\"content_index\":0,\"delta\":\"Checking\"}\n\n")
+			io.WriteString(w, "event: response.output_item.added\ndata: {\"type\":\"response.output_item.added\",\"sequence_number\":2,\"output_index\":1,\"item\":{\"id\":\"fc_1\",\"type\":\"function_call\",\"call_id\":\"call_2\",\"name\":\"get_weather\",\"arguments\":\"\",\"status\":\"in_progress\"}}\n\n")
+			io.WriteString(w, "event: response.function_call_arguments.delta\ndata: {\"type\":\"response.function_call_arguments.delta\",\"sequence_number\":3,\"item_id\":\"fc_1\",\"output_index\":1,\"delta\":\"{\\\"city\\\":\"}\n\n")
+			io.WriteString(w, "event: response.function_call_arguments.delta\ndata: {\"type\":\"response.function_call_arguments.delta\",\"sequence_number\":4,\"item_id\":\"fc_1\",\"output_index\":1,\"delta\":\"\\\"Paris\\\"}\"}\n\n")
+			io.WriteString(w, "event: response.completed\ndata: {\"type\":\"response.completed\",\"sequence_number\":5,\"response\":{\"id\":\"resp_1\",\"object\":\"response\",\"created_at\":1,\"model\":\"o3-pro\",\"status\":\"completed\",\"usage\":{\"input_tokens\":20,\"output_tokens\":6,\"total_tokens\":26}}}\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id":"resp_1","object":"response","created_at":1,"model":"o3-pro","status":"completed","output":[
+			{"id":"rs_1","type":"reasoning","summary":[]},
+			{"id":"msg_1","type":"message","role":"assistant","status":"completed","content":[{"type":"output_text","text":"Checking","annotations":[]}]},
+			{"id":"fc_1","type":"function_call","status":"completed","call_id":"call_2","name":"get_weather","arguments":"{\"city\":\"Paris\"}"}
+		],"usage":{"input_tokens":20,"output_tokens":6,"total_tokens":26}}`)
+	}))
+	adapter := oaiadapter.NewAdapter(&oaiadapter.AdapterConfig{
+		AdapterType:     oaiadapter.OpenAI,
+		EndPoint:        server.URL,
+		ResponsesModels: []string{"o3-pro"},
+	})
+	return adapter, server.Close
+}
+
+func newResponsesChatRequest(stream bool) *v1.ChatCompletionRequest {
+	var req v1.ChatCompletionRequest
+	_ = json.Unmarshal([]byte(`{
+		"model": "o3-pro",
+		"messages": [
+			{"role": "system", "content": "be brief"},
+			{"role": "user", "content": [{"type": "text", "text": "weather?"}]},
+			{"role": "assistant", "content": null, "tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Rome\"}"}}]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "rain"}
+		],
+		"tools": [{"type": "function", "function": {"name": "get_weather", "parameters": {"type": "object"}}}],
+		"tool_choice": {"type": "function", "function": {"name": "get_weather"}},
+		"response_format": {"type": "json_schema", "json_schema": {"name": "answer", "schema": {"type": "object"}}},
+		"max_completion_tokens": 64,
+		"stream_options": {"include_usage": true}
+	}`), &req)
+	req.Stream = stream
+	return &req
+}
+
+func TestChatOverResponses(t *testing.T) {
+	adapter, closeFn := newResponsesChatAdapter(t)
+	defer closeFn()
+
+	body, _, err := adapter.CreateChatCompletions(context.Background(), newResponsesChatRequest(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp v1.ChatCompletionResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	choice := resp.Choices[0]
+	if resp.ID != "chatcmpl-1" || resp.Object != "chat.completion" || choice.FinishReason != "tool_calls" ||
+		choice.Message.StringContent() != "Checking" || resp.Usage.TotalTokens != 26 {
+		t.Errorf("response = %+v", resp)
+	}
+	if len(choice.Message.ToolCalls) != 1 || choice.Message.ToolCalls[0].Id != "call_2" || choice.Message.ToolCalls[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("tool_calls = %+v", choice.Message.ToolCalls)
+	}
+}
+
+func TestChatOverResponsesStream(t *testing.T) {
+	adapter, closeFn := newResponsesChatAdapter(t)
+	defer closeFn()
+
+	body, header, err := adapter.CreateChatCompletions(context.Background(), newResponsesChatRequest(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("Content-Type") != "text/event-stream" {
+		t.Errorf("header = %v", header)
+	}
+	var text, arguments, finishReason string
+	var usage v1.Usage
+	var done bool
+	err = base.ReadSSE(body, func(ev *base.SSEEvent) error {
+		if string(ev.Data) == "[DONE]" {
+			done = true
+			return nil
+		}
+		var chunk v1.ChatCompletionStreamResponse
+		if err := json.Unmarshal(ev.Data, &chunk); err != nil {
+			return err
+		}
+		if chunk.Object != "chat.completion.chunk" || chunk.ID != "chatcmpl-1" {
+			t.Errorf("chunk = %s", ev.Data)
+		}
+		if len(chunk.Choices) == 0 {
+			usage = chunk.Usage
+			return nil
+		}
+		delta := chunk.Choices[0].Delta
+		text += delta.Content
+		for _, toolCall := range delta.ToolCalls {
+			if toolCall.Index == nil || *toolCall.Index != 0 {
+				t.Errorf("tool call = %s", ev.Data)
+			}
+			arguments += toolCall.Function.Arguments
+		}
+		if chunk.Choices[0].FinishReason != "" {
+			finishReason = chunk.Choices[0].FinishReason
+		}
+		return nil
+	})
+	if err != nil || !done {
+		t.Fatalf("done = %v, err = %v", done, err)
+	}
+	if text != "Checking" || arguments != `{"city":"Paris"}` || finishReason != "tool_calls" || usage.TotalTokens != 26 {
+		t.Errorf("text = %q, arguments = %q, finish_reason = %q, usage = %+v", text, arguments, finishReason, usage)
+	}
+}
+
+func TestChatOverResponsesIncomplete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req v1.ResponsesRequest
+		if json.NewDecoder(r.Body).Decode(&req) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		incomplete := `{"id":"resp_1","object":"response","model":"o3-pro","status":"incomplete","incomplete_details":{"reason":"max_output_tokens"},"usage":{"input_tokens":5,"output_tokens":16,"total_tokens":21}}`
+		if req.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			io.WriteString(w, "event: response.created\ndata: {\"type\":\"response.created\",\"sequence_number\":0,\"response\":{\"id\":\"resp_1\",\"object\":\"response\",\"model\":\"o3-pro\",\"status\":\"in_progress\"}}\n\n")
+			io.WriteString(w, "event: response.output_text.delta\ndata: {\"type\":\"response.output_text.delta\",\"sequence_number\":1,\"item_id\":\"msg_1\",\"output_index\":0,\"content_index\":0,\"delta\":\"Once upon\"}\n\n")
+			io.WriteString(w, "event: response.incomplete\ndata: {\"type\":\"response.incomplete\",\"sequence_number\":2,\"response\":"+incomplete+"}\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, incomplete[:len(incomplete)-1]+`,"output":[{"id":"msg_1","type":"message","role":"assistant","status":"incomplete","content":[{"type":"output_text","text":"Once upon","annotations":[]}]}]}`)
+	}))
+	defer server.Close()
+	adapter := oaiadapter.NewAdapter(&oaiadapter.AdapterConfig{AdapterType: oaiadapter.OpenAI, EndPoint: server.URL, ResponsesModels: []string{"*"}})
+	message := v1.Message{Role: "user"}
+	message.SetStringContent("tell a story")
+
+	body, _, err := adapter.CreateChatCompletions(context.Background(), &v1.ChatCompletionRequest{Model: "o3-pro", Messages: []v1.Message{message}, MaxTokens: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp v1.ChatCompletionResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Choices[0].FinishReason != "length" || resp.Choices[0].Message.StringContent() != "Once upon" {
+		t.Errorf("response = %+v", resp)
+	}
+
+	body, _, err = adapter.CreateChatCompletions(context.Background(), &v1.ChatCompletionRequest{Model: "o3-pro", Messages: []v1.Message{message}, MaxTokens: 16, Stream: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var finishReason string
+	err = base.ReadSSE(body, func(ev *base.SSEEvent) error {
+		var chunk v1.ChatCompletionStreamResponse
+		if string(ev.Data) == "[DONE]" || json.Unmarshal(ev.Data, &chunk) != nil || len(chunk.Choices) == 0 {
+			return nil
+		}
+		if chunk.Choices[0].FinishReason != "" {
+			finishReason = chunk.Choices[0].FinishReason
+		}
+		return nil
+	})
+	if err != nil || finishReason != "length" {
+		t.Errorf("finish_reason = %q, err = %v", finishReason, err)
+	}
+}
+
+func TestChatOverResponsesStreamTruncated(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "event: response.created\ndata: {\"type\":\"response.created\",\"sequence_number\":0,\"response\":{\"id\":\"resp_1\",\"object\":\"response\",\"model\":\"o3-pro\",\"status\":\"in_progress\"}}\n\n")
+		io.WriteString(w, "event: response.output_text.delta\ndata: {\"type\":\"response.output_text.delta\",\"sequence_number\":1,\"item_id\":\"msg_1\",\"output_index\":0,\"content_index\":0,\"delta\":\"Once\"}\n\n")
+	}))
+	defer server.Close()
+	adapter := oaiadapter.NewAdapter(&oaiadapter.AdapterConfig{AdapterType: oaiadapter.OpenAI, EndPoint: server.URL, ResponsesModels: []string{"*"}})
+	message := v1.Message{Role: "user"}
+	message.SetStringContent("tell a story")
+
+	body, _, err := adapter.CreateChatCompletions(context.Background(), &v1.ChatCompletionRequest{Model: "o3-pro", Messages: []v1.Message{message}, Stream: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(body)
+	if err != io.ErrUnexpectedEOF || bytes.Contains(data, []byte("[DONE]")) {
+		t.Errorf("data = %s, err = %v", data, err)
+	}
+}
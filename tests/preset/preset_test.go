@@ -0,0 +1,124 @@
+package preset
+
+import (
+	"context"
+	"errors"
+	oaiadapter "github.com/jiu-u/oai-adapter"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/preset"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const presetYAML = `
+presets:
+  - name: Acme
+    prefix: /v1
+    paths:
+      completions: /beta/completions
+    operations: [chat, completions, models]
+    headers:
+      X-Acme-Client: oai-adapter
+`
+
+func TestLoadFileAndRoute(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" || r.Header.Get("X-Acme-Client") != "oai-adapter" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		paths = append(paths, r.URL.Path)
+		io.WriteString(w, `{"object":"list","data":[{"id":"acme-1","object":"model"}]}`)
+	}))
+	defer server.Close()
+
+	file := filepath.Join(t.TempDir(), "presets.yaml")
+	if err := os.WriteFile(file, []byte(presetYAML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := preset.LoadFile(file); err != nil {
+		t.Fatal(err)
+	}
+
+	client := oaiadapter.NewAdapter(&oaiadapter.AdapterConfig{AdapterType: "acme", EndPoint: server.URL, ApiKey: "key"})
+	ctx := context.Background()
+	body, _, err := client.CreateChatCompletions(ctx, &v1.ChatCompletionRequest{Model: "acme-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body.Close()
+	body, _, err = client.CreateCompletions(ctx, &v1.CompletionsRequest{Model: "acme-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body.Close()
+	models, err := client.Models(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models.Data) != 1 || models.Data[0].ID != "acme-1" {
+		t.Errorf("models = %+v", models)
+	}
+	if _, _, err = client.CreateEmbeddings(ctx, &v1.EmbeddingsRequest{Model: "acme-1"}); !errors.Is(err, v1.NoImplementError) {
+		t.Errorf("embeddings should not be implemented, err = %v", err)
+	}
+
+	want := []string{"/v1/chat/completions", "/beta/completions", "/v1/models"}
+	if len(paths) != len(want) {
+		t.Fatalf("paths = %v", paths)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("paths[%d] = %s, want %s", i, paths[i], want[i])
+		}
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	for _, name := range []string{"DeepSeek", "XAI", "SiliconFlow", "Groq", "Together", "Fireworks", "OpenRouter", "Mistral", "Moonshot", "Perplexity"} {
+		if _, ok := preset.Get(name); !ok {
+			t.Errorf("builtin preset %s not registered", name)
+		}
+	}
+	if _, err := preset.Parse([]byte(`[{"name":"bad","operations":["fly"]}]`), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := preset.Register(&preset.Preset{Name: "bad", Operations: []string{"fly"}}); err == nil {
+		t.Error("unknown operation should be rejected")
+	}
+}
+
+func TestDeepSeekPreset(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		io.WriteString(w, `{"object":"list","data":[]}`)
+	}))
+	defer server.Close()
+
+	client := oaiadapter.NewAdapter(&oaiadapter.AdapterConfig{AdapterType: oaiadapter.DeepSeek, EndPoint: server.URL, ApiKey: "key"})
+	ctx := context.Background()
+	body, _, err := client.CreateChatCompletions(ctx, &v1.ChatCompletionRequest{Model: "deepseek-chat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body.Close()
+	body, _, err = client.CreateCompletions(ctx, &v1.CompletionsRequest{Model: "deepseek-chat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body.Close()
+	if _, _, err = client.CreateImage(ctx, &v1.ImageGenerateRequest{Model: "deepseek-chat"}); !errors.Is(err, v1.NoImplementError) {
+		t.Errorf("image should not be implemented, err = %v", err)
+	}
+
+	want := []string{"/v1/chat/completions", "/beta/completions"}
+	if len(paths) != len(want) || paths[0] != want[0] || paths[1] != want[1] {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+}
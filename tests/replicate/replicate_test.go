@@ -0,0 +1,113 @@
+package replicate
+
+import (
+	"context"
+	"encoding/json"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/replicate"
+	"github.com/jiu-u/oai-adapter/pkg/task"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newServer(finalStatus string) *httptest.Server {
+	polls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/models/black-forest-labs/flux-schnell", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"owner":"black-forest-labs","name":"flux-schnell","latest_version":{"id":"v123"}}`)
+	})
+	mux.HandleFunc("/v1/predictions", func(w http.ResponseWriter, r *http.Request) {
+		var req replicate.PredictionRequest
+		if json.NewDecoder(r.Body).Decode(&req) != nil || req.Version != "v123" || req.Input["width"] != float64(512) {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, `{"id":"p1","status":"starting"}`)
+	})
+	mux.HandleFunc("/v1/predictions/p1", func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		if polls < 2 {
+			io.WriteString(w, `{"id":"p1","status":"processing"}`)
+			return
+		}
+		if finalStatus == "failed" {
+			io.WriteString(w, `{"id":"p1","status":"failed","error":"NSFW"}`)
+			return
+		}
+		io.WriteString(w, `{"id":"p1","status":"succeeded","output":["https://example.com/1.webp"]}`)
+	})
+	return httptest.NewServer(mux)
+}
+
+func newClient(server *httptest.Server) *replicate.Client {
+	tm := task.NewTaskManager(time.Minute, 10*time.Millisecond, 20*time.Millisecond)
+	client := replicate.NewClient(server.URL, "key")
+	client.SetTaskManager(tm)
+	replicate.WaitInterval = 10 * time.Millisecond
+	return client
+}
+
+func TestCreateImage(t *testing.T) {
+	server := newServer("succeeded")
+	defer server.Close()
+	client := newClient(server)
+
+	body, _, err := client.CreateImage(context.Background(), &v1.ImageGenerateRequest{Model: "black-forest-labs/flux-schnell", Prompt: "a cat", Size: "512x512"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp v1.ImageGenerateResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].URL != "https://example.com/1.webp" {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestCreateImageFailed(t *testing.T) {
+	server := newServer("failed")
+	defer server.Close()
+	client := newClient(server)
+
+	_, _, err := client.CreateImage(context.Background(), &v1.ImageGenerateRequest{Model: "black-forest-labs/flux-schnell", Prompt: "a cat", Size: "512x512"})
+	if err == nil {
+		t.Fatal("failed prediction should return error")
+	}
+}
+
+func TestCreateImageContextCanceled(t *testing.T) {
+	server := newServer("succeeded")
+	defer server.Close()
+	client := newClient(server)
+	replicate.WaitInterval = time.Second
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, _, err := client.CreateImage(ctx, &v1.ImageGenerateRequest{Model: "black-forest-labs/flux-schnell", Prompt: "a cat", Size: "512x512"})
+	if err != context.DeadlineExceeded {
+		t.Errorf("err = %v, want deadline exceeded", err)
+	}
+}
+
+func TestGetVideoStatusFailed(t *testing.T) {
+	server := newServer("failed")
+	defer server.Close()
+	client := newClient(server)
+
+	completed, _, err := client.GetVideoStatus(context.Background(), "p1")
+	if completed || err != nil {
+		t.Fatalf("completed = %v, err = %v", completed, err)
+	}
+	completed, data, err := client.GetVideoStatus(context.Background(), "p1")
+	if !completed || err != nil {
+		t.Fatalf("completed = %v, err = %v", completed, err)
+	}
+	status := data.(*v1.VideoStatusResponse)
+	if status.Status != "Failed" || status.Reason != "NSFW" {
+		t.Errorf("status = %+v", status)
+	}
+}
@@ -0,0 +1,100 @@
+package stability
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/stability"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConvertAspectRatio(t *testing.T) {
+	cases := map[string]string{
+		"1024x1024": "1:1",
+		"1792x1024": "16:9",
+		"1024x1792": "9:16",
+		"1536x1024": "3:2",
+		"3:2":       "3:2",
+		"auto":      "",
+	}
+	for size, want := range cases {
+		if got := stability.ConvertAspectRatio(size); got != want {
+			t.Errorf("ConvertAspectRatio(%s) = %s, want %s", size, got, want)
+		}
+	}
+}
+
+func fileHeader(t *testing.T, name, content string) *multipart.FileHeader {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, _ := writer.CreateFormFile(name, name+".png")
+	part.Write([]byte(content))
+	writer.Close()
+	form, err := multipart.NewReader(&buf, writer.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return form.File[name][0]
+}
+
+func TestGenerateAndInpaint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "application/json" || r.ParseMultipartForm(1<<20) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2beta/stable-image/generate/sd3":
+			if r.FormValue("model") != "sd3.5-large" || r.FormValue("aspect_ratio") != "16:9" || r.FormValue("output_format") != "webp" {
+				http.Error(w, "bad fields", http.StatusBadRequest)
+				return
+			}
+		case "/v2beta/stable-image/edit/inpaint":
+			if r.MultipartForm.File["image"] == nil || r.MultipartForm.File["mask"] == nil || r.FormValue("prompt") != "add a hat" {
+				http.Error(w, "bad fields", http.StatusBadRequest)
+				return
+			}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, `{"image":"aW1n","finish_reason":"SUCCESS","seed":1}`)
+	}))
+	defer server.Close()
+	client := stability.NewClient(server.URL, "key")
+
+	body, _, err := client.CreateImage(context.Background(), &v1.ImageGenerateRequest{Model: "sd3.5-large", Prompt: "a cat", Size: "1792x1024", OutputFormat: "webp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp v1.ImageGenerateResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].URL != "data:image/webp;base64,aW1n" {
+		t.Errorf("response = %+v", resp)
+	}
+
+	body, _, err = client.CreateImageEdit(context.Background(), &v1.ImageEditRequest{
+		Model:          "stable-image-core",
+		Prompt:         "add a hat",
+		Image:          []*multipart.FileHeader{fileHeader(t, "image", "img")},
+		Mask:           fileHeader(t, "mask", "mask"),
+		ResponseFormat: "b64_json",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp = v1.ImageGenerateResponse{}
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].B64JSON != "aW1n" {
+		t.Errorf("response = %+v", resp)
+	}
+}
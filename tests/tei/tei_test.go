@@ -0,0 +1,113 @@
+package tei
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/tei"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/embed", func(w http.ResponseWriter, r *http.Request) {
+		var req tei.EmbedRequest
+		if json.NewDecoder(r.Body).Decode(&req) != nil || len(req.Inputs) != 2 || !req.Normalize {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("X-Compute-Tokens", "7")
+		io.WriteString(w, `[[0.6,0.8,0,0],[0,0,0.6,0.8]]`)
+	})
+	mux.HandleFunc("/rerank", func(w http.ResponseWriter, r *http.Request) {
+		var req tei.RerankRequest
+		if json.NewDecoder(r.Body).Decode(&req) != nil || req.Query != "q" || len(req.Texts) != 3 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, `[{"index":1,"score":0.2},{"index":2,"score":0.9},{"index":0,"score":0.5}]`)
+	})
+	mux.HandleFunc("/info", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"model_id":"BAAI/bge-m3","max_input_length":8192}`)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestCreateEmbeddings(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client := tei.NewClient(server.URL, "")
+
+	body, _, err := client.CreateEmbeddings(context.Background(), &v1.EmbeddingsRequest{
+		Model: "bge-m3", Input: []any{"a", "b"}, Dimensions: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp v1.EmbeddingsResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 2 || len(resp.Data[0].Embedding) != 1 || resp.Data[0].Embedding[0] != 1.0 || resp.Usage.TotalTokens != 7 {
+		t.Errorf("response = %+v", resp)
+	}
+
+	body, _, err = client.CreateEmbeddings(context.Background(), &v1.EmbeddingsRequest{
+		Model: "bge-m3", Input: []any{"a", "b"}, EncodingFormat: "base64",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b64Resp struct {
+		Data []struct {
+			Embedding string `json:"embedding"`
+		} `json:"data"`
+	}
+	if err = json.NewDecoder(body).Decode(&b64Resp); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(b64Resp.Data[1].Embedding)
+	if err != nil || len(raw) != 16 {
+		t.Fatalf("raw = %v, err = %v", raw, err)
+	}
+	if v := math.Float32frombits(uint32(raw[12]) | uint32(raw[13])<<8 | uint32(raw[14])<<16 | uint32(raw[15])<<24); v != 0.8 {
+		t.Errorf("last value = %v", v)
+	}
+}
+
+func TestCreateRerank(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client := tei.NewClient(server.URL, "")
+
+	body, _, err := client.CreateRerank(context.Background(), &v1.RerankRequest{
+		Model: "bge-reranker", Query: "q", Documents: []string{"d0", "d1", "d2"}, TopN: 2, ReturnDocuments: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp v1.RerankResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != 2 || resp.Results[0].Index != 2 || resp.Results[0].Document.Text != "d2" || resp.Results[1].Index != 0 {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestModels(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	resp, err := tei.NewClient(server.URL, "").Models(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].ID != "BAAI/bge-m3" {
+		t.Errorf("response = %+v", resp)
+	}
+}
@@ -0,0 +1,113 @@
+package vertex
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/vertex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServiceAccountChat(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyBytes, _ := x509.MarshalPKCS8PrivateKey(key)
+	pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}))
+
+	tokenCalls := 0
+	var chatPaths []string
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenCalls++
+		_ = r.ParseForm()
+		parts := strings.Split(r.PostForm.Get("assertion"), ".")
+		if len(parts) != 3 {
+			http.Error(w, "bad assertion", http.StatusBadRequest)
+			return
+		}
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		claimsBytes, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		var claims map[string]any
+		_ = json.Unmarshal(claimsBytes, &claims)
+		if claims["iss"] != "sa@proj.iam.gserviceaccount.com" || claims["aud"] != server.URL+"/token" {
+			http.Error(w, "bad claims", http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(w, `{"access_token":"ya29.test","expires_in":3600,"token_type":"Bearer"}`)
+	})
+	mux.HandleFunc("/v1/projects/proj/locations/us-central1/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ya29.test" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		chatPaths = append(chatPaths, r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, ":generateContent") {
+			io.WriteString(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":"hi"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":1,"candidatesTokenCount":1,"totalTokenCount":2}}`)
+			return
+		}
+		io.WriteString(w, `{"id":"chatcmpl-1","object":"chat.completion","choices":[]}`)
+	})
+
+	saJson, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"project_id":   "proj",
+		"private_key":  pemKey,
+		"client_email": "sa@proj.iam.gserviceaccount.com",
+		"token_uri":    server.URL + "/token",
+	})
+	sa, err := vertex.ParseServiceAccount(saJson)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := vertex.NewClientWithServiceAccount(server.URL, "us-central1", sa)
+
+	messages := []v1.Message{{Role: "user", Content: json.RawMessage(`"hello"`)}}
+	body, _, err := client.CreateChatCompletions(context.Background(), &v1.ChatCompletionRequest{Model: "gemini-2.0-flash", Messages: messages})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp v1.ChatCompletionResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Choices) != 1 || resp.Choices[0].Message.StringContent() != "hi" {
+		t.Errorf("response = %+v", resp)
+	}
+
+	body, _, err = client.CreateChatCompletions(context.Background(), &v1.ChatCompletionRequest{Model: "google/gemini-2.0-flash", Messages: messages})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body.Close()
+
+	want := []string{
+		"/v1/projects/proj/locations/us-central1/publishers/google/models/gemini-2.0-flash:generateContent",
+		"/v1/projects/proj/locations/us-central1/endpoints/openapi/chat/completions",
+	}
+	if strings.Join(chatPaths, ",") != strings.Join(want, ",") {
+		t.Errorf("paths = %v", chatPaths)
+	}
+	if tokenCalls != 1 {
+		t.Errorf("token should be cached, calls = %d", tokenCalls)
+	}
+}
@@ -0,0 +1,115 @@
+package vllm
+
+import (
+	"context"
+	"encoding/json"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/vllm"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newServer 记录上游收到的请求体
+func newServer(got *map[string]any) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || json.NewDecoder(r.Body).Decode(got) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, `{"id":"1","object":"chat.completion","choices":[]}`)
+	}))
+}
+
+func TestExtraBodyRoundTrip(t *testing.T) {
+	var req v1.ChatCompletionRequest
+	data := `{"model":"m","messages":[],"top_k":20,"seed":9007199254740993,"extra_body":{"min_p":0.05}}`
+	if err := json.Unmarshal([]byte(data), &req); err != nil {
+		t.Fatal(err)
+	}
+	if len(req.ExtraBody) != 1 || req.ExtraBody["min_p"] == nil || len(req.UnknownFields) != 1 || req.UnknownFields["top_k"] == nil {
+		t.Fatalf("extra body = %v, unknown fields = %v", req.ExtraBody, req.UnknownFields)
+	}
+	out, err := json.Marshal(&req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]json.RawMessage
+	if err = json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	// 未声明的顶层字段默认不发往上游
+	if got["top_k"] != nil || string(got["min_p"]) != "0.05" || got["extra_body"] != nil {
+		t.Errorf("marshaled = %s", out)
+	}
+
+	var body map[string]any
+	server := newServer(&body)
+	defer server.Close()
+	if _, _, err = vllm.NewClient(server.URL, "").CreateChatCompletions(context.Background(), &req); err != nil {
+		t.Fatal(err)
+	}
+	if body["top_k"] != float64(20) || body["min_p"] != 0.05 {
+		t.Errorf("vllm body = %v", body)
+	}
+}
+
+func TestGuidedDecoding(t *testing.T) {
+	var got map[string]any
+	server := newServer(&got)
+	defer server.Close()
+
+	schema := map[string]any{"type": "object"}
+	req := &v1.ChatCompletionRequest{
+		Model:          "qwen",
+		ResponseFormat: &v1.ResponseFormat{Type: "json_schema", JsonSchema: &v1.FormatJsonSchema{Name: "s", Schema: schema}},
+		ExtraBody:      map[string]any{"repetition_penalty": 1.1, "grammar": "root ::= x"},
+	}
+	if _, _, err := vllm.NewClient(server.URL, "").CreateChatCompletions(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	// 已显式指定 grammar 时不再转换 schema
+	if got["guided_grammar"] != "root ::= x" || got["grammar"] != nil || got["guided_json"] != nil || got["response_format"] != nil || got["repetition_penalty"] != 1.1 {
+		t.Errorf("vllm body = %v", got)
+	}
+	if req.ExtraBody["grammar"] == nil || req.ResponseFormat == nil {
+		t.Error("request should not be modified")
+	}
+
+	delete(req.ExtraBody, "grammar")
+	got = nil
+	if _, _, err := vllm.NewClient(server.URL, "").CreateChatCompletions(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if got["guided_json"] == nil || got["response_format"] != nil {
+		t.Errorf("vllm body = %v", got)
+	}
+
+	got = nil
+	req.ExtraBody = map[string]any{"guided_regex": "[a-z]+"}
+	req.ResponseFormat = nil
+	if _, _, err := vllm.NewLlamaCppClient(server.URL, "").CreateChatCompletions(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if got["guided_regex"] != "[a-z]+" {
+		t.Errorf("llama.cpp body = %v", got)
+	}
+}
+
+func TestLlamaCppJsonSchema(t *testing.T) {
+	var got map[string]any
+	server := newServer(&got)
+	defer server.Close()
+
+	req := &v1.ChatCompletionRequest{
+		Model:          "llama",
+		ResponseFormat: &v1.ResponseFormat{Type: "json_schema", JsonSchema: &v1.FormatJsonSchema{Name: "s", Schema: map[string]any{"type": "string"}}},
+	}
+	if _, _, err := vllm.NewLlamaCppClient(server.URL, "").CreateChatCompletions(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if schema, ok := got["json_schema"].(map[string]any); !ok || schema["type"] != "string" || got["response_format"] != nil {
+		t.Errorf("llama.cpp body = %v", got)
+	}
+}
@@ -0,0 +1,120 @@
+package zhipu
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	v1 "github.com/jiu-u/oai-adapter/api/v1"
+	"github.com/jiu-u/oai-adapter/clients/zhipu"
+	"github.com/jiu-u/oai-adapter/pkg/task"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func verifyToken(auth string) bool {
+	parts := strings.Split(strings.TrimPrefix(auth, "Bearer "), ".")
+	if len(parts) != 3 {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if base64.RawURLEncoding.EncodeToString(mac.Sum(nil)) != parts[2] {
+		return false
+	}
+	claimsBytes, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims struct {
+		ApiKey string `json:"api_key"`
+		Exp    int64  `json:"exp"`
+	}
+	_ = json.Unmarshal(claimsBytes, &claims)
+	// exp 以毫秒为单位
+	return claims.ApiKey == "id" && claims.Exp > time.Now().Add(time.Minute).UnixMilli()
+}
+
+func TestChatWithJWT(t *testing.T) {
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !verifyToken(auth) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		tokens = append(tokens, auth)
+		var req zhipu.ChatRequest
+		if r.URL.Path != "/api/paas/v4/chat/completions" || json.NewDecoder(r.Body).Decode(&req) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if len(req.Tools) != 1 || req.Tools[0].Type != "web_search" || req.DoSample == nil || *req.DoSample || req.RequestId != "req-1" {
+			http.Error(w, "bad fields", http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, `{"id":"1","object":"chat.completion","choices":[]}`)
+	}))
+	defer server.Close()
+
+	client := zhipu.NewClient(server.URL, "id.secret")
+	req := &v1.ChatCompletionRequest{
+		Model:            "glm-4-plus",
+		Messages:         []v1.Message{{Role: "user", Content: json.RawMessage(`"hello"`)}},
+		MetaData:         map[string]any{"do_sample": false, "request_id": "req-1"},
+		WebSearchOptions: map[string]any{},
+	}
+	for i := 0; i < 2; i++ {
+		body, _, err := client.CreateChatCompletions(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(body)
+		if !strings.Contains(string(data), "chat.completion") {
+			t.Fatalf("response = %s", data)
+		}
+	}
+	if len(tokens) != 2 || tokens[0] != tokens[1] {
+		t.Errorf("token should be cached, tokens = %v", tokens)
+	}
+}
+
+func TestVideoPolling(t *testing.T) {
+	polls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/paas/v4/videos/generations", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"id":"task-1","model":"cogvideox-2","task_status":"PROCESSING"}`)
+	})
+	mux.HandleFunc("/api/paas/v4/async-result/task-1", func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		if polls < 2 {
+			io.WriteString(w, `{"task_status":"PROCESSING"}`)
+			return
+		}
+		io.WriteString(w, `{"task_status":"SUCCESS","video_result":[{"url":"https://example.com/v.mp4"}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tm := task.NewTaskManager(time.Minute, 10*time.Millisecond, 20*time.Millisecond)
+	defer tm.Close()
+	client := zhipu.NewClient(server.URL, "id.secret")
+	client.SetTaskManager(tm)
+
+	resp, err := client.CreateVideoSubmit(context.Background(), &v1.VideoRequest{Model: "cogvideox-2", Prompt: "a cat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	result, err := task.Wait(ctx, tm, resp.RequestId, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, ok := result.Data.(*v1.VideoStatusResponse)
+	if result.Status != task.StatusCompleted || !ok || status.Status != "Succeed" || status.Results[0].Videos[0].Url != "https://example.com/v.mp4" {
+		t.Errorf("result = %+v", result)
+	}
+}
@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+)
+
+// PCMToWav 为 16bit 小端 PCM 数据添加 44 字节的 WAV 头
+func PCMToWav(pcm []byte, sampleRate, channels int) []byte {
+	const bitsPerSample = 16
+	blockAlign := channels * bitsPerSample / 8
+	buf := make([]byte, 44+len(pcm))
+	copy(buf[0:], "RIFF")
+	binary.LittleEndian.PutUint32(buf[4:], uint32(36+len(pcm)))
+	copy(buf[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(buf[16:], 16)
+	binary.LittleEndian.PutUint16(buf[20:], 1) // PCM
+	binary.LittleEndian.PutUint16(buf[22:], uint16(channels))
+	binary.LittleEndian.PutUint32(buf[24:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(buf[28:], uint32(sampleRate*blockAlign))
+	binary.LittleEndian.PutUint16(buf[32:], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(buf[34:], bitsPerSample)
+	copy(buf[36:], "data")
+	binary.LittleEndian.PutUint32(buf[40:], uint32(len(pcm)))
+	copy(buf[44:], pcm)
+	return buf
+}
+
+// ParsePCMRate 从 audio/L16;codec=pcm;rate=24000 形式的 MIME 类型中解析采样率
+func ParsePCMRate(mimeType string, defaultRate int) int {
+	for _, param := range strings.Split(mimeType, ";") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(param), "rate="); ok {
+			if rate, err := strconv.Atoi(value); err == nil && rate > 0 {
+				return rate
+			}
+		}
+	}
+	return defaultRate
+}
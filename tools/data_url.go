@@ -0,0 +1,24 @@
+package tools
+
+import "strings"
+
+// ParseDataURL 解析 data:{mime};base64,{data} 形式的地址, 返回 MIME 类型与 base64 数据
+func ParseDataURL(url string) (mimeType string, data string, ok bool) {
+	if !strings.HasPrefix(url, "data:") {
+		return "", "", false
+	}
+	meta, data, found := strings.Cut(url[len("data:"):], ",")
+	if !found {
+		return "", "", false
+	}
+	meta, isBase64 := strings.CutSuffix(meta, ";base64")
+	if !isBase64 {
+		return "", "", false
+	}
+	return meta, data, true
+}
+
+// ToDataURL 将 base64 数据组装为 data URL
+func ToDataURL(mimeType, data string) string {
+	return "data:" + mimeType + ";base64," + data
+}